@@ -1,10 +1,13 @@
 package main
 
 import (
-	"log"
+	"fmt"
+	"log/slog"
 	"os"
+	"runtime"
 
 	"github.com/pascalwhoop/ghospel/internal/cli"
+	urfavecli "github.com/urfave/cli/v2"
 )
 
 // Version information injected at build time by GoReleaser
@@ -17,7 +20,18 @@ var (
 func main() {
 	app := cli.NewApp()
 	app.Version = version
+
+	// Print full build metadata so bug reports can pin the exact build
+	urfavecli.VersionPrinter = func(c *urfavecli.Context) {
+		fmt.Fprintf(c.App.Writer, "ghospel %s\n", version)
+		fmt.Fprintf(c.App.Writer, "  commit:   %s\n", commit)
+		fmt.Fprintf(c.App.Writer, "  built:    %s\n", date)
+		fmt.Fprintf(c.App.Writer, "  go:       %s\n", runtime.Version())
+		fmt.Fprintf(c.App.Writer, "  platform: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	}
+
 	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+		slog.Error(err.Error())
+		os.Exit(cli.ExitCode(err))
 	}
 }
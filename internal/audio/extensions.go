@@ -0,0 +1,41 @@
+package audio
+
+import (
+	"slices"
+	"strings"
+)
+
+// DefaultExtensions lists the file extensions treated as audio when no
+// others are configured. ffmpeg reads many more, they can be added with the
+// audio_extensions setting.
+var DefaultExtensions = []string{".mp3", ".m4a", ".wav", ".flac", ".mp4", ".aac", ".ogg", ".opus", ".mkv"}
+
+// NormalizeExtensions lowercases extensions, adds missing leading dots and
+// drops empty entries and duplicates
+func NormalizeExtensions(exts []string) []string {
+	var normalized []string
+	for _, ext := range exts {
+		ext = strings.ToLower(strings.TrimSpace(ext))
+		if ext == "" || ext == "." {
+			continue
+		}
+
+		if !strings.HasPrefix(ext, ".") {
+			ext = "." + ext
+		}
+
+		if !slices.Contains(normalized, ext) {
+			normalized = append(normalized, ext)
+		}
+	}
+
+	return normalized
+}
+
+// ParseExtensions parses a comma or space separated list of extensions such
+// as "opus, .wma amr"
+func ParseExtensions(value string) []string {
+	return NormalizeExtensions(strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t'
+	}))
+}
@@ -0,0 +1,137 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Chapter is a titled section of an audio file
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration
+	Title string
+}
+
+// chapterRegex matches ffmpeg's description of an input chapter, e.g.
+// "Chapter #0:1: start 312.500000, end 640.000000"
+var chapterRegex = regexp.MustCompile(`^Chapter #0:\d+: start (-?[\d.]+), end (-?[\d.]+)`)
+
+// Chapters lists the chapters stored in the input, such as those of an
+// audiobook or podcast episode, from its header. Inputs without chapters
+// return none.
+func (p *Processor) Chapters(inputPath string) ([]Chapter, error) {
+	if _, err := os.Stat(inputPath); err != nil {
+		return nil, fmt.Errorf("input file does not exist: %s", inputPath)
+	}
+
+	// ffmpeg exits non-zero without an output file, the header is still printed
+	output, err := exec.Command(p.ffmpegPath, "-hide_banner", "-i", inputPath).CombinedOutput()
+	if notStarted(err) {
+		return nil, fmt.Errorf("failed to run ffmpeg: %w", err)
+	}
+
+	return parseChapters(string(output)), nil
+}
+
+// parseChapters extracts the input chapters and their titles from ffmpeg's
+// log. Chapters without a title get an empty one.
+func parseChapters(output string) []Chapter {
+	var chapters []Chapter
+
+	inChapter := false
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+
+		// Streams and outputs follow the chapters
+		if strings.HasPrefix(line, "Stream #") || strings.HasPrefix(line, "Output #") {
+			inChapter = false
+			continue
+		}
+
+		if match := chapterRegex.FindStringSubmatch(line); match != nil {
+			chapters = append(chapters, Chapter{Start: parseSeconds(match[1]), End: parseSeconds(match[2])})
+			inChapter = true
+
+			continue
+		}
+
+		if key, value, ok := strings.Cut(line, ":"); ok && inChapter && strings.TrimSpace(key) == "title" {
+			chapters[len(chapters)-1].Title = strings.TrimSpace(value)
+		}
+	}
+
+	return chapters
+}
+
+// EmbedMetadata writes a copy of the input to outputPath with the given
+// metadata tags and chapters added. Audio streams are copied, not
+// re-encoded, and existing tags are kept unless overwritten.
+func (p *Processor) EmbedMetadata(ctx context.Context, inputPath, outputPath string, tags map[string]string, chapters []Chapter) error {
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return fmt.Errorf("input file does not exist: %s", inputPath)
+	}
+
+	args := []string{"-i", inputPath}
+
+	if len(chapters) > 0 {
+		tempDir, err := p.tempDir()
+		if err != nil {
+			return err
+		}
+
+		metadataPath := filepath.Join(tempDir, strings.TrimSuffix(filepath.Base(outputPath), filepath.Ext(outputPath))+".ffmetadata")
+		if err := os.WriteFile(metadataPath, []byte(ffmetadata(chapters)), 0o644); err != nil {
+			return fmt.Errorf("failed to write chapter metadata: %w", err)
+		}
+		defer os.Remove(metadataPath)
+
+		args = append(args, "-i", metadataPath, "-map_chapters", "1")
+	}
+
+	args = append(args, "-map", "0", "-map_metadata", "0", "-c", "copy")
+	for key, value := range tags {
+		args = append(args, "-metadata", key+"="+value)
+	}
+
+	if strings.EqualFold(filepath.Ext(outputPath), ".mp3") {
+		// ID3v2.3 is what most players and podcast apps read
+		args = append(args, "-id3v2_version", "3")
+	}
+
+	args = append(args, "-y", outputPath)
+
+	output, err := exec.CommandContext(ctx, p.ffmpegPath, args...).CombinedOutput()
+	if err != nil {
+		os.Remove(outputPath)
+
+		return fmt.Errorf("ffmpeg failed to write metadata: %w\nOutput: %s", err, string(output))
+	}
+
+	return nil
+}
+
+// ffmetadata renders chapters in ffmpeg's metadata file format
+func ffmetadata(chapters []Chapter) string {
+	var content strings.Builder
+
+	content.WriteString(";FFMETADATA1\n")
+
+	for _, chapter := range chapters {
+		fmt.Fprintf(&content, "\n[CHAPTER]\nTIMEBASE=1/1000\nSTART=%d\nEND=%d\ntitle=%s\n",
+			chapter.Start.Milliseconds(), chapter.End.Milliseconds(), escapeMetadata(chapter.Title))
+	}
+
+	return content.String()
+}
+
+// escapeMetadata escapes the characters with a special meaning in ffmetadata
+// files
+func escapeMetadata(value string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, ";", `\;`, "#", `\#`, "\n", "\\\n").Replace(value)
+}
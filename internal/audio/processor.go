@@ -1,54 +1,344 @@
 package audio
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
 // Processor handles audio file processing and conversion
 type Processor struct {
 	ffmpegPath string
-	tempDir    string
+	baseDir    string
+
+	// workDir is this processor's own directory below baseDir, created on
+	// first use so concurrent ghospel processes never share files
+	mu      sync.Mutex
+	workDir string
 }
 
-// NewProcessor creates a new audio processor
+// NewProcessor creates a new audio processor keeping its intermediate files
+// in a directory of its own below tempDir. Call Close to remove it.
 func NewProcessor(ffmpegPath, tempDir string) *Processor {
 	if ffmpegPath == "" {
 		ffmpegPath = "ffmpeg" // Default to system ffmpeg
 	}
 
 	if tempDir == "" {
-		tempDir = "/tmp/ghospel"
+		tempDir = filepath.Join(os.TempDir(), "ghospel")
 	}
 
-	// Ensure temp directory exists
-	os.MkdirAll(tempDir, 0o755)
-
 	return &Processor{
 		ffmpegPath: ffmpegPath,
-		tempDir:    tempDir,
+		baseDir:    tempDir,
 	}
 }
 
-// ConvertToWav converts an audio file to 16kHz mono WAV format required by Whisper
-func (p *Processor) ConvertToWav(inputPath string) (string, error) {
-	// Generate output filename
-	inputBase := filepath.Base(inputPath)
-	inputExt := filepath.Ext(inputBase)
-	outputName := strings.TrimSuffix(inputBase, inputExt) + "_converted.wav"
-	outputPath := filepath.Join(p.tempDir, outputName)
+// tempDir returns the processor's working directory, creating it if needed
+func (p *Processor) tempDir() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.workDir != "" {
+		return p.workDir, nil
+	}
+
+	if err := os.MkdirAll(p.baseDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	dir, err := os.MkdirTemp(p.baseDir, "run-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	p.workDir = dir
 
+	return dir, nil
+}
+
+// TempDir returns the directory holding the processor's intermediate files,
+// empty until the first file was written there
+func (p *Processor) TempDir() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.workDir
+}
+
+// Close removes the working directory with all intermediate files left in
+// it, e.g. by conversions that were interrupted. The processor can still be
+// used afterwards and starts a new directory.
+func (p *Processor) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.workDir == "" {
+		return nil
+	}
+
+	err := os.RemoveAll(p.workDir)
+	p.workDir = ""
+
+	return err
+}
+
+// ConvertOptions controls optional processing applied during conversion
+type ConvertOptions struct {
+	Normalize bool // Apply EBU R128 loudness normalization (ffmpeg loudnorm)
+	Denoise   bool // Apply FFT-based noise reduction (ffmpeg afftdn)
+	HighPass  int  // High-pass cutoff in Hz to remove hum and rumble, 0 disables it
+
+	Start    time.Duration // Offset to start extracting from
+	Duration time.Duration // Length of audio to extract, 0 means until the end
+
+	// Keep limits the output to these regions of the extracted window,
+	// concatenated, e.g. to skip silence found by DetectSpeech
+	Keep []Region
+
+	// Channel extracts a single input channel (1-based) instead of
+	// downmixing all channels to mono, 0 downmixes
+	Channel int
+
+	// Track is the audio stream to extract (see AudioStream.Index), 0 is
+	// the first one
+	Track int
+
+	// Resample selects how the audio is resampled to 16kHz, one of
+	// ResampleQualities; empty uses ffmpeg's default (ResampleFast)
+	Resample string
+}
+
+// Resampling qualities for ConvertOptions.Resample
+const (
+	ResampleFast = "fast" // ffmpeg's built-in resampler
+	ResampleHigh = "high" // SoX resampler, sharper anti-aliasing
+	ResampleBest = "best" // SoX resampler at 28-bit precision, dithered to 16 bits
+)
+
+// ResampleQualities lists the supported resampling qualities
+var ResampleQualities = []string{ResampleFast, ResampleHigh, ResampleBest}
+
+// resampleFilters are the aresample filters for each quality. They run last
+// so the final step down to 16kHz uses them, loudnorm e.g. works at 192kHz.
+var resampleFilters = map[string]string{
+	ResampleHigh: "aresample=16000:resampler=soxr",
+	ResampleBest: "aresample=16000:resampler=soxr:precision=28:dither_method=triangular",
+}
+
+// NeedsConversion reports whether the options require ffmpeg processing, in
+// which case even 16kHz WAV inputs have to go through ffmpeg
+func (o ConvertOptions) NeedsConversion() bool {
+	return o.processingFilters() != "" || o.Start > 0 || o.Duration > 0 || o.Track > 0
+}
+
+// inputArgs are the ffmpeg arguments reading the selected window and audio
+// stream of the input
+func (o ConvertOptions) inputArgs(inputPath string) []string {
+	var args []string
+	if o.Start > 0 {
+		args = append(args, "-ss", formatSeconds(o.Start)) // Seek before input for speed
+	}
+
+	args = append(args, "-i", inputPath)                        // Input file
+	args = append(args, "-map", fmt.Sprintf("0:a:%d", o.Track)) // Audio stream, ignoring video
+	if o.Duration > 0 {
+		args = append(args, "-t", formatSeconds(o.Duration)) // Length to extract
+	}
+
+	return args
+}
+
+// filterChain assembles the -af filter graph for the options, ending with
+// the resampler
+func (o ConvertOptions) filterChain() string {
+	filters := o.processingFilters()
+	if resample := resampleFilters[o.Resample]; resample != "" {
+		if filters != "" {
+			return filters + "," + resample
+		}
+
+		return resample
+	}
+
+	return filters
+}
+
+// processingFilters are the filters changing the audio itself. Cleanup runs
+// before normalization so the noise floor isn't amplified first.
+func (o ConvertOptions) processingFilters() string {
+	filters := []string{}
+	if base := o.baseFilters(); base != "" {
+		filters = append(filters, base)
+	}
+
+	if o.HighPass > 0 {
+		filters = append(filters, fmt.Sprintf("highpass=f=%d", o.HighPass))
+	}
+
+	if o.Denoise {
+		filters = append(filters, "afftdn")
+	}
+
+	if o.Normalize {
+		filters = append(filters, "loudnorm")
+	}
+
+	return strings.Join(filters, ",")
+}
+
+// baseFilters are the filters that change timing or channel selection and
+// must survive the fallback for ffmpeg builds lacking the enhancement filters
+func (o ConvertOptions) baseFilters() string {
+	filters := []string{}
+	if o.Channel > 0 {
+		// pan numbers channels from 0
+		filters = append(filters, fmt.Sprintf("pan=mono|c0=c%d", o.Channel-1))
+	}
+
+	if len(o.Keep) > 0 {
+		filters = append(filters, selectFilter(o.Keep))
+	}
+
+	return strings.Join(filters, ",")
+}
+
+// ConvertToWav converts an audio file to 16kHz mono WAV format required by Whisper
+// The context bounds the ffmpeg run; a partially written output is removed
+// when it fails.
+func (p *Processor) ConvertToWav(ctx context.Context, inputPath string, opts ConvertOptions) (string, error) {
 	// Check if input file exists
 	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
 		return "", fmt.Errorf("input file does not exist: %s", inputPath)
 	}
 
-	// FFmpeg command to convert to 16kHz mono WAV
-	cmd := exec.Command(p.ffmpegPath,
-		"-i", inputPath, // Input file
+	outputPath, err := p.createTempWav(inputPath, opts.Channel)
+	if err != nil {
+		return "", err
+	}
+
+	filters := opts.filterChain()
+
+	output, err := p.runConversion(ctx, inputPath, outputPath, filters, opts)
+	if err != nil && filters != opts.baseFilters() && isFilterError(string(output)) {
+		// Older or minimal ffmpeg builds may lack a filter; a plain conversion
+		// still gives whisper something to work with
+		output, err = p.runConversion(ctx, inputPath, outputPath, opts.baseFilters(), opts)
+	}
+
+	if err != nil {
+		os.Remove(outputPath)
+
+		return "", fmt.Errorf("ffmpeg conversion failed: %w\nOutput: %s", err, string(output))
+	}
+
+	// Verify ffmpeg wrote to the reserved file
+	if stat, err := os.Stat(outputPath); err != nil || stat.Size() == 0 {
+		os.Remove(outputPath)
+
+		return "", fmt.Errorf("output file was not created: %s", outputPath)
+	}
+
+	return outputPath, nil
+}
+
+// MkdirTemp creates a unique directory below the processor's working
+// directory, for the intermediate files of other tools such as whisper's
+// output files. Close removes it at the latest.
+func (p *Processor) MkdirTemp(pattern string) (string, error) {
+	tempDir, err := p.tempDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := os.MkdirTemp(tempDir, pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+
+	return dir, nil
+}
+
+// createTempWav reserves a unique file for the conversion of inputPath,
+// named after the input for readability. Inputs with the same name from
+// different directories, or the same input converted concurrently, each get
+// their own file.
+func (p *Processor) createTempWav(inputPath string, channel int) (string, error) {
+	tempDir, err := p.tempDir()
+	if err != nil {
+		return "", err
+	}
+
+	name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	if channel > 0 {
+		// Channels of one file are converted separately
+		name = fmt.Sprintf("%s_ch%d", name, channel)
+	}
+
+	file, err := os.CreateTemp(tempDir, name+"_converted-*.wav")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %w", err)
+	}
+	file.Close()
+
+	return file.Name(), nil
+}
+
+// StreamWav starts ffmpeg converting the input to 16kHz mono WAV on its
+// stdout, so the audio can be piped straight into whisper without a temp
+// file. The caller must read the returned stream and then call Wait on the
+// command; ffmpeg's diagnostics are collected in stderr.
+func (p *Processor) StreamWav(ctx context.Context, inputPath string, opts ConvertOptions) (*exec.Cmd, io.ReadCloser, *bytes.Buffer, error) {
+	if _, err := os.Stat(inputPath); os.IsNotExist(err) {
+		return nil, nil, nil, fmt.Errorf("input file does not exist: %s", inputPath)
+	}
+
+	cmd := exec.CommandContext(ctx, p.ffmpegPath, conversionArgs(inputPath, "-", opts.filterChain(), opts)...)
+
+	stderr := &bytes.Buffer{}
+	cmd.Stderr = stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open ffmpeg output pipe: %w", err)
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	return cmd, stdout, stderr, nil
+}
+
+// runConversion runs ffmpeg to produce a 16kHz mono WAV, applying the given
+// filter chain if it's non-empty and extracting the requested time range
+func (p *Processor) runConversion(ctx context.Context, inputPath, outputPath, filters string, opts ConvertOptions) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, p.ffmpegPath, conversionArgs(inputPath, outputPath, filters, opts)...)
+
+	// Capture both stdout and stderr
+	return cmd.CombinedOutput()
+}
+
+// conversionArgs builds the ffmpeg arguments for a 16kHz mono WAV conversion.
+// An outputPath of "-" writes to stdout.
+func conversionArgs(inputPath, outputPath, filters string, opts ConvertOptions) []string {
+	args := opts.inputArgs(inputPath)
+	if filters != "" {
+		args = append(args, "-af", filters) // Audio filter chain
+	}
+
+	// FFmpeg arguments to convert to 16kHz mono WAV
+	args = append(args,
 		"-ar", "16000", // Sample rate: 16kHz (required by Whisper)
 		"-ac", "1", // Audio channels: 1 (mono)
 		"-c:a", "pcm_s16le", // Audio codec: 16-bit PCM
@@ -57,37 +347,80 @@ func (p *Processor) ConvertToWav(inputPath string) (string, error) {
 		outputPath, // Output file
 	)
 
-	// Capture both stdout and stderr
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return "", fmt.Errorf("ffmpeg conversion failed: %w\nOutput: %s", err, string(output))
-	}
+	return args
+}
 
-	// Verify the output file was created
-	if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-		return "", fmt.Errorf("output file was not created: %s", outputPath)
-	}
+// formatSeconds formats a duration as fractional seconds for ffmpeg
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', 3, 64)
+}
 
-	return outputPath, nil
+// isFilterError reports whether ffmpeg failed because a filter is
+// unavailable, including the SoX resampler in builds without libsoxr
+func isFilterError(output string) bool {
+	return strings.Contains(output, "No such filter") ||
+		strings.Contains(output, "Error initializing filter") ||
+		strings.Contains(output, "Error parsing filterchain") ||
+		strings.Contains(output, "Requested resampling engine is unavailable")
+}
+
+// notStarted reports whether running ffmpeg failed because it couldn't be
+// started at all: not found on PATH, or a configured path that doesn't exist
+func notStarted(err error) bool {
+	var execErr *exec.Error
+	var pathErr *fs.PathError
+
+	return errors.As(err, &execErr) || errors.As(err, &pathErr)
 }
 
+// ErrNoAudioStream is returned by GetAudioInfo for inputs ffmpeg finds no
+// audio in, such as empty files, video-only files or web pages saved with an
+// audio extension
+var ErrNoAudioStream = errors.New("no audio stream found")
+
 // GetAudioInfo returns basic information about an audio file
-func (p *Processor) GetAudioInfo(inputPath string) (map[string]string, error) {
-	cmd := exec.Command(p.ffmpegPath,
+func (p *Processor) GetAudioInfo(ctx context.Context, inputPath string) (map[string]string, error) {
+	cmd := exec.CommandContext(ctx, p.ffmpegPath,
 		"-i", inputPath,
 		"-hide_banner",
 		"-f", "null",
 		"-",
 	)
 
+	// ffmpeg may exit non-zero but still print the stream information, so
+	// only a failure to run it at all leaves nothing to parse
 	output, err := cmd.CombinedOutput()
-	if err == nil {
-		// ffmpeg returns non-zero exit code when using -f null, but still provides info
-		// So we ignore the error and parse the output
+
+	if notStarted(err) || ctx.Err() != nil {
+		return map[string]string{}, nil
+	}
+
+	info := parseAudioInfo(string(output))
+	if info["audio_info"] == "" {
+		return nil, ErrNoAudioStream
+	}
+
+	return info, nil
+}
+
+// ProbeAudioInfo returns the same information as GetAudioInfo from the file
+// header only, without decoding the audio. It is much faster but durations
+// may be estimates for files without accurate headers.
+func (p *Processor) ProbeAudioInfo(inputPath string) (map[string]string, error) {
+	if _, err := os.Stat(inputPath); err != nil {
+		return nil, fmt.Errorf("input file does not exist: %s", inputPath)
 	}
 
+	// ffmpeg exits non-zero without an output file, the header is still printed
+	output, _ := exec.Command(p.ffmpegPath, "-hide_banner", "-i", inputPath).CombinedOutput()
+
+	return parseAudioInfo(string(output)), nil
+}
+
+// parseAudioInfo extracts the duration and audio stream description from ffmpeg's log
+func parseAudioInfo(output string) map[string]string {
 	info := make(map[string]string)
-	lines := strings.Split(string(output), "\n")
+	lines := strings.Split(output, "\n")
 
 	for _, line := range lines {
 		line = strings.TrimSpace(line)
@@ -100,24 +433,73 @@ func (p *Processor) GetAudioInfo(inputPath string) (map[string]string, error) {
 			}
 		}
 
-		if strings.Contains(line, "Audio:") {
+		// The first audio stream is the one transcribed by default
+		if strings.Contains(line, "Audio:") && info["audio_info"] == "" {
 			// Extract audio format info
 			info["audio_info"] = line
+			if channels := channelCount(line); channels > 0 {
+				info["channels"] = strconv.Itoa(channels)
+			}
 		}
 	}
 
-	return info, nil
+	return info
+}
+
+// channelLayouts maps ffmpeg's named channel layouts to their channel count
+var channelLayouts = map[string]int{
+	"mono":   1,
+	"stereo": 2,
+	"2.1":    3,
+	"3.0":    3,
+	"quad":   4,
+	"4.0":    4,
+	"5.0":    5,
+	"5.1":    6,
+	"6.1":    7,
+	"7.1":    8,
+}
+
+// channelCount reads the number of channels from an ffmpeg audio stream
+// description such as "Audio: mp3, 44100 Hz, stereo, fltp, 128 kb/s".
+// It returns 0 if the layout isn't recognized.
+func channelCount(streamInfo string) int {
+	for _, field := range strings.Split(streamInfo, ",") {
+		field = strings.TrimSpace(field)
+
+		if n, ok := strings.CutSuffix(field, " channels"); ok {
+			if count, err := strconv.Atoi(n); err == nil {
+				return count
+			}
+		}
+
+		// Strip variants like "5.1(side)"
+		if i := strings.Index(field, "("); i > 0 {
+			field = field[:i]
+		}
+
+		if count, ok := channelLayouts[field]; ok {
+			return count
+		}
+	}
+
+	return 0
 }
 
-// Cleanup removes temporary files
+// Cleanup removes a temporary file of the processor
 func (p *Processor) Cleanup(filePath string) error {
-	if strings.Contains(filePath, p.tempDir) {
+	if dir := p.TempDir(); dir != "" && strings.HasPrefix(filePath, dir+string(filepath.Separator)) {
 		return os.Remove(filePath)
 	}
 
 	return nil
 }
 
+// FFmpegPath returns the ffmpeg binary the processor runs
+func (p *Processor) FFmpegPath() string {
+	return p.ffmpegPath
+}
+
 // IsFFmpegAvailable checks if FFmpeg is available on the system
 func (p *Processor) IsFFmpegAvailable() bool {
 	cmd := exec.Command(p.ffmpegPath, "-version")
@@ -0,0 +1,84 @@
+package audio
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFilterChain(t *testing.T) {
+	tests := []struct {
+		name string
+		opts ConvertOptions
+		want string
+	}{
+		{"no filters", ConvertOptions{}, ""},
+		{"denoise", ConvertOptions{Denoise: true}, "afftdn"},
+		{"denoise and highpass", ConvertOptions{Denoise: true, HighPass: 80}, "highpass=f=80,afftdn"},
+		{"denoise and normalize", ConvertOptions{Denoise: true, Normalize: true}, "afftdn,loudnorm"},
+		{
+			name: "all enhancements",
+			opts: ConvertOptions{Denoise: true, HighPass: 100, Normalize: true},
+			want: "highpass=f=100,afftdn,loudnorm",
+		},
+		{"channel first", ConvertOptions{Channel: 2, Denoise: true}, "pan=mono|c0=c1,afftdn"},
+		{"resampler last", ConvertOptions{Denoise: true, Resample: ResampleHigh}, "afftdn,aresample=16000:resampler=soxr"},
+		{"resampler alone", ConvertOptions{Resample: ResampleHigh}, "aresample=16000:resampler=soxr"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.filterChain(); got != tt.want {
+				t.Errorf("filterChain() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNeedsConversion(t *testing.T) {
+	if (ConvertOptions{}).NeedsConversion() {
+		t.Error("NeedsConversion() = true without options")
+	}
+
+	if !(ConvertOptions{Denoise: true}).NeedsConversion() {
+		t.Error("NeedsConversion() = false with Denoise")
+	}
+}
+
+func TestCreateTempWavSameName(t *testing.T) {
+	p := NewProcessor("", t.TempDir())
+	defer p.Close()
+
+	// Inputs with the same name from different directories
+	first, err := p.createTempWav(filepath.Join("podcasts", "a", "audio.mp3"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	second, err := p.createTempWav(filepath.Join("podcasts", "b", "audio.mp3"), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if first == second {
+		t.Fatalf("both inputs got %s", first)
+	}
+
+	for _, path := range []string{first, second} {
+		if _, err := os.Stat(path); err != nil {
+			t.Errorf("reserved file %s: %v", path, err)
+		}
+
+		if filepath.Dir(path) != p.TempDir() {
+			t.Errorf("%s is not in the working directory %s", path, p.TempDir())
+		}
+	}
+
+	if err := p.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(first); !os.IsNotExist(err) {
+		t.Errorf("Close left %s behind", first)
+	}
+}
@@ -0,0 +1,68 @@
+package audio
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// AudioStream is one of the audio streams of an input, e.g. a language
+// track of a film
+type AudioStream struct {
+	Index    int    // Position among the input's audio streams, from 0, as in ffmpeg's 0:a:N
+	Language string // Language tag such as "eng", empty if untagged
+	Channels int    // Number of channels, 0 if the layout isn't recognized
+	Info     string // ffmpeg's description of the stream
+}
+
+// audioStreamRegex matches ffmpeg's description of an input audio stream,
+// e.g. "Stream #0:1[0x2](eng): Audio: aac (LC), 48000 Hz, stereo, fltp"
+var audioStreamRegex = regexp.MustCompile(`Stream #0:\d+(?:\[\w+\])?(?:\((\w+)\))?: Audio: (.*)`)
+
+// AudioStreams lists the audio streams of the input from its header
+func (p *Processor) AudioStreams(inputPath string) ([]AudioStream, error) {
+	if _, err := os.Stat(inputPath); err != nil {
+		return nil, fmt.Errorf("input file does not exist: %s", inputPath)
+	}
+
+	// ffmpeg exits non-zero without an output file, the header is still printed
+	output, err := exec.Command(p.ffmpegPath, "-hide_banner", "-i", inputPath).CombinedOutput()
+	if notStarted(err) {
+		return nil, fmt.Errorf("failed to run ffmpeg: %w", err)
+	}
+
+	return parseAudioStreams(string(output)), nil
+}
+
+// parseAudioStreams extracts the input audio streams from ffmpeg's log
+func parseAudioStreams(output string) []AudioStream {
+	var streams []AudioStream
+
+	for _, line := range strings.Split(output, "\n") {
+		// Output streams are listed after "Output #0" and aren't inputs
+		if strings.HasPrefix(line, "Output #") {
+			break
+		}
+
+		match := audioStreamRegex.FindStringSubmatch(line)
+		if match == nil {
+			continue
+		}
+
+		language := match[1]
+		if language == "und" {
+			language = ""
+		}
+
+		streams = append(streams, AudioStream{
+			Index:    len(streams),
+			Language: language,
+			Channels: channelCount(match[2]),
+			Info:     strings.TrimSpace(match[2]),
+		})
+	}
+
+	return streams
+}
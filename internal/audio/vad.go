@@ -0,0 +1,146 @@
+package audio
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Silence detection tuning: quieter than silenceNoiseFloor for at least
+// minSilence counts as silence, and speech keeps speechPadding on both sides
+// so word onsets and trailing syllables aren't clipped
+const (
+	silenceNoiseFloor = "-35dB"
+	minSilence        = 2 * time.Second
+	speechPadding     = 250 * time.Millisecond
+)
+
+var (
+	silenceStartRegex = regexp.MustCompile(`silence_start: (-?[\d.]+)`)
+	silenceEndRegex   = regexp.MustCompile(`silence_end: (-?[\d.]+)`)
+)
+
+// Region is a span of audio
+type Region struct {
+	Start time.Duration
+	End   time.Duration
+}
+
+// DetectSpeech runs ffmpeg's silencedetect over the window of the input
+// selected by opts and returns the non-silent regions, relative to the start
+// of the window. total is the window's length. An empty result means the
+// window is entirely silent.
+func (p *Processor) DetectSpeech(ctx context.Context, inputPath string, opts ConvertOptions, total time.Duration) ([]Region, error) {
+	args := append(opts.inputArgs(inputPath),
+		"-af", fmt.Sprintf("silencedetect=noise=%s:d=%s", silenceNoiseFloor, formatSeconds(minSilence)),
+		"-f", "null",
+		"-",
+	)
+
+	output, err := exec.CommandContext(ctx, p.ffmpegPath, args...).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("silence detection failed: %w\nOutput: %s", err, string(output))
+	}
+
+	return speechRegions(parseSilences(string(output), total), total), nil
+}
+
+// parseSilences extracts the silent regions from silencedetect's log output.
+// A silence still open at the end of the input runs until total.
+func parseSilences(output string, total time.Duration) []Region {
+	var silences []Region
+
+	var start time.Duration
+
+	open := false
+
+	for _, line := range strings.Split(output, "\n") {
+		if match := silenceStartRegex.FindStringSubmatch(line); match != nil {
+			start = parseSeconds(match[1])
+			open = true
+		}
+
+		if match := silenceEndRegex.FindStringSubmatch(line); match != nil && open {
+			silences = append(silences, Region{Start: start, End: parseSeconds(match[1])})
+			open = false
+		}
+	}
+
+	if open {
+		silences = append(silences, Region{Start: start, End: total})
+	}
+
+	return silences
+}
+
+// speechRegions returns the gaps between silences within [0, total], padded
+// by speechPadding and merged where the padding makes them overlap
+func speechRegions(silences []Region, total time.Duration) []Region {
+	var regions []Region
+
+	cursor := time.Duration(0)
+
+	for _, silence := range append(silences, Region{Start: total, End: total}) {
+		if silence.Start > cursor {
+			start := max(cursor-speechPadding, 0)
+			end := min(silence.Start+speechPadding, total)
+
+			if n := len(regions); n > 0 && start <= regions[n-1].End {
+				regions[n-1].End = end
+			} else {
+				regions = append(regions, Region{Start: start, End: end})
+			}
+		}
+
+		cursor = max(cursor, silence.End)
+	}
+
+	return regions
+}
+
+// MapToSource converts a timestamp in audio made of the concatenated regions
+// back to the corresponding timestamp in the original audio
+func MapToSource(regions []Region, t time.Duration) time.Duration {
+	var offset time.Duration
+
+	for _, region := range regions {
+		length := region.End - region.Start
+		if t < offset+length {
+			return region.Start + (t - offset)
+		}
+
+		offset += length
+	}
+
+	if len(regions) == 0 {
+		return t
+	}
+
+	return regions[len(regions)-1].End + (t - offset)
+}
+
+// selectFilter builds the filter keeping only the given regions and closing
+// the gaps between them
+func selectFilter(regions []Region) string {
+	spans := make([]string, len(regions))
+	for i, region := range regions {
+		spans[i] = fmt.Sprintf("between(t,%s,%s)", formatSeconds(region.Start), formatSeconds(region.End))
+	}
+
+	return fmt.Sprintf("aselect='%s',asetpts=N/SR/TB", strings.Join(spans, "+"))
+}
+
+// parseSeconds parses ffmpeg's fractional seconds, clamping negative values
+// silencedetect reports for silence at the very start
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.ParseFloat(value, 64)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,63 @@
+package audio
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IsWhisperWav reports whether the file is a 16-bit PCM WAV file at 16kHz
+// mono, which whisper reads directly without an ffmpeg conversion. Only the
+// header is read.
+func IsWhisperWav(path string) bool {
+	if !strings.EqualFold(filepath.Ext(path), ".wav") {
+		return false
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	var riff [12]byte
+	if _, err := io.ReadFull(file, riff[:]); err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return false
+	}
+
+	// Walk the chunks up to the format description
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(file, header[:]); err != nil {
+			return false
+		}
+
+		size := binary.LittleEndian.Uint32(header[4:8])
+
+		if string(header[0:4]) != "fmt " {
+			// Chunks are padded to an even size
+			if _, err := file.Seek(int64(size)+int64(size%2), io.SeekCurrent); err != nil {
+				return false
+			}
+
+			continue
+		}
+
+		var format [16]byte
+		if size < 16 {
+			return false
+		}
+		if _, err := io.ReadFull(file, format[:]); err != nil {
+			return false
+		}
+
+		audioFormat := binary.LittleEndian.Uint16(format[0:2])
+		channels := binary.LittleEndian.Uint16(format[2:4])
+		sampleRate := binary.LittleEndian.Uint32(format[4:8])
+		bitsPerSample := binary.LittleEndian.Uint16(format[14:16])
+
+		return audioFormat == 1 && channels == 1 && sampleRate == 16000 && bitsPerSample == 16
+	}
+}
@@ -1,15 +1,23 @@
 package cache
 
 import (
+	"context"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+
+	"github.com/pascalwhoop/ghospel/internal/models"
 )
 
 // Manager handles cache operations
 type Manager struct {
-	cacheDir string
+	cacheDir  string
+	modelsDir string // Where models are kept when not in cacheDir
 }
 
 // NewManager creates a new cache manager
@@ -25,6 +33,34 @@ func NewManager(cacheDir string) *Manager {
 	return &Manager{cacheDir: cacheDir}
 }
 
+// SetModelsDir sets the directory models are stored in when it isn't the
+// cache directory, the models_dir setting. Empty keeps them in the cache.
+func (m *Manager) SetModelsDir(dir string) {
+	m.modelsDir = dir
+}
+
+// modelsPath is the directory models are stored in
+func (m *Manager) modelsPath() string {
+	if m.modelsDir == "" {
+		return m.cacheDir
+	}
+
+	return m.modelsDir
+}
+
+// dirs are the directories making up the cache: the cache directory and a
+// separate models directory, if set
+func (m *Manager) dirs() []string {
+	dirs := []string{m.cacheDir}
+
+	rel, err := filepath.Rel(m.cacheDir, m.modelsDir)
+	if m.modelsDir != "" && (err != nil || strings.HasPrefix(rel, "..")) {
+		dirs = append(dirs, m.modelsDir)
+	}
+
+	return dirs
+}
+
 // Info displays cache statistics
 func (m *Manager) Info() error {
 	fmt.Println("Cache Information:")
@@ -55,6 +91,12 @@ func (m *Manager) Info() error {
 	fmt.Printf("Total Size: %s\n", formatBytes(totalSize))
 	fmt.Printf("File Count: %d\n", fileCount)
 
+	if last := m.LastCleanup(); last.IsZero() {
+		fmt.Println("Last Cleanup: never")
+	} else {
+		fmt.Printf("Last Cleanup: %s (%s ago)\n", last.Format("2006-01-02 15:04"), formatAge(time.Since(last)))
+	}
+
 	// Check if cache directory exists
 	if _, err := os.Stat(m.cacheDir); os.IsNotExist(err) {
 		fmt.Println("Status: Cache directory does not exist")
@@ -65,9 +107,138 @@ func (m *Manager) Info() error {
 	return nil
 }
 
-// Clean removes old cached files
-func (m *Manager) Clean(olderThan string) error {
-	fmt.Printf("🧹 Cleaning cache files older than %s...\n", olderThan)
+// entry is a cached file listed by List
+type entry struct {
+	path    string // Relative to the cache directory
+	label   string
+	size    int64
+	modTime time.Time
+}
+
+// List prints every cached file with its size and modification time, largest
+// first. Model files are labeled with their name in the model registry.
+// Files of a separate models directory are listed with their full path.
+func (m *Manager) List() error {
+	known := make(map[string]models.ModelInfo)
+	for _, model := range models.NewManager(m.modelsPath()).AvailableModels() {
+		known[filepath.Base(model.Path)] = model
+	}
+
+	var entries []entry
+
+	for _, dir := range m.dirs() {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+
+			name := rel
+			if dir != m.cacheDir {
+				name = path
+			}
+
+			entries = append(entries, entry{
+				path:    name,
+				label:   label(rel, known),
+				size:    info.Size(),
+				modTime: info.ModTime(),
+			})
+
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to list cache: %w", err)
+		}
+	}
+
+	if len(entries) == 0 {
+		fmt.Printf("Cache is empty (%s)\n", m.cacheDir)
+		return nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].size > entries[j].size
+	})
+
+	var totalSize int64
+
+	for _, e := range entries {
+		totalSize += e.size
+
+		fmt.Printf("%-10s | %-16s | %-24s | %s\n",
+			formatBytes(e.size), e.modTime.Format("2006-01-02 15:04"), e.label, e.path)
+	}
+
+	fmt.Printf("\n%d file(s), %s in %s\n", len(entries), formatBytes(totalSize), strings.Join(m.dirs(), " and "))
+
+	return nil
+}
+
+// label describes a cached file for List
+func label(rel string, known map[string]models.ModelInfo) string {
+	name := filepath.Base(rel)
+
+	switch {
+	case filepath.Dir(rel) == "." && known[name].Name != "":
+		return "model " + known[name].Name
+	case strings.HasSuffix(name, ".bin.part"):
+		return "unfinished download"
+	case strings.HasSuffix(name, ".bin.lock"):
+		return "download lock"
+	case strings.HasPrefix(name, "ggml-") && strings.HasSuffix(name, ".bin"):
+		return "model (not in registry)"
+	case strings.HasPrefix(name, "ggml-") && strings.HasSuffix(name, ".bin.json"):
+		return "model download record"
+	case filepath.Dir(rel) == "downloads":
+		return "downloaded audio"
+	case filepath.Dir(rel) == "journals":
+		return "batch journal"
+	case rel == metadataFile:
+		return "cache metadata"
+	default:
+		return "other"
+	}
+}
+
+// Clean removes old cached files. Model files and their download records
+// are kept, only Clear and Evict remove models. With dryRun it only lists
+// the files.
+func (m *Manager) Clean(olderThan string, dryRun bool) error {
+	return m.clean(olderThan, dryRun, false)
+}
+
+// AutoClean removes cached files older than retention, at most once a day.
+// Like Clean it keeps model files, which are slow to download again; it only
+// reports when files were removed.
+func (m *Manager) AutoClean(retention string) error {
+	if time.Since(m.LastCleanup()) < 24*time.Hour {
+		return nil
+	}
+
+	return m.clean(retention, false, true)
+}
+
+// clean removes or, with dryRun, lists cached files older than olderThan.
+// auto is set for automatic cleanups, see AutoClean.
+func (m *Manager) clean(olderThan string, dryRun, auto bool) error {
+	switch {
+	case dryRun:
+		slog.Info(fmt.Sprintf("📋 Dry run - cache files older than %s that would be removed:", olderThan),
+			"older_than", olderThan)
+	case auto:
+		slog.Debug(fmt.Sprintf("🧹 Cleaning cache files older than %s...", olderThan), "older_than", olderThan)
+	default:
+		slog.Info(fmt.Sprintf("🧹 Cleaning cache files older than %s...", olderThan), "older_than", olderThan)
+	}
 
 	// Parse duration
 	duration, err := parseDuration(olderThan)
@@ -87,45 +258,206 @@ func (m *Manager) Clean(olderThan string) error {
 		}
 
 		// Skip directories and recently modified files
-		if info.IsDir() || info.ModTime().After(cutoff) {
+		if info.IsDir() || info.ModTime().After(cutoff) || m.isMetadata(path) {
 			return nil
 		}
 
-		// Don't remove model files during clean (only during clear)
-		if filepath.Dir(path) == filepath.Join(m.cacheDir, "models") {
+		// Don't remove models and their download records during clean, only
+		// during clear
+		if isModelFile(path) {
 			return nil
 		}
 
 		removedSize += info.Size()
 		removedCount++
 
+		if dryRun {
+			slog.Info(fmt.Sprintf("   %s (%s, modified %s)", path, formatBytes(info.Size()), info.ModTime().Format("2006-01-02")),
+				"path", path, "bytes", info.Size(), "modified", info.ModTime())
+			return nil
+		}
+
 		return os.Remove(path)
 	})
 	if err != nil {
 		return fmt.Errorf("failed to clean cache: %w", err)
 	}
 
-	fmt.Printf("✅ Removed %d files (%s freed)\n", removedCount, formatBytes(removedSize))
+	if dryRun {
+		slog.Info(fmt.Sprintf("📊 %d files would be removed (%s freed)", removedCount, formatBytes(removedSize)),
+			"files", removedCount, "bytes", removedSize)
+		return nil
+	}
+
+	level := slog.LevelInfo
+	if auto && removedCount == 0 {
+		level = slog.LevelDebug
+	}
 
-	return nil
+	slog.Log(context.Background(), level, fmt.Sprintf("✅ Removed %d files (%s freed)", removedCount, formatBytes(removedSize)),
+		"files", removedCount, "bytes", removedSize)
+
+	return m.recordCleanup(time.Now())
+}
+
+// isModelFile tells whether path is a model, stored in the cache root as
+// ggml-<name>.bin, or its download record ggml-<name>.bin.json
+func isModelFile(path string) bool {
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+
+	return strings.HasPrefix(name, "ggml-") && strings.HasSuffix(name, ".bin")
+}
+
+// Evict removes the least recently used files until the cache takes at most
+// maxSize bytes. Files named in keep, such as the default model, are never
+// removed, nor are files of downloads in progress. A model's download record
+// is removed together with the model. Models are marked as used whenever a
+// transcription runs with them, so a file's modification time tells when it
+// was last used. With dryRun the files are only listed.
+func (m *Manager) Evict(maxSize int64, keep []string, dryRun bool) error {
+	var entries []entry
+
+	// Download records by the path of their model
+	records := make(map[string]os.FileInfo)
+
+	var totalSize int64
+
+	for _, dir := range m.dirs() {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.IsDir() {
+				return nil
+			}
+
+			totalSize += info.Size()
+
+			// Lock and partial files belong to running downloads, records
+			// of kept models are kept with them
+			name := filepath.Base(path)
+			if strings.HasSuffix(name, ".lock") || strings.HasSuffix(name, ".bin.part") ||
+				slices.Contains(keep, strings.TrimSuffix(name, ".json")) || m.isMetadata(path) {
+				return nil
+			}
+
+			if isModelFile(path) && strings.HasSuffix(name, ".json") {
+				records[strings.TrimSuffix(path, ".json")] = info
+				return nil
+			}
+
+			entries = append(entries, entry{path: path, size: info.Size(), modTime: info.ModTime()})
+
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to evict from cache: %w", err)
+		}
+	}
+
+	// Records left behind by a removed model are evicted on their own
+	for model, info := range records {
+		if _, err := os.Stat(model); os.IsNotExist(err) {
+			entries = append(entries, entry{path: model + ".json", size: info.Size(), modTime: info.ModTime()})
+			delete(records, model)
+		}
+	}
+
+	if totalSize <= maxSize {
+		slog.Info(fmt.Sprintf("✅ Cache takes %s, within the limit of %s", formatBytes(totalSize), formatBytes(maxSize)),
+			"bytes", totalSize, "max_bytes", maxSize)
+		return nil
+	}
+
+	verb := "evicting"
+	if dryRun {
+		verb = "would evict"
+	}
+
+	slog.Info(fmt.Sprintf("🧹 Cache takes %s, %s least recently used files down to %s...",
+		formatBytes(totalSize), verb, formatBytes(maxSize)), "bytes", totalSize, "max_bytes", maxSize)
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].modTime.Before(entries[j].modTime)
+	})
+
+	var removedCount int
+
+	var removedSize int64
+
+	for _, e := range entries {
+		if totalSize <= maxSize {
+			break
+		}
+
+		if !dryRun {
+			if err := os.Remove(e.path); err != nil {
+				return fmt.Errorf("failed to evict %s: %w", e.path, err)
+			}
+		}
+
+		totalSize -= e.size
+		removedSize += e.size
+		removedCount++
+
+		slog.Info(fmt.Sprintf("   🗑️  %s (%s, last used %s)", e.path, formatBytes(e.size), e.modTime.Format("2006-01-02")),
+			"path", e.path, "bytes", e.size, "last_used", e.modTime)
+
+		if record, ok := records[e.path]; ok {
+			if !dryRun {
+				if err := os.Remove(e.path + ".json"); err != nil {
+					return fmt.Errorf("failed to evict %s: %w", e.path+".json", err)
+				}
+			}
+
+			totalSize -= record.Size()
+			removedSize += record.Size()
+			removedCount++
+
+			slog.Info(fmt.Sprintf("   🗑️  %s (%s, download record)", e.path+".json", formatBytes(record.Size())),
+				"path", e.path+".json", "bytes", record.Size())
+		}
+	}
+
+	if dryRun {
+		slog.Info(fmt.Sprintf("📊 %d files would be evicted (%s freed), leaving %s", removedCount,
+			formatBytes(removedSize), formatBytes(totalSize)),
+			"files", removedCount, "bytes", removedSize, "remaining_bytes", totalSize)
+	} else {
+		slog.Info(fmt.Sprintf("✅ Evicted %d files (%s freed), cache now takes %s", removedCount,
+			formatBytes(removedSize), formatBytes(totalSize)),
+			"files", removedCount, "bytes", removedSize, "remaining_bytes", totalSize)
+	}
+
+	if totalSize > maxSize {
+		slog.Warn(fmt.Sprintf("⚠️  Cache is still above %s, the kept files alone are larger", formatBytes(maxSize)),
+			"bytes", totalSize, "max_bytes", maxSize)
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	return m.recordCleanup(time.Now())
 }
 
 // Clear removes all cached files
 func (m *Manager) Clear(force bool) error {
 	if !force {
-		fmt.Print("⚠️  This will remove all cached files including models. Continue? (y/N): ")
+		fmt.Fprint(os.Stderr, "⚠️  This will remove all cached files including models. Continue? (y/N): ")
 
 		var response string
 
 		fmt.Scanln(&response)
 
 		if response != "y" && response != "Y" {
-			fmt.Println("Cancelled")
+			slog.Info("Cancelled")
 			return nil
 		}
 	}
 
-	fmt.Println("🗑️  Clearing entire cache...")
+	slog.Info("🗑️  Clearing entire cache...", "path", m.cacheDir)
 
 	// Remove entire cache directory
 	if err := os.RemoveAll(m.cacheDir); err != nil {
@@ -137,7 +469,26 @@ func (m *Manager) Clear(force bool) error {
 		return fmt.Errorf("failed to recreate cache directory: %w", err)
 	}
 
-	fmt.Println("✅ Cache cleared successfully")
+	// A separate models directory may hold other files, only the models and
+	// their downloads go
+	if len(m.dirs()) > 1 {
+		modelFiles, err := filepath.Glob(filepath.Join(m.modelsPath(), "ggml-*"))
+		if err != nil {
+			return fmt.Errorf("failed to clear models: %w", err)
+		}
+
+		for _, path := range modelFiles {
+			if err := os.Remove(path); err != nil {
+				return fmt.Errorf("failed to clear models: %w", err)
+			}
+		}
+	}
+
+	if err := m.recordCleanup(time.Now()); err != nil {
+		return err
+	}
+
+	slog.Info("✅ Cache cleared successfully")
 
 	return nil
 }
@@ -164,6 +515,19 @@ func formatBytes(bytes int64) string {
 	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
+// formatAge formats how long ago something happened in days or hours
+func formatAge(d time.Duration) string {
+	if d >= 48*time.Hour {
+		return fmt.Sprintf("%d days", int(d/(24*time.Hour)))
+	}
+
+	if d >= time.Hour {
+		return fmt.Sprintf("%d hours", int(d/time.Hour))
+	}
+
+	return "less than an hour"
+}
+
 // parseDuration parses duration strings like "30d", "7d", "24h"
 func parseDuration(s string) (time.Duration, error) {
 	if len(s) < 2 {
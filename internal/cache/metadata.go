@@ -0,0 +1,58 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// metadataFile holds the cache's own bookkeeping, in the cache directory
+const metadataFile = ".ghospel-cache.json"
+
+// metadata is the content of metadataFile
+type metadata struct {
+	LastCleanup time.Time `json:"last_cleanup"`
+}
+
+// readMetadata reads the cache metadata, zero values if there is none yet
+func (m *Manager) readMetadata() metadata {
+	var meta metadata
+
+	data, err := os.ReadFile(filepath.Join(m.cacheDir, metadataFile))
+	if err == nil {
+		_ = json.Unmarshal(data, &meta)
+	}
+
+	return meta
+}
+
+// recordCleanup stores now as the time of the last cleanup
+func (m *Manager) recordCleanup(now time.Time) error {
+	meta := m.readMetadata()
+	meta.LastCleanup = now
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(filepath.Join(m.cacheDir, metadataFile), data, 0o644); err != nil {
+		return fmt.Errorf("failed to record cache cleanup: %w", err)
+	}
+
+	return nil
+}
+
+// LastCleanup returns when the cache was last cleaned, the zero time if it
+// never was
+func (m *Manager) LastCleanup() time.Time {
+	return m.readMetadata().LastCleanup
+}
+
+// isMetadata reports whether path is the cache metadata file, which cleanups
+// leave alone
+func (m *Manager) isMetadata(path string) bool {
+	return path == filepath.Join(m.cacheDir, metadataFile)
+}
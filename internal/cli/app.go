@@ -2,11 +2,13 @@ package cli
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
 
 	"github.com/pascalwhoop/ghospel/internal/commands"
 	"github.com/pascalwhoop/ghospel/internal/config"
+	"github.com/pascalwhoop/ghospel/internal/logging"
 	"github.com/urfave/cli/v2"
 )
 
@@ -23,15 +25,41 @@ func NewApp() *cli.App {
 				Email: "pascal@example.com",
 			},
 		},
+		EnableBashCompletion: true,
 		Before: func(c *cli.Context) error {
+			// Status output goes to stderr, leaving stdout to command results
+			if err := logging.Setup(os.Stderr, c.String("log-format")); err != nil {
+				return err
+			}
+
+			level, err := logging.ParseLevel(c.String("log-level"))
+			if err != nil {
+				return err
+			}
+
+			logging.SetLevel(level)
+			if c.Bool("verbose") {
+				logging.SetLevel(slog.LevelDebug)
+			}
+
+			logging.ConfigureStyle(c.Bool("no-color"), c.Bool("ascii"))
+
 			// Initialize config directory
-			return config.InitConfigDir()
+			if err := config.InitConfigDir(); err != nil {
+				return err
+			}
+
+			return nil
 		},
 		Commands: []*cli.Command{
 			commands.TranscribeCommand(),
 			commands.ModelsCommand(),
 			commands.ConfigCommand(),
 			commands.CacheCommand(),
+			commands.ServeCommand(),
+			commands.DiffCommand(),
+			commands.BenchCommand(),
+			commands.CompletionCommand(),
 		},
 		Flags: []cli.Flag{
 			&cli.BoolFlag{
@@ -39,6 +67,27 @@ func NewApp() *cli.App {
 				Usage:   "Enable verbose output",
 				EnvVars: []string{"GHOSPEL_VERBOSE"},
 			},
+			&cli.StringFlag{
+				Name:    "log-format",
+				Usage:   "Format of status messages on stderr: text (human-friendly) or json (one record per line)",
+				Value:   logging.FormatText,
+				EnvVars: []string{"GHOSPEL_LOG_FORMAT"},
+			},
+			&cli.StringFlag{
+				Name:    "log-level",
+				Usage:   "Minimum level of status messages: debug, info, warn or error",
+				Value:   "info",
+				EnvVars: []string{"GHOSPEL_LOG_LEVEL"},
+			},
+			&cli.BoolFlag{
+				Name:  "no-color",
+				Usage: "Disable colors and emoji, like setting NO_COLOR or GHOSPEL_NO_COLOR (emoji are also left out when stderr isn't a terminal)",
+			},
+			&cli.BoolFlag{
+				Name:    "ascii",
+				Usage:   "Replace emoji in status messages with plain markers such as [OK] and [FAIL]",
+				EnvVars: []string{"GHOSPEL_ASCII"},
+			},
 			&cli.StringFlag{
 				Name:    "config",
 				Aliases: []string{"c"},
@@ -0,0 +1,28 @@
+package cli
+
+import (
+	"errors"
+
+	"github.com/pascalwhoop/ghospel/internal/transcription"
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// Exit codes of the ghospel command, documented in the README for scripts
+// and CI
+const (
+	ExitFailure           = 1 // Transcription failures and any other error
+	ExitNoInput           = 2 // The inputs matched no audio files or don't exist
+	ExitMissingDependency = 3 // ffmpeg or whisper-cli can't be found
+)
+
+// ExitCode maps an error returned by the app to the process exit code
+func ExitCode(err error) int {
+	switch {
+	case errors.Is(err, transcribe.ErrFFmpegNotFound) || errors.Is(err, transcribe.ErrWhisperNotFound):
+		return ExitMissingDependency
+	case errors.Is(err, transcription.ErrNoAudioFiles) || errors.Is(err, transcription.ErrInputNotFound):
+		return ExitNoInput
+	default:
+		return ExitFailure
+	}
+}
@@ -0,0 +1,178 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/pascalwhoop/ghospel/internal/config"
+	"github.com/pascalwhoop/ghospel/internal/transcription"
+	"github.com/urfave/cli/v2"
+)
+
+// BenchCommand creates the bench command
+func BenchCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "bench",
+		Usage:     "Compare the speed and accuracy of models on a sample",
+		ArgsUsage: "<sample>",
+		Description: `Transcribe the same audio with each model and compare how long they take,
+   to pick the right tradeoff between speed and accuracy for this machine.
+
+   Prints the wall time, realtime factor (seconds of audio per second of
+   processing) and word count of each model. With --wer the transcripts are
+   compared to the one of the largest model by word error rate, the share
+   of its words the other models got wrong.
+
+   Models that are missing are downloaded first. Language, prompt and the
+   decoding settings come from the config file. A sample of a few minutes
+   gives meaningful numbers; the first run may be slower while the model
+   is read from disk.
+
+   Examples:
+     ghospel bench sample.mp3
+     ghospel bench --models base,small,large-v3-turbo --wer interview.m4a
+     ghospel bench --json sample.mp3 > bench.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "models",
+				Usage: "Comma-separated models to compare",
+				Value: "tiny,base,small",
+			},
+			&cli.StringFlag{
+				Name:    "language",
+				Aliases: []string{"l"},
+				Usage:   "Language of the sample, or auto to detect it (default from config)",
+			},
+			&cli.BoolFlag{
+				Name:  "wer",
+				Usage: "Compute the word error rate of each model against the largest one",
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the results as JSON",
+			},
+		},
+		BashComplete: completeModelNames,
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return cli.ShowCommandHelp(c, "bench")
+			}
+
+			modelNames, err := parseModelList(c.String("models"))
+			if err != nil {
+				return fmt.Errorf("invalid --models: %w", err)
+			}
+
+			if c.Bool("wer") && len(modelNames) < 2 {
+				return fmt.Errorf("--wer needs at least two models to compare")
+			}
+
+			cfg, err := config.Load(c.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			opts := transcription.Options{
+				Language: c.String("language"),
+				BeamSize: 5,
+			}
+			applyConfig(c, &opts, cfg)
+
+			if err := applyPromptFile(c, &opts, cfg); err != nil {
+				return err
+			}
+
+			sample := c.Args().First()
+			if !transcription.IsURL(sample) {
+				if _, err := os.Stat(sample); err != nil {
+					return fmt.Errorf("cannot read sample: %w", err)
+				}
+
+				sample, _ = filepath.Abs(sample)
+			}
+
+			service := transcription.NewService(opts)
+			defer service.Close()
+
+			// Interrupted runs remove their temporary files too
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(signals)
+
+			go func() {
+				<-signals
+				service.Close()
+				os.Exit(130)
+			}()
+
+			results, benchErr := service.Bench(sample, modelNames, c.Bool("wer"))
+			if results == nil {
+				return benchErr
+			}
+
+			if c.Bool("json") {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+
+				if err := encoder.Encode(results); err != nil {
+					return err
+				}
+
+				return benchErr
+			}
+
+			printBenchResults(results, c.Bool("wer"))
+
+			return benchErr
+		},
+	}
+}
+
+// printBenchResults prints the results of a benchmark as a table, one model
+// per row
+func printBenchResults(results []transcription.BenchResult, wer bool) {
+	width := len("Model")
+	for _, result := range results {
+		width = max(width, len(result.Model))
+	}
+
+	header := fmt.Sprintf("%-*s | %9s | %9s | %7s", width, "Model", "Time", "Realtime", "Words")
+	if wer {
+		header += fmt.Sprintf(" | %9s", "WER")
+	}
+
+	fmt.Println(header)
+	fmt.Println(strings.Repeat("-", len(header)))
+
+	for _, result := range results {
+		if result.Error != "" {
+			fmt.Printf("%-*s | failed: %s\n", width, result.Model, result.Error)
+			continue
+		}
+
+		// The realtime factor is unknown when the sample's duration can't be read
+		realtime := "-"
+		if result.RealtimeFactor > 0 {
+			realtime = fmt.Sprintf("%.1fx", result.RealtimeFactor)
+		}
+
+		row := fmt.Sprintf("%-*s | %8.1fs | %9s | %7d", width, result.Model, result.ElapsedSeconds, realtime,
+			result.Words)
+
+		if wer {
+			switch {
+			case result.Reference:
+				row += fmt.Sprintf(" | %9s", "reference")
+			case result.WordErrorRate != nil:
+				row += fmt.Sprintf(" | %8.1f%%", *result.WordErrorRate*100)
+			}
+		}
+
+		fmt.Println(row)
+	}
+}
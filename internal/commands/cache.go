@@ -1,7 +1,12 @@
 package commands
 
 import (
+	"fmt"
+	"log/slog"
+
 	"github.com/pascalwhoop/ghospel/internal/cache"
+	"github.com/pascalwhoop/ghospel/internal/config"
+	"github.com/pascalwhoop/ghospel/internal/models"
 	"github.com/urfave/cli/v2"
 )
 
@@ -12,7 +17,8 @@ func CacheCommand() *cli.Command {
 		Usage: "Manage download and processing cache",
 		Description: `Manage cached files including models, downloaded audio, and temporary files.
 
-   Cache is stored in ~/.whisper/ by default.`,
+   Cache is stored in ~/.whisper/ by default, see cache_dir and models_dir
+   in the config.`,
 		Subcommands: []*cli.Command{
 			{
 				Name:      "info",
@@ -24,8 +30,27 @@ func CacheCommand() *cli.Command {
    - Cache directory location
    - Last cleanup date`,
 				Action: func(c *cli.Context) error {
-					manager := cache.NewManager("")
-					return manager.Info()
+					cfg, err := config.Load(c.String("config"))
+					if err != nil {
+						return err
+					}
+
+					return newCacheManager(cfg).Info()
+				},
+			},
+			{
+				Name:      "list",
+				Usage:     "List cached models and files by size",
+				ArgsUsage: " ",
+				Description: `List every cached file, largest first, with its size, last modification
+   and what it is, to decide what to remove.`,
+				Action: func(c *cli.Context) error {
+					cfg, err := config.Load(c.String("config"))
+					if err != nil {
+						return err
+					}
+
+					return newCacheManager(cfg).List()
 				},
 			},
 			{
@@ -34,17 +59,62 @@ func CacheCommand() *cli.Command {
 				ArgsUsage: " ",
 				Description: `Remove cached files older than the retention period.
    
-   This preserves recently used models and files while cleaning up old data.`,
+   Models are kept whatever their age, only cache clear removes them.
+   With --max-size (or cache_max_size in the config) the least recently used
+   files, models included, are then evicted until the cache fits. The default
+   model and downloads in progress are always kept.`,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:  "older-than",
 						Usage: "Remove files older than duration (e.g., 30d, 7d, 24h)",
 						Value: "30d",
 					},
+					&cli.StringFlag{
+						Name:  "max-size",
+						Usage: "Then evict least recently used files, models included, until the cache is under this size (e.g. 10G, default from config cache_max_size)",
+					},
+					&cli.BoolFlag{
+						Name:  "dry-run",
+						Usage: "List the files that would be removed and the space freed without deleting anything",
+					},
 				},
 				Action: func(c *cli.Context) error {
-					manager := cache.NewManager("")
-					return manager.Clean(c.String("older-than"))
+					// The config is read before anything is removed, so a
+					// broken one doesn't stop a clean halfway
+					cfg, err := config.Load(c.String("config"))
+					if err != nil {
+						return err
+					}
+
+					maxSize := cfg.CacheMaxSize
+					if c.IsSet("max-size") {
+						maxSize = c.String("max-size")
+					}
+
+					var limit int64
+					if maxSize != "" {
+						if limit, err = config.ParseSize(maxSize); err != nil {
+							return fmt.Errorf("invalid --max-size: %w", err)
+						}
+					}
+
+					manager := newCacheManager(cfg)
+					dryRun := c.Bool("dry-run")
+					if err := manager.Clean(c.String("older-than"), dryRun); err != nil {
+						return err
+					}
+
+					if maxSize == "" {
+						return nil
+					}
+
+					// The default model is needed by the next run
+					var keep []string
+					if !models.IsCustomPath(cfg.Model) {
+						keep = append(keep, fmt.Sprintf("ggml-%s.bin", models.Resolve(cfg.Model)))
+					}
+
+					return manager.Evict(limit, keep, dryRun)
 				},
 			},
 			{
@@ -61,8 +131,12 @@ func CacheCommand() *cli.Command {
 					},
 				},
 				Action: func(c *cli.Context) error {
-					manager := cache.NewManager("")
-					return manager.Clear(c.Bool("force"))
+					cfg, err := config.Load(c.String("config"))
+					if err != nil {
+						return err
+					}
+
+					return newCacheManager(cfg).Clear(c.Bool("force"))
 				},
 			},
 			{
@@ -70,8 +144,12 @@ func CacheCommand() *cli.Command {
 				Usage:     "Show cache directory path",
 				ArgsUsage: " ",
 				Action: func(c *cli.Context) error {
-					manager := cache.NewManager("")
-					return manager.ShowPath()
+					cfg, err := config.Load(c.String("config"))
+					if err != nil {
+						return err
+					}
+
+					return newCacheManager(cfg).ShowPath()
 				},
 			},
 		},
@@ -80,3 +158,24 @@ func CacheCommand() *cli.Command {
 		},
 	}
 }
+
+// newCacheManager creates a cache manager for the configured cache and
+// models directories
+func newCacheManager(cfg *config.Config) *cache.Manager {
+	manager := cache.NewManager(cfg.CacheDir)
+	manager.SetModelsDir(cfg.ModelsDir)
+
+	return manager
+}
+
+// autoCleanup removes cached files older than the configured retention when
+// auto_cleanup is on. It runs at most once a day and never fails the command.
+func autoCleanup(cfg *config.Config) {
+	if !cfg.AutoCleanup {
+		return
+	}
+
+	if err := newCacheManager(cfg).AutoClean(cfg.CacheRetention); err != nil {
+		slog.Debug(fmt.Sprintf("Automatic cache cleanup failed: %v", err), "error", err)
+	}
+}
@@ -0,0 +1,125 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pascalwhoop/ghospel/internal/config"
+	"github.com/pascalwhoop/ghospel/internal/models"
+	"github.com/urfave/cli/v2"
+)
+
+// bashCompletion delegates to the app's --generate-bash-completion flag
+const bashCompletion = `#!/usr/bin/env bash
+
+_ghospel_bash_autocomplete() {
+  if [[ "${COMP_WORDS[0]}" != "source" ]]; then
+    local cur opts
+    COMPREPLY=()
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    if [[ "$cur" == "-"* ]]; then
+      opts=$( ${COMP_WORDS[@]:0:$COMP_CWORD} ${cur} --generate-bash-completion )
+    else
+      opts=$( ${COMP_WORDS[@]:0:$COMP_CWORD} --generate-bash-completion )
+    fi
+    COMPREPLY=( $(compgen -W "${opts}" -- ${cur}) )
+    return 0
+  fi
+}
+
+complete -o bashdefault -o default -o nospace -F _ghospel_bash_autocomplete ghospel
+`
+
+// zshCompletion delegates to the app's --generate-bash-completion flag
+const zshCompletion = `#compdef ghospel
+
+_ghospel_zsh_autocomplete() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} --generate-bash-completion)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} --generate-bash-completion)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+
+compdef _ghospel_zsh_autocomplete ghospel
+`
+
+// CompletionCommand creates the completion command
+func CompletionCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "completion",
+		Usage:     "Generate shell completion scripts",
+		ArgsUsage: "<bash|zsh|fish>",
+		Description: `Print a completion script for the given shell to stdout.
+
+   Install with:
+     bash: ghospel completion bash > /usr/local/etc/bash_completion.d/ghospel
+     zsh:  ghospel completion zsh > "${fpath[1]}/_ghospel"
+     fish: ghospel completion fish > ~/.config/fish/completions/ghospel.fish`,
+		BashComplete: func(c *cli.Context) {
+			if c.NArg() > 0 {
+				return
+			}
+			for _, shell := range []string{"bash", "zsh", "fish"} {
+				fmt.Fprintln(c.App.Writer, shell)
+			}
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 1 {
+				return cli.ShowCommandHelp(c, "completion")
+			}
+
+			switch shell := c.Args().First(); shell {
+			case "bash":
+				fmt.Fprint(c.App.Writer, bashCompletion)
+			case "zsh":
+				fmt.Fprint(c.App.Writer, zshCompletion)
+			case "fish":
+				script, err := fishCompletion(c.App)
+				if err != nil {
+					return fmt.Errorf("failed to generate fish completion: %w", err)
+				}
+				fmt.Fprint(c.App.Writer, script)
+			default:
+				return fmt.Errorf("unsupported shell: %s (valid: bash, zsh, fish)", shell)
+			}
+
+			return nil
+		},
+	}
+}
+
+// fishCompletion generates the fish script, adding the dynamic model and config
+// key completions that urfave/cli's static fish generator doesn't know about
+func fishCompletion(app *cli.App) (string, error) {
+	script, err := app.ToFishCompletion()
+	if err != nil {
+		return "", err
+	}
+
+	manager := models.NewManager("")
+	modelNames := make([]string, 0, len(manager.AvailableModels()))
+
+	for _, model := range manager.AvailableModels() {
+		modelNames = append(modelNames, model.Name)
+	}
+
+	var b strings.Builder
+
+	b.WriteString(script)
+	fmt.Fprintf(&b, "\ncomplete -c ghospel -n '__fish_seen_subcommand_from models; and __fish_seen_subcommand_from download info' -f -a '%s'\n",
+		strings.Join(modelNames, " "))
+	fmt.Fprintf(&b, "complete -c ghospel -n '__fish_seen_subcommand_from config; and __fish_seen_subcommand_from set get' -f -a '%s'\n",
+		strings.Join(config.Keys, " "))
+
+	return b.String(), nil
+}
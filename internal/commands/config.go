@@ -35,12 +35,56 @@ func ConfigCommand() *cli.Command {
 				Description: `Set a configuration key to a specific value.
 
    Available keys:
-     model         - Default Whisper model (tiny, base, small, medium, large, large-v3)
+     model         - Default Whisper model (tiny, base, small, medium, large-v2, large-v3,
+                     large-v3-turbo, distil-large-v3; large and turbo are aliases)
+                     or an absolute path to a custom ggml model file
      cache_dir     - Directory for model and file caching  
+     models_dir    - Directory for model files, overrides cache_dir for models
+     cache_max_size - Size cache clean evicts down to, e.g. 10G (empty disables)
+     model_base_url - Mirror to download ggml-*.bin models from (env GHOSPEL_MODEL_BASE_URL wins)
      workers       - Number of concurrent transcription workers
      language      - Default language for transcription
-     output_format - Default output format (txt, srt, vtt)
-     ffmpeg_path   - Path to FFmpeg binary`,
+     prompt_file   - UTF-8 text file holding the default transcription prompt
+     output_format - Default output format (txt, md, srt, vtt, json), several separated by commas, or all
+     ffmpeg_path   - Path to FFmpeg binary
+     whisper_path  - Path to the whisper-cli binary, empty looks for it automatically
+     paragraph_words     - Target words per paragraph (default 50)
+     paragraph_sentences - Maximum significant sentences per paragraph (default 4)
+     min_sentence_words  - Words a sentence needs to count as significant (default 4)
+     no_format           - Write raw whisper text without paragraphs (true/false)
+     normalize           - Normalize loudness before transcription (true/false)
+     denoise             - Reduce background noise before transcription (true/false)
+     highpass            - High-pass filter cutoff in Hz, 0 disables it
+     resample_quality    - Resampler for the conversion to 16kHz: fast, high or best (default fast)
+     encoding            - Encoding of transcript files: utf-8, utf-8-bom, utf-16le or utf-16be (default utf-8)
+     audio_extensions    - Comma-separated file extensions picked up from directories, e.g. .mp3,.opus,.wma
+     retries             - Extra attempts after transient whisper failures (default 1)
+     beam_size           - Whisper beam search width, 1-8 (default 5)
+     temperature         - Whisper sampling temperature, 0-1 (default 0)
+     entropy_threshold   - Decode again above this segment entropy (default 2.4)
+     logprob_threshold   - Decode again below this average log probability (default -1)
+     no_speech_threshold - Treat segments as silence above this no-speech probability, 0-1 (default 0.6)
+     no_fallback         - Never decode again at higher temperatures (true/false)
+     suppress_non_speech - Suppress non-speech tokens such as ♪ and [Music] (true/false)
+     min_duration        - Skip files shorter than this, e.g. 1s (0 disables)
+     max_duration        - Skip files longer than this, e.g. 2h (0 disables)
+     no_header           - Omit the txt comment header and md metadata line (true/false)
+     header_template     - Go template for the txt header, e.g. "# {{.Title}} ({{.Duration}})"
+     repair_punctuation  - Add missing sentence punctuation and capitals (true/false)
+     repeat_guard        - Collapse hallucinated loops of alike segments (true/false)
+     repeat_similarity   - How alike segments must be for repeat_guard, 0-1 (default 0.9)
+     normalize_text      - Write spelled-out numbers, currencies and units as digits (true/false)
+     replace_file        - YAML or CSV file of phrases to replace, e.g. "get hub: GitHub"
+     censor              - Mask profanity in transcripts (true/false)
+     censor_words        - Word list file for censor, one word per line (empty uses the built-in list)`,
+				BashComplete: func(c *cli.Context) {
+					if c.NArg() > 0 {
+						return
+					}
+					for _, key := range config.Keys {
+						fmt.Fprintln(c.App.Writer, key)
+					}
+				},
 				Action: func(c *cli.Context) error {
 					if c.NArg() != 2 {
 						return cli.ShowCommandHelp(c, "set")
@@ -70,6 +114,19 @@ func ConfigCommand() *cli.Command {
 					return config.Get(cfg, key)
 				},
 			},
+			{
+				Name:      "edit",
+				Usage:     "Open the configuration file in $EDITOR",
+				ArgsUsage: " ",
+				Description: `Open the configuration file in $EDITOR (falling back to nano or vi),
+   creating it with defaults first if it doesn't exist.
+
+   The file is checked when the editor closes. Invalid YAML or unknown keys
+   are rejected and you can reopen the editor to fix them.`,
+				Action: func(c *cli.Context) error {
+					return config.Edit(c.String("config"))
+				},
+			},
 			{
 				Name:      "reset",
 				Usage:     "Reset configuration to defaults",
@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/pascalwhoop/ghospel/internal/diff"
+	"github.com/pascalwhoop/ghospel/internal/logging"
+	"github.com/pascalwhoop/ghospel/internal/transcription"
+	"github.com/urfave/cli/v2"
+	"golang.org/x/term"
+)
+
+// DiffCommand creates the diff command
+func DiffCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "diff",
+		Usage:     "Compare two transcripts of the same audio",
+		ArgsUsage: "<a> <b>",
+		Description: `Show how two transcripts differ, e.g. after switching models or prompts.
+
+   Transcripts can be in any output format (txt, md, srt, vtt, json) and
+   don't need to match; headers, timings and timestamp markers are ignored.
+
+   Examples:
+     ghospel diff talk.base.txt talk.large.txt
+     ghospel diff --level segment talk.srt talk-new.srt
+     ghospel diff --json a.json b.json`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "level",
+				Usage: "Compare individual words (word) or whole paragraphs/cues (segment)",
+				Value: diff.LevelWord,
+			},
+			&cli.BoolFlag{
+				Name:  "json",
+				Usage: "Print the changes and statistics as JSON",
+			},
+			&cli.StringFlag{
+				Name:  "color",
+				Usage: "Color the output: auto (when writing to a terminal), always or never",
+				Value: "auto",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			if c.NArg() != 2 {
+				return cli.ShowCommandHelp(c, "diff")
+			}
+
+			a, err := transcription.ReadSegments(c.Args().Get(0))
+			if err != nil {
+				return err
+			}
+
+			b, err := transcription.ReadSegments(c.Args().Get(1))
+			if err != nil {
+				return err
+			}
+
+			result, err := diff.Compare(a, b, c.String("level"))
+			if err != nil {
+				return err
+			}
+
+			if c.Bool("json") {
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+
+				return encoder.Encode(result)
+			}
+
+			var color bool
+			switch c.String("color") {
+			case "always":
+				color = true
+			case "never":
+			case "auto":
+				color = logging.Color() && term.IsTerminal(int(os.Stdout.Fd()))
+			default:
+				return fmt.Errorf("invalid --color: %s (valid: auto, always, never)", c.String("color"))
+			}
+
+			diff.Render(os.Stdout, result, color)
+
+			return nil
+		},
+	}
+}
@@ -1,6 +1,10 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+
+	"github.com/pascalwhoop/ghospel/internal/config"
 	"github.com/pascalwhoop/ghospel/internal/models"
 	"github.com/urfave/cli/v2"
 )
@@ -19,25 +23,104 @@ func ModelsCommand() *cli.Command {
 				Usage:     "List available and downloaded models",
 				ArgsUsage: " ",
 				Action: func(c *cli.Context) error {
-					manager := models.NewManager("")
+					manager := newModelManager(c)
 					return manager.List()
 				},
 			},
 			{
 				Name:      "download",
-				Usage:     "Download a specific model",
-				ArgsUsage: "<model-name>",
-				Description: `Download a Whisper model for offline use.
+				Usage:     "Download one or more models",
+				ArgsUsage: "<model-name...>",
+				Description: `Download Whisper models for offline use. Models that are already
+   present are skipped.
+
+   Available models: tiny, base, small, medium, large-v2, large-v3, large-v3-turbo,
+   distil-large-v3 and English-only variants such as base.en. large and turbo
+   are short for large-v3 and large-v3-turbo.
 
-   Available models: tiny, base, small, medium, large, large-v3`,
+   Examples:
+     ghospel models download large-v3-turbo
+     ghospel models download base small medium
+     ghospel models download --all`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Download every model in the registry",
+					},
+				},
+				BashComplete: completeModelNames,
 				Action: func(c *cli.Context) error {
-					if c.NArg() != 1 {
+					manager := newModelManager(c)
+
+					modelNames := c.Args().Slice()
+					if c.Bool("all") {
+						if len(modelNames) > 0 {
+							return fmt.Errorf("--all cannot be combined with model names")
+						}
+
+						for _, model := range manager.AvailableModels() {
+							modelNames = append(modelNames, model.Name)
+						}
+					}
+
+					switch len(modelNames) {
+					case 0:
 						return cli.ShowCommandHelp(c, "download")
+					case 1:
+						return manager.Download(modelNames[0])
+					default:
+						return manager.DownloadMany(modelNames)
 					}
+				},
+			},
+			{
+				Name:      "update",
+				Usage:     "Download models again that changed upstream",
+				ArgsUsage: "<model-name...>",
+				Description: `Check downloaded models against the published files and download
+   those that changed again. Models that are current are not downloaded.
 
-					modelName := c.Args().First()
-					manager := models.NewManager("")
-					return manager.Download(modelName)
+   The published checksum is compared where the server provides one (Hugging
+   Face does), otherwise the ETag or Last-Modified date recorded at download
+   time, or the file size. Models downloaded by older ghospel versions are
+   hashed once on the first check.
+
+   Examples:
+     ghospel models update large-v3
+     ghospel models update --all`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "Check every downloaded model",
+					},
+				},
+				BashComplete: completeModelNames,
+				Action: func(c *cli.Context) error {
+					manager := newModelManager(c)
+
+					modelNames := c.Args().Slice()
+					if c.Bool("all") {
+						if len(modelNames) > 0 {
+							return fmt.Errorf("--all cannot be combined with model names")
+						}
+
+						for _, model := range manager.AvailableModels() {
+							if _, err := os.Stat(model.Path); err == nil {
+								modelNames = append(modelNames, model.Name)
+							}
+						}
+
+						if len(modelNames) == 0 {
+							fmt.Println("No models downloaded yet")
+							return nil
+						}
+					}
+
+					if len(modelNames) == 0 {
+						return cli.ShowCommandHelp(c, "update")
+					}
+
+					return manager.Update(modelNames)
 				},
 			},
 			{
@@ -48,21 +131,22 @@ func ModelsCommand() *cli.Command {
    
    This will remove models that haven't been used recently.`,
 				Action: func(c *cli.Context) error {
-					manager := models.NewManager("")
+					manager := newModelManager(c)
 					return manager.Cleanup()
 				},
 			},
 			{
-				Name:      "info",
-				Usage:     "Show information about a specific model",
-				ArgsUsage: "<model-name>",
+				Name:         "info",
+				Usage:        "Show information about a specific model",
+				ArgsUsage:    "<model-name>",
+				BashComplete: completeModelName,
 				Action: func(c *cli.Context) error {
 					if c.NArg() != 1 {
 						return cli.ShowCommandHelp(c, "info")
 					}
 
 					modelName := c.Args().First()
-					manager := models.NewManager("")
+					manager := newModelManager(c)
 					return manager.Info(modelName)
 				},
 			},
@@ -72,3 +156,47 @@ func ModelsCommand() *cli.Command {
 		},
 	}
 }
+
+// completeModelName completes a single model name argument
+func completeModelName(c *cli.Context) {
+	if c.NArg() > 0 {
+		return
+	}
+
+	completeModelNames(c)
+}
+
+// completeModelNames prints the registry model names not yet on the command
+// line for shell completion
+func completeModelNames(c *cli.Context) {
+	given := make(map[string]bool)
+	for _, arg := range c.Args().Slice() {
+		given[arg] = true
+	}
+
+	manager := newModelManager(c)
+	for _, model := range manager.AvailableModels() {
+		if !given[model.Name] {
+			fmt.Fprintln(c.App.Writer, model.Name)
+		}
+	}
+}
+
+// newModelManager creates a model manager for the configured models directory,
+// falling back to the default cache when the config can't be read
+func newModelManager(c *cli.Context) *models.Manager {
+	cfg, err := config.Load(c.String("config"))
+	if err != nil {
+		return models.NewManager("")
+	}
+
+	dir := cfg.ModelsDir
+	if dir == "" {
+		dir = cfg.CacheDir
+	}
+
+	manager := models.NewManager(dir)
+	manager.SetBaseURL(cfg.ModelBaseURL)
+
+	return manager
+}
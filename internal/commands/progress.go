@@ -0,0 +1,147 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/internal/logging"
+	"github.com/pascalwhoop/ghospel/internal/transcription"
+	"github.com/schollz/progressbar/v3"
+)
+
+// defaultFileWeight stands in for files whose duration can't be probed
+// upfront (e.g. URLs) when no other file's duration is known either
+const defaultFileWeight = time.Minute
+
+// batchProgress is the batch progress bar, driven by the service as an
+// observer. Progress is weighted by audio duration so a 2-hour file advances
+// the bar more than a 30-second one, and the remaining time is estimated
+// from the realtime factor achieved so far. Status messages logged while it
+// is shown are printed above it. Batches of a single file get no bar.
+type batchProgress struct {
+	transcription.NopObserver
+
+	service   *transcription.Service
+	bar       *progressbar.ProgressBar
+	index     map[string]int // Position of each file of the batch, from 0
+	weights   []time.Duration
+	total     time.Duration
+	processed time.Duration
+	completed int
+	startTime time.Time
+}
+
+// newBatchProgress creates the progress bar for the batches of a service
+func newBatchProgress(service *transcription.Service) *batchProgress {
+	return &batchProgress{service: service}
+}
+
+// OnBatchStart probes the duration of every file and sets up the bar
+func (p *batchProgress) OnBatchStart(files []string, _ int) {
+	if len(files) < 2 {
+		return
+	}
+
+	p.index = make(map[string]int, len(files))
+	p.weights = make([]time.Duration, len(files))
+	p.total = 0
+
+	var known time.Duration
+
+	knownCount := 0
+
+	for i, file := range files {
+		p.index[file] = i
+
+		if transcription.IsURL(file) {
+			continue
+		}
+
+		duration, err := p.service.TranscribedDuration(file)
+		if err != nil {
+			continue
+		}
+
+		p.weights[i] = duration
+		known += p.weights[i]
+		knownCount++
+	}
+
+	// Assume unknown files are as long as the average known one
+	fallback := defaultFileWeight
+	if knownCount > 0 {
+		fallback = known / time.Duration(knownCount)
+	}
+
+	for i := range p.weights {
+		if p.weights[i] <= 0 {
+			p.weights[i] = fallback
+		}
+
+		p.total += p.weights[i]
+	}
+
+	p.bar = progressbar.NewOptions64(p.total.Milliseconds(),
+		progressbar.OptionSetDescription(p.describe()),
+		progressbar.OptionSetWriter(os.Stderr),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionSetPredictTime(false),
+		progressbar.OptionSetRenderBlankState(true),
+	)
+	p.startTime = time.Now()
+
+	logging.SetOverlay(p.bar)
+}
+
+// OnFileDone advances the bar past the file
+func (p *batchProgress) OnFileDone(file string, _ *transcription.FileStats) {
+	p.advance(file)
+}
+
+// OnError advances the bar past the file, failed files are done too
+func (p *batchProgress) OnError(file string, _ error) {
+	p.advance(file)
+}
+
+// OnBatchDone stops keeping the bar below status messages and ends its
+// line, so the summary starts on a fresh one
+func (p *batchProgress) OnBatchDone(*transcription.Report) {
+	if p.bar == nil {
+		return
+	}
+
+	logging.SetOverlay(nil)
+	fmt.Fprintln(os.Stderr)
+	p.bar = nil
+}
+
+// advance marks a file as done, whether it succeeded or not
+func (p *batchProgress) advance(file string) {
+	i, ok := p.index[file]
+	if p.bar == nil || !ok {
+		return
+	}
+
+	p.completed++
+	p.processed += p.weights[i]
+
+	p.bar.Describe(p.describe())
+	p.bar.Add64(p.weights[i].Milliseconds())
+}
+
+// describe renders the bar's label with the file count and, once a file is
+// done, the realtime factor and estimated remaining time
+func (p *batchProgress) describe() string {
+	label := fmt.Sprintf("Transcribing [%d/%d]", p.completed, len(p.weights))
+
+	elapsed := time.Since(p.startTime)
+	if p.completed == 0 || p.processed <= 0 || elapsed <= 0 {
+		return label
+	}
+
+	speed := p.processed.Seconds() / elapsed.Seconds()
+	remaining := time.Duration((p.total - p.processed).Seconds() / speed * float64(time.Second))
+
+	return fmt.Sprintf("%s %.1fx realtime, ~%s left", label, speed, remaining.Round(time.Second))
+}
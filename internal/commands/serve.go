@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/pascalwhoop/ghospel/internal/config"
+	"github.com/pascalwhoop/ghospel/internal/server"
+	"github.com/pascalwhoop/ghospel/internal/transcription"
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+	"github.com/urfave/cli/v2"
+)
+
+// ServeCommand creates the serve command
+func ServeCommand() *cli.Command {
+	return &cli.Command{
+		Name:      "serve",
+		Usage:     "Run a local HTTP server that transcribes uploaded audio",
+		ArgsUsage: " ",
+		Description: `Start an HTTP server so other programs can transcribe audio through ghospel.
+
+   POST an audio file as multipart form data in the "file" field to /transcribe.
+   The transcript is returned as JSON unless another format is requested.
+   Query parameters: model, language, prompt, format (txt, md, srt, vtt, json).
+   Defaults come from the config file; models must be downloaded beforehand.
+
+   Examples:
+     ghospel serve --addr :8080
+     curl -F file=@interview.mp3 'localhost:8080/transcribe?format=srt&language=en'`,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "addr",
+				Usage:   "Address to listen on",
+				Value:   "localhost:8080",
+				EnvVars: []string{"GHOSPEL_ADDR"},
+			},
+			&cli.IntFlag{
+				Name:  "max-concurrent",
+				Usage: "Transcriptions to run at once; further requests wait for a free slot",
+				Value: 1,
+			},
+			&cli.Int64Flag{
+				Name:  "max-upload-mb",
+				Usage: "Largest accepted upload in MB",
+				Value: 1024,
+			},
+			&cli.StringFlag{
+				Name:    "model",
+				Aliases: []string{"m"},
+				Usage:   "Default model for requests without a model parameter (default from config)",
+			},
+			&cli.StringFlag{
+				Name:    "language",
+				Aliases: []string{"l"},
+				Usage:   "Default language for requests without a language parameter (default from config)",
+			},
+		},
+		Action: func(c *cli.Context) error {
+			cfg, err := config.Load(c.String("config"))
+			if err != nil {
+				return fmt.Errorf("failed to load config: %w", err)
+			}
+
+			prompt := cfg.Prompt
+			if cfg.PromptFile != "" {
+				if prompt, err = transcription.LoadPrompt(cfg.PromptFile); err != nil {
+					return err
+				}
+			}
+
+			if c.Int("max-concurrent") < 1 {
+				return fmt.Errorf("invalid --max-concurrent: %d (must be 1 or more)", c.Int("max-concurrent"))
+			}
+
+			if c.Int64("max-upload-mb") < 1 {
+				return fmt.Errorf("invalid --max-upload-mb: %d (must be 1 or more)", c.Int64("max-upload-mb"))
+			}
+
+			opts := transcription.Options{
+				Model:             cfg.Model,
+				Language:          cfg.Language,
+				Prompt:            prompt,
+				Timestamps:        cfg.IncludeTimestamps,
+				NoFormat:          cfg.NoFormat,
+				Normalize:         cfg.Normalize,
+				Denoise:           cfg.Denoise,
+				HighPass:          cfg.HighPass,
+				Resample:          cfg.ResampleQuality,
+				Encoding:          cfg.Encoding,
+				BeamSize:          cfg.BeamSize,
+				Temperature:       cfg.Temperature,
+				EntropyThreshold:  cfg.EntropyThreshold,
+				LogprobThreshold:  cfg.LogprobThreshold,
+				NoSpeechThreshold: cfg.NoSpeechThreshold,
+				NoFallback:        cfg.NoFallback,
+				SuppressNonSpeech: cfg.SuppressNonSpeech,
+				NoHeader:          cfg.NoHeader,
+				ReplaceFile:       cfg.ReplaceFile,
+				NormalizeText:     cfg.NormalizeText,
+				RepairPunctuation: cfg.RepairPunctuation,
+				RepeatGuard:       cfg.RepeatGuard,
+				RepeatSimilarity:  cfg.RepeatSimilarity,
+				Censor:            cfg.Censor,
+				CensorWords:       cfg.CensorWords,
+				Formatter: transcription.FormatterOptions{
+					TargetWordCount:                cfg.ParagraphWords,
+					MaxSentencesPerChunk:           cfg.ParagraphSentences,
+					MinWordsForSignificantSentence: cfg.MinSentenceWords,
+				},
+				HeaderTemplate: cfg.HeaderTemplate,
+			}
+			if c.IsSet("model") {
+				opts.Model = c.String("model")
+			}
+			if c.IsSet("language") {
+				opts.Language = c.String("language")
+			}
+
+			modelsDir := cfg.ModelsDir
+			if modelsDir == "" {
+				modelsDir = cfg.CacheDir
+			}
+
+			if cfg.WhisperPath != "" {
+				if err := checkExecutable(cfg.WhisperPath); err != nil {
+					return fmt.Errorf("invalid whisper_path: %w", err)
+				}
+			}
+
+			transcriber := transcribe.New(transcribe.Config{
+				ModelsDir:   modelsDir,
+				FFmpegPath:  cfg.FFmpegPath,
+				WhisperPath: cfg.WhisperPath,
+				TempDir:     cfg.TempDir,
+			})
+			defer transcriber.Close()
+
+			srv := server.New(server.Options{
+				Addr:          c.String("addr"),
+				MaxConcurrent: c.Int("max-concurrent"),
+				MaxUploadSize: c.Int64("max-upload-mb") << 20,
+				TempDir:       cfg.TempDir,
+				Transcription: opts,
+			}, transcriber)
+
+			ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+			defer stop()
+
+			return srv.ListenAndServe(ctx)
+		},
+	}
+}
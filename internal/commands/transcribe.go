@@ -2,11 +2,23 @@ package commands
 
 import (
 	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"syscall"
+	"time"
 
+	"github.com/pascalwhoop/ghospel/internal/audio"
 	"github.com/pascalwhoop/ghospel/internal/config"
+	"github.com/pascalwhoop/ghospel/internal/logging"
+	"github.com/pascalwhoop/ghospel/internal/models"
 	"github.com/pascalwhoop/ghospel/internal/transcription"
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
 	"github.com/urfave/cli/v2"
 )
 
@@ -15,16 +27,18 @@ func TranscribeCommand() *cli.Command {
 	return &cli.Command{
 		Name:      "transcribe",
 		Usage:     "Transcribe audio files or directories",
-		ArgsUsage: "[files or directories...]",
+		ArgsUsage: "[files, directories or URLs...]",
 		Description: `Transcribe audio files to text using local Whisper models.
 
    Supports common audio formats: MP3, M4A, WAV, FLAC, MP4, etc.
-   Output files are created alongside input files with .txt extension.`,
+   Output files are created alongside input files with .txt extension.
+   http(s) URLs are downloaded first; their transcripts are written to the
+   current directory unless --output-dir is set.`,
 		Flags: []cli.Flag{
 			&cli.StringFlag{
 				Name:    "model",
 				Aliases: []string{"m"},
-				Usage:   "Whisper model to use (tiny, base, small, medium, large-v3, large-v3-turbo)",
+				Usage:   "Whisper model to use (tiny, base, small, medium, large-v2, large-v3, large-v3-turbo, distil-large-v3; large and turbo are short for large-v3 and large-v3-turbo) or an absolute path to a ggml model file; several comma-separated models each write <name>.<model>.<ext>",
 				Value:   "large-v3-turbo",
 				EnvVars: []string{"GHOSPEL_MODEL"},
 			},
@@ -34,6 +48,11 @@ func TranscribeCommand() *cli.Command {
 				Usage:   "Custom output directory (default: same as input)",
 				EnvVars: []string{"GHOSPEL_OUTPUT_DIR"},
 			},
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"O"},
+				Usage:   "Exact transcript path for a single input, e.g. -O notes.md (format from the extension unless --format is given)",
+			},
 			&cli.IntFlag{
 				Name:    "workers",
 				Aliases: []string{"w"},
@@ -57,6 +76,11 @@ func TranscribeCommand() *cli.Command {
 				Usage:   "Custom transcription prompt for better accuracy",
 				EnvVars: []string{"GHOSPEL_PROMPT"},
 			},
+			&cli.StringFlag{
+				Name:    "prompt-file",
+				Usage:   "Read the transcription prompt from a UTF-8 text file, e.g. a long vocabulary list",
+				EnvVars: []string{"GHOSPEL_PROMPT_FILE"},
+			},
 			&cli.StringFlag{
 				Name:    "language",
 				Aliases: []string{"l"},
@@ -67,7 +91,7 @@ func TranscribeCommand() *cli.Command {
 			&cli.StringFlag{
 				Name:    "format",
 				Aliases: []string{"f"},
-				Usage:   "Output format (txt, srt, vtt)",
+				Usage:   "Output format (txt, md, srt, vtt, json), several separated by commas, or all",
 				Value:   "txt",
 				EnvVars: []string{"GHOSPEL_FORMAT"},
 			},
@@ -76,6 +100,32 @@ func TranscribeCommand() *cli.Command {
 				Usage:   "Override default cache directory",
 				EnvVars: []string{"GHOSPEL_CACHE_DIR"},
 			},
+			&cli.StringFlag{
+				Name:    "temp-dir",
+				Usage:   "Directory for intermediate files; each run works in its own subdirectory below it",
+				EnvVars: []string{"GHOSPEL_TEMP_DIR"},
+			},
+			&cli.StringFlag{
+				Name:  "include-ext",
+				Usage: "Also pick up files with these extensions from directories, e.g. wma,amr",
+			},
+			&cli.StringFlag{
+				Name:  "exclude-ext",
+				Usage: "Ignore files with these extensions in directories, e.g. mp4,mkv",
+			},
+			&cli.StringSliceFlag{
+				Name:  "include",
+				Usage: "Only transcribe files whose name matches this glob, e.g. 'interview-*' (repeatable)",
+			},
+			&cli.StringSliceFlag{
+				Name:  "exclude",
+				Usage: "Skip files whose name matches this glob, e.g. '*draft*' (repeatable)",
+			},
+			&cli.StringFlag{
+				Name:  "progress",
+				Usage: "Progress output: bar (progress bar and status messages) or json (one lifecycle event per line on stderr, for scripts)",
+				Value: transcription.ProgressBar,
+			},
 			&cli.BoolFlag{
 				Name:    "quiet",
 				Aliases: []string{"q"},
@@ -86,69 +136,1208 @@ func TranscribeCommand() *cli.Command {
 				Aliases: []string{"F"},
 				Usage:   "Force re-transcription of files that already have output files",
 			},
+			&cli.StringFlag{
+				Name:  "overwrite-policy",
+				Usage: "What to do with files that already have a transcript: skip, overwrite, or rename (write name-2.txt and so on)",
+				Value: transcription.OverwriteSkip,
+			},
+			&cli.BoolFlag{
+				Name:  "normalize",
+				Usage: "Normalize loudness (ffmpeg loudnorm) before transcription, helps quiet recordings",
+			},
+			&cli.BoolFlag{
+				Name:  "denoise",
+				Usage: "Reduce background noise (ffmpeg afftdn) before transcription",
+			},
+			&cli.IntFlag{
+				Name:  "highpass",
+				Usage: "Apply a high-pass filter at this frequency in Hz to remove hum (e.g. 80)",
+			},
+			&cli.StringFlag{
+				Name:  "resample-quality",
+				Usage: "Resampler for the conversion to 16kHz: fast (ffmpeg default), high (SoX) or best (SoX, 28-bit precision, dithered)",
+				Value: transcribe.ResampleFast,
+			},
+			&cli.StringFlag{
+				Name:  "start",
+				Usage: "Only transcribe from this offset (e.g. 1:30, 00:05:00, 90s)",
+			},
+			&cli.StringFlag{
+				Name:  "end",
+				Usage: "Only transcribe up to this offset (e.g. 6:30)",
+			},
+			&cli.StringFlag{
+				Name:  "duration",
+				Usage: "Only transcribe this much audio from --start (e.g. 5m), alternative to --end",
+			},
+			&cli.BoolFlag{
+				Name:  "stream",
+				Usage: "Pipe ffmpeg output straight into whisper instead of writing temporary WAV files",
+			},
+			&cli.StringFlag{
+				Name:  "whisper-path",
+				Usage: "Path to the whisper-cli binary, instead of looking for it automatically (default from config)",
+			},
+			&cli.StringFlag{
+				Name:  "audio-track",
+				Usage: "Audio track of videos with several, by number (1 is the first) or language tag (e.g. eng)",
+			},
+			&cli.IntFlag{
+				Name:  "beam-size",
+				Usage: "Whisper beam search width (1-8); larger is slightly more accurate but slower",
+				Value: 5,
+			},
+			&cli.Float64Flag{
+				Name:  "temperature",
+				Usage: "Whisper sampling temperature (0-1); 0 is deterministic",
+			},
+			&cli.Float64Flag{
+				Name:  "entropy-threshold",
+				Usage: "Decode a segment again at a higher temperature when its entropy is above this; lower catches more repetition",
+				Value: transcribe.DefaultEntropyThreshold,
+			},
+			&cli.Float64Flag{
+				Name:  "logprob-threshold",
+				Usage: "Decode a segment again when its average log probability is below this (negative)",
+				Value: transcribe.DefaultLogprobThreshold,
+			},
+			&cli.Float64Flag{
+				Name:  "no-speech-threshold",
+				Usage: "Treat a segment as silence when its no-speech probability is above this (0-1); lower drops more",
+				Value: transcribe.DefaultNoSpeechThreshold,
+			},
+			&cli.BoolFlag{
+				Name:  "no-fallback",
+				Usage: "Keep whisper's first decoding instead of retrying failed segments at higher temperatures",
+			},
+			&cli.BoolFlag{
+				Name:  "suppress-non-speech",
+				Usage: "Keep whisper from writing non-speech tokens such as ♪ or [Music]",
+			},
+			&cli.BoolFlag{
+				Name:  "vad",
+				Usage: "Detect long silences and only transcribe the speech between them",
+			},
+			&cli.BoolFlag{
+				Name:  "word-timestamps",
+				Usage: "Include per-word timing in json and vtt output (karaoke-style captions)",
+			},
+			&cli.BoolFlag{
+				Name:  "diarize",
+				Usage: "Label speaker turns (Speaker 1/Speaker 2); needs a tinydiarize model such as small.en-tdrz",
+			},
+			&cli.StringFlag{
+				Name:  "channels",
+				Usage: "How to handle multi-channel audio: mix (downmix to mono), split (transcribe and label each channel), or a channel number to transcribe only that one",
+				Value: "mix",
+			},
+			&cli.IntFlag{
+				Name:  "max-line-chars",
+				Usage: "Split srt/vtt cues longer than this many characters (e.g. 42), 0 disables",
+			},
+			&cli.Float64Flag{
+				Name:  "max-cue-seconds",
+				Usage: "Split srt/vtt cues that stay on screen longer than this (e.g. 7), 0 disables",
+			},
+			&cli.BoolFlag{
+				Name:  "native-subtitles",
+				Usage: "Write the srt/vtt files whisper makes itself, keeping its exact cue timings",
+			},
+			&cli.StringFlag{
+				Name:  "report",
+				Usage: "Write a JSON summary of the run (per-file status, words, timings) to this path",
+			},
+			&cli.IntFlag{
+				Name:  "retries",
+				Usage: "Extra attempts for files where whisper fails transiently (exits without output)",
+				Value: 1,
+			},
+			&cli.IntFlag{
+				Name:  "paragraph-words",
+				Usage: "Target number of words per paragraph (default from config: 50)",
+			},
+			&cli.IntFlag{
+				Name:  "paragraph-sentences",
+				Usage: "Maximum number of significant sentences per paragraph (default from config: 4)",
+			},
+			&cli.IntFlag{
+				Name:  "min-sentence-words",
+				Usage: "Words a sentence needs to count as significant (default from config: 4)",
+			},
+			&cli.BoolFlag{
+				Name:  "no-format",
+				Usage: "Write the raw whisper text without paragraph formatting",
+			},
+			&cli.BoolFlag{
+				Name:  "no-header",
+				Usage: "Omit the comment header of txt transcripts and the metadata line of md transcripts",
+			},
+			&cli.StringFlag{
+				Name:  "header-template",
+				Usage: "Go template for the txt header with .Title, .Source, .Model, .Duration, .Language and .Date",
+			},
+			&cli.DurationFlag{
+				Name:  "min-duration",
+				Usage: "Skip files shorter than this, e.g. 1s to ignore junk clips (default from config)",
+			},
+			&cli.DurationFlag{
+				Name:  "max-duration",
+				Usage: "Skip files longer than this, e.g. 2h (default from config)",
+			},
+			&cli.StringFlag{
+				Name:  "since",
+				Usage: "Only transcribe files modified since a duration ago (e.g. 24h, 7d) or a date (e.g. 2024-01-01)",
+			},
+			&cli.IntFlag{
+				Name:  "wrap",
+				Usage: "Wrap txt and md paragraphs at this column without breaking words (0 = no wrapping)",
+			},
+			&cli.StringFlag{
+				Name:  "encoding",
+				Usage: "Encoding of transcript files: utf-8 (no BOM), utf-8-bom, utf-16le or utf-16be for tools that need them",
+				Value: transcription.EncodingUTF8,
+			},
+			&cli.BoolFlag{
+				Name:  "stdout",
+				Usage: "Write transcripts to stdout instead of files; status messages stay on stderr",
+			},
+			&cli.BoolFlag{
+				Name:  "embed",
+				Usage: "Store the transcript in the audio file's lyrics tag (and chapters with --timestamps), written to a .tagged copy",
+			},
+			&cli.BoolFlag{
+				Name:  "in-place",
+				Usage: "With --embed, tag the original audio file instead of writing a copy",
+			},
+			&cli.StringFlag{
+				Name:  "merge",
+				Usage: "Transcribe the inputs in order into one combined transcript at this path, e.g. --merge talk.srt part1.mp3 part2.mp3",
+			},
+			&cli.StringFlag{
+				Name:  "append",
+				Usage: "Append each transcript, in input order and below a line with its source and date, to this file, e.g. --append journal.md; inputs already in it are skipped",
+			},
+			&cli.BoolFlag{
+				Name:  "split-chapters",
+				Usage: "Write one transcript per chapter, named after its title, for inputs with chapters such as audiobooks (others get a single transcript)",
+			},
+			&cli.BoolFlag{
+				Name:  "segment-output",
+				Usage: "Also write each segment as a JSON line (start, end, text) to <name>.segments.jsonl next to the transcript",
+			},
+			&cli.BoolFlag{
+				Name:  "repeat-guard",
+				Usage: "Collapse runs of 3 or more identical or near-identical consecutive segments, whisper's hallucinated loops, into one (default from config)",
+			},
+			&cli.Float64Flag{
+				Name:  "repeat-similarity",
+				Usage: "How alike segments must be for --repeat-guard, from 0 to 1 (identical)",
+				Value: transcription.DefaultRepeatSimilarity,
+			},
+			&cli.BoolFlag{
+				Name:  "repair-punctuation",
+				Usage: "End unpunctuated sentences at pauses and capitalize sentence starts, for models that omit punctuation (heuristic, default from config)",
+			},
+			&cli.BoolFlag{
+				Name:  "normalize-text",
+				Usage: "Write spelled-out numbers, currencies, percentages and units as digits and symbols (English only, default from config)",
+			},
+			&cli.StringFlag{
+				Name:  "replace-file",
+				Usage: "YAML or CSV file of phrases to replace in transcripts, e.g. \"get hub: GitHub\" (default from config)",
+			},
+			&cli.BoolFlag{
+				Name:  "censor",
+				Usage: "Mask profanity in transcripts, e.g. \"shit\" becomes \"s***\" (default from config)",
+			},
+			&cli.StringFlag{
+				Name:  "censor-words",
+				Usage: "Word list file for --censor, one word per line, a trailing * matches longer words (default: built-in list)",
+			},
+			&cli.BoolFlag{
+				Name:  "front-matter",
+				Usage: "Start transcripts with a YAML front-matter block (title, model, duration, language, date)",
+			},
+			&cli.StringFlag{
+				Name:    "on-complete",
+				Usage:   "Shell command run after each transcript is written (output path is passed as an argument)",
+				EnvVars: []string{"GHOSPEL_ON_COMPLETE"},
+			},
+			&cli.BoolFlag{
+				Name:  "strict-hooks",
+				Usage: "Abort the batch if the --on-complete command fails",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-download",
+				Usage: "Keep audio downloaded from URL inputs in the cache instead of deleting it",
+			},
+			&cli.BoolFlag{
+				Name:  "keep-wav",
+				Usage: "Keep the converted WAV file whisper read (in the temp directory) and print its path",
+			},
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Usage: "Give up on a file after this long (e.g. 30m) and continue with the next",
+			},
+			&cli.DurationFlag{
+				Name:  "batch-timeout",
+				Usage: "Stop the whole run after this long (e.g. 8h), remaining files are marked failed",
+			},
+			&cli.BoolFlag{
+				Name:  "language-detect-only",
+				Usage: "Only detect the language of each input from its first 30 seconds (or --start to --end) and print it with whisper's confidence, without transcribing",
+			},
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "List the files that would be transcribed and their output paths without transcribing",
+			},
+			&cli.BoolFlag{
+				Name:  "resume",
+				Usage: "Skip the files an interrupted run of the same command already finished, as recorded in its journal",
+			},
+			&cli.BoolFlag{
+				Name:  "watch",
+				Usage: "Keep watching input directories and transcribe new audio files as they appear",
+			},
 		},
 		Action: func(c *cli.Context) error {
 			if c.NArg() == 0 {
 				return cli.ShowCommandHelp(c, "transcribe")
 			}
 
+			progress := strings.ToLower(c.String("progress"))
+			if !slices.Contains(transcription.ProgressModes, progress) {
+				return fmt.Errorf("invalid --progress: %s (valid: %s)", progress, strings.Join(transcription.ProgressModes, ", "))
+			}
+
+			overwritePolicy := strings.ToLower(c.String("overwrite-policy"))
+			if !slices.Contains(transcription.OverwritePolicies, overwritePolicy) {
+				return fmt.Errorf("invalid --overwrite-policy: %s (valid: %s)", overwritePolicy,
+					strings.Join(transcription.OverwritePolicies, ", "))
+			}
+			if c.Bool("force") && overwritePolicy != transcription.OverwriteOverwrite && c.IsSet("overwrite-policy") {
+				return fmt.Errorf("--force cannot be combined with --overwrite-policy %s", overwritePolicy)
+			}
+
+			// JSON progress takes over stderr: status messages are left out
+			// like with --quiet, and what's still logged is JSON as well
+			if progress == transcription.ProgressJSON {
+				if err := logging.Setup(os.Stderr, logging.FormatJSON); err != nil {
+					return err
+				}
+			}
+
+			// --quiet keeps warnings and errors, --verbose still wins
+			if (c.Bool("quiet") || progress == transcription.ProgressJSON) && !c.Bool("verbose") {
+				logging.SetLevel(slog.LevelWarn)
+			}
+
 			// Load configuration
 			cfg, err := config.Load(c.String("config"))
 			if err != nil {
 				return fmt.Errorf("failed to load config: %w", err)
 			}
 
+			// Auto cleanup only ever works on the global config's cache
+			global := cfg
+
+			// A .ghospel.yaml next to the inputs overrides the global config
+			if dir := inputDir(c.Args().Slice()); dir != "" {
+				if path := config.FindOverride(dir); path != "" {
+					cfg, err = config.ApplyOverride(cfg, path)
+					if err != nil {
+						return err
+					}
+
+					slog.Debug(fmt.Sprintf("⚙️  Using settings from %s", path), "path", path)
+				}
+			}
+
 			// Override config with CLI flags
 			opts := transcription.Options{
-				Model:      c.String("model"),
-				OutputDir:  c.String("output-dir"),
-				Workers:    c.Int("workers"),
-				Recursive:  c.Bool("recursive"),
-				Timestamps: c.Bool("timestamps"),
-				Prompt:     c.String("prompt"),
-				Language:   c.String("language"),
-				Format:     c.String("format"),
-				CacheDir:   c.String("cache-dir"),
-				Quiet:      c.Bool("quiet"),
-				Verbose:    c.Bool("verbose"),
-				Force:      c.Bool("force"),
+				Model:             c.String("model"),
+				OutputDir:         c.String("output-dir"),
+				Workers:           c.Int("workers"),
+				Recursive:         c.Bool("recursive"),
+				Timestamps:        c.Bool("timestamps"),
+				Prompt:            c.String("prompt"),
+				Language:          c.String("language"),
+				Format:            c.String("format"),
+				CacheDir:          c.String("cache-dir"),
+				TempDir:           c.String("temp-dir"),
+				Quiet:             c.Bool("quiet"),
+				Verbose:           c.Bool("verbose"),
+				Force:             c.Bool("force"),
+				OverwritePolicy:   overwritePolicy,
+				DryRun:            c.Bool("dry-run"),
+				Resume:            c.Bool("resume"),
+				KeepDownload:      c.Bool("keep-download"),
+				KeepWAV:           c.Bool("keep-wav"),
+				NoFormat:          c.Bool("no-format"),
+				FrontMatter:       c.Bool("front-matter"),
+				NoHeader:          c.Bool("no-header"),
+				MinDuration:       c.Duration("min-duration"),
+				MaxDuration:       c.Duration("max-duration"),
+				Wrap:              c.Int("wrap"),
+				Stdout:            c.Bool("stdout"),
+				Include:           c.StringSlice("include"),
+				Exclude:           c.StringSlice("exclude"),
+				Encoding:          strings.ToLower(c.String("encoding")),
+				Embed:             c.Bool("embed"),
+				InPlace:           c.Bool("in-place"),
+				SplitChapters:     c.Bool("split-chapters"),
+				SegmentOutput:     c.Bool("segment-output"),
+				Progress:          progress,
+				HeaderTemplate:    c.String("header-template"),
+				ReplaceFile:       c.String("replace-file"),
+				WhisperPath:       c.String("whisper-path"),
+				NormalizeText:     c.Bool("normalize-text"),
+				RepairPunctuation: c.Bool("repair-punctuation"),
+				RepeatGuard:       c.Bool("repeat-guard"),
+				RepeatSimilarity:  c.Float64("repeat-similarity"),
+				Censor:            c.Bool("censor"),
+				CensorWords:       c.String("censor-words"),
+				Normalize:         c.Bool("normalize"),
+				Denoise:           c.Bool("denoise"),
+				HighPass:          c.Int("highpass"),
+				Resample:          strings.ToLower(c.String("resample-quality")),
+				Stream:            c.Bool("stream"),
+				Retries:           c.Int("retries"),
+				ReportPath:        c.String("report"),
+				Diarize:           c.Bool("diarize"),
+				WordTimestamps:    c.Bool("word-timestamps"),
+				VAD:               c.Bool("vad"),
+				BeamSize:          c.Int("beam-size"),
+				Temperature:       c.Float64("temperature"),
+				EntropyThreshold:  c.Float64("entropy-threshold"),
+				LogprobThreshold:  c.Float64("logprob-threshold"),
+				NoSpeechThreshold: c.Float64("no-speech-threshold"),
+				NoFallback:        c.Bool("no-fallback"),
+				SuppressNonSpeech: c.Bool("suppress-non-speech"),
+				NativeSubtitles:   c.Bool("native-subtitles"),
+				Timeout:           c.Duration("timeout"),
+				BatchTimeout:      c.Duration("batch-timeout"),
+				OnComplete:        c.String("on-complete"),
+				StrictHooks:       c.Bool("strict-hooks"),
+				Subtitles: transcription.SubtitleOptions{
+					MaxLineChars:   c.Int("max-line-chars"),
+					MaxCueDuration: time.Duration(c.Float64("max-cue-seconds") * float64(time.Second)),
+				},
+				Formatter: transcription.FormatterOptions{
+					TargetWordCount:                c.Int("paragraph-words"),
+					MaxSentencesPerChunk:           c.Int("paragraph-sentences"),
+					MinWordsForSignificantSentence: c.Int("min-sentence-words"),
+				},
+			}
+
+			// Apply config values for every option the user did not set
+			// explicitly. c.IsSet is true for both flags and their env vars,
+			// so the precedence is flag > env > config > flag default.
+			applyConfig(c, &opts, cfg)
+			if err := applyPromptFile(c, &opts, cfg); err != nil {
+				return err
+			}
+
+			// Several comma-separated models transcribe every input with each
+			modelNames, err := parseModelList(opts.Model)
+			if err != nil {
+				return fmt.Errorf("invalid --model: %w", err)
+			}
+			opts.Model = modelNames[0]
+			if len(modelNames) > 1 {
+				opts.Models = modelNames
+			}
+
+			// Several comma-separated formats, or all, are written from the
+			// same transcription
+			formats, err := transcription.ParseFormats(opts.Format)
+			if err != nil {
+				return err
+			}
+			opts.Format = formats[0]
+			if len(formats) > 1 {
+				opts.Formats = formats
+			}
+			if len(opts.AudioExtensions) == 0 {
+				return fmt.Errorf("--exclude-ext leaves no audio extensions to pick up")
+			}
+			if err := transcription.CheckNamePatterns("include", opts.Include); err != nil {
+				return err
+			}
+			if err := transcription.CheckNamePatterns("exclude", opts.Exclude); err != nil {
+				return err
+			}
+			if opts.Retries < 0 {
+				return fmt.Errorf("invalid --retries: %d (must be 0 or more)", opts.Retries)
+			}
+			if opts.BeamSize < 1 || opts.BeamSize > transcribe.MaxBeamSize {
+				return fmt.Errorf("invalid --beam-size: %d (must be between 1 and %d)", opts.BeamSize, transcribe.MaxBeamSize)
+			}
+			if opts.Temperature < 0 || opts.Temperature > 1 {
+				return fmt.Errorf("invalid --temperature: %g (must be between 0 and 1)", opts.Temperature)
+			}
+			if opts.RepeatSimilarity <= 0 || opts.RepeatSimilarity > 1 {
+				return fmt.Errorf("invalid --repeat-similarity: %g (must be above 0 and at most 1)", opts.RepeatSimilarity)
+			}
+			if opts.EntropyThreshold <= 0 {
+				return fmt.Errorf("invalid --entropy-threshold: %g (must be positive)", opts.EntropyThreshold)
+			}
+			if opts.LogprobThreshold >= 0 {
+				return fmt.Errorf("invalid --logprob-threshold: %g (must be negative)", opts.LogprobThreshold)
+			}
+			if opts.NoSpeechThreshold <= 0 || opts.NoSpeechThreshold > 1 {
+				return fmt.Errorf("invalid --no-speech-threshold: %g (must be above 0 and at most 1)", opts.NoSpeechThreshold)
+			}
+			if opts.MinDuration < 0 || opts.MaxDuration < 0 {
+				return fmt.Errorf("--min-duration and --max-duration cannot be negative")
+			}
+			if opts.MinDuration > 0 && opts.MaxDuration > 0 && opts.MinDuration > opts.MaxDuration {
+				return fmt.Errorf("--min-duration (%s) must not exceed --max-duration (%s)", opts.MinDuration, opts.MaxDuration)
+			}
+			if opts.Stdout && opts.OnComplete != "" {
+				return fmt.Errorf("--on-complete needs transcript files and cannot be combined with --stdout")
+			}
+			if opts.InPlace && !opts.Embed {
+				return fmt.Errorf("--in-place only applies together with --embed")
+			}
+			if err := checkOutputDir(opts.OutputDir); err != nil {
+				return err
+			}
+			if _, err := transcription.ParseHeaderTemplate(opts.HeaderTemplate); err != nil {
+				return err
+			}
+			if opts.WhisperPath != "" {
+				if err := checkExecutable(opts.WhisperPath); err != nil {
+					return fmt.Errorf("invalid whisper path: %w", err)
+				}
+			}
+			if opts.ReplaceFile != "" {
+				if _, err := transcription.LoadReplacer(opts.ReplaceFile); err != nil {
+					return err
+				}
+			}
+			if c.IsSet("censor-words") {
+				opts.Censor = true
+			}
+			if opts.Censor {
+				if _, err := transcription.NewCensor(opts.CensorWords); err != nil {
+					return err
+				}
+			}
+			if opts.Wrap < 0 {
+				return fmt.Errorf("invalid --wrap: %d (must be 0 or a positive column)", opts.Wrap)
+			}
+			if opts.Subtitles.MaxLineChars < 0 || opts.Subtitles.MaxCueDuration < 0 {
+				return fmt.Errorf("--max-line-chars and --max-cue-seconds cannot be negative")
+			}
+			if opts.Timeout < 0 || opts.BatchTimeout < 0 {
+				return fmt.Errorf("timeouts must be positive durations")
+			}
+			if !slices.Contains(transcribe.ResampleQualities, opts.Resample) {
+				return fmt.Errorf("invalid --resample-quality: %s (valid: %s)", opts.Resample,
+					strings.Join(transcribe.ResampleQualities, ", "))
+			}
+			if !slices.Contains(transcription.Encodings, opts.Encoding) {
+				return fmt.Errorf("invalid --encoding: %s (valid: %s)", opts.Encoding,
+					strings.Join(transcription.Encodings, ", "))
+			}
+			if opts.Stdout && c.IsSet("encoding") && opts.Encoding != transcription.EncodingUTF8 {
+				return fmt.Errorf("--encoding only applies to transcript files, --stdout is always UTF-8")
+			}
+			if opts.HighPass < 0 {
+				return fmt.Errorf("invalid --highpass: %d (must be a positive frequency in Hz)", opts.HighPass)
+			}
+			for _, model := range modelNames {
+				if models.IsCustomPath(model) {
+					if err := models.ValidateModelFile(model); err != nil {
+						return err
+					}
+				}
+				if opts.Diarize && !models.SupportsDiarization(model) {
+					return fmt.Errorf("--diarize needs a tinydiarize model (e.g. --model small.en-tdrz), got %s", model)
+				}
+			}
+
+			if opts.Channel, opts.SplitChannels, err = parseChannels(c.String("channels")); err != nil {
+				return err
+			}
+			if opts.AudioTrack, opts.AudioLanguage, err = parseAudioTrack(c.String("audio-track")); err != nil {
+				return err
+			}
+			if opts.SplitChannels && opts.Diarize {
+				return fmt.Errorf("--channels split and --diarize cannot be combined")
+			}
+
+			if c.IsSet("since") {
+				if opts.Since, err = parseSince(c.String("since"), time.Now()); err != nil {
+					return fmt.Errorf("invalid --since: %w", err)
+				}
 			}
 
-			// Apply config defaults
-			if opts.CacheDir == "" {
-				opts.CacheDir = cfg.CacheDir
+			// Parse the optional time range
+			if opts.Start, err = parseTimeSpec(c.String("start")); err != nil {
+				return fmt.Errorf("invalid --start: %w", err)
+			}
+			if c.IsSet("end") && c.IsSet("duration") {
+				return fmt.Errorf("--end and --duration cannot be combined")
 			}
-			if opts.Model == "large-v3-turbo" && cfg.Model != "" {
-				opts.Model = cfg.Model
+			if opts.End, err = parseTimeSpec(c.String("end")); err != nil {
+				return fmt.Errorf("invalid --end: %w", err)
+			}
+			if c.IsSet("duration") {
+				length, err := parseTimeSpec(c.String("duration"))
+				if err != nil || length <= 0 {
+					return fmt.Errorf("invalid --duration: %s", c.String("duration"))
+				}
+				opts.End = opts.Start + length
 			}
-			if opts.Workers == 4 && cfg.Workers > 0 {
-				opts.Workers = cfg.Workers
+			if opts.End > 0 && opts.Start >= opts.End {
+				return fmt.Errorf("--start (%s) must be before --end (%s)", opts.Start, opts.End)
 			}
 
-			// Validate output format
-			validFormats := []string{"txt", "srt", "vtt"}
-			formatValid := false
-			for _, f := range validFormats {
-				if strings.EqualFold(opts.Format, f) {
-					formatValid = true
-					break
+			if c.IsSet("merge") {
+				if err := applyMergePath(c, &opts); err != nil {
+					return err
 				}
 			}
-			if !formatValid {
-				return fmt.Errorf("invalid format: %s (valid: %s)", opts.Format, strings.Join(validFormats, ", "))
+
+			if c.IsSet("output") {
+				if err := applyOutputPath(c, &opts); err != nil {
+					return err
+				}
+			}
+
+			if c.IsSet("append") {
+				if err := applyAppendPath(c, &opts); err != nil {
+					return err
+				}
+			}
+
+			if c.Bool("language-detect-only") {
+				switch {
+				case c.Bool("watch"):
+					return fmt.Errorf("--language-detect-only cannot be combined with --watch")
+				case opts.MergePath != "" || opts.AppendPath != "":
+					return fmt.Errorf("--language-detect-only cannot be combined with --merge or --append")
+				case opts.DryRun:
+					return fmt.Errorf("--language-detect-only cannot be combined with --dry-run")
+				}
+			}
+
+			if opts.SegmentOutput && opts.Stdout {
+				return fmt.Errorf("--segment-output cannot be combined with --stdout")
+			}
+
+			if opts.Resume {
+				switch {
+				case c.Bool("watch"):
+					return fmt.Errorf("--resume cannot be combined with --watch")
+				case opts.MergePath != "" || opts.AppendPath != "":
+					return fmt.Errorf("--resume cannot be combined with --merge or --append")
+				case c.Bool("language-detect-only"):
+					return fmt.Errorf("--resume cannot be combined with --language-detect-only")
+				}
+			}
+
+			if len(opts.Models) > 1 {
+				switch {
+				case c.Bool("watch"):
+					return fmt.Errorf("several models cannot be combined with --watch")
+				case opts.Stdout:
+					return fmt.Errorf("several models cannot be combined with --stdout")
+				case opts.OutputPath != "":
+					return fmt.Errorf("several models cannot be combined with --output, use --output-dir")
+				case opts.MergePath != "" || opts.AppendPath != "":
+					return fmt.Errorf("several models cannot be combined with --merge or --append")
+				case opts.Embed:
+					return fmt.Errorf("several models cannot be combined with --embed")
+				case opts.ReportPath != "":
+					return fmt.Errorf("several models cannot be combined with --report")
+				case c.Bool("language-detect-only"):
+					return fmt.Errorf("several models cannot be combined with --language-detect-only")
+				}
+			}
+
+			if len(opts.Formats) > 1 {
+				switch {
+				case opts.Stdout:
+					return fmt.Errorf("several formats cannot be combined with --stdout")
+				case opts.MergePath != "" || opts.AppendPath != "":
+					return fmt.Errorf("several formats cannot be combined with --merge or --append")
+				case opts.SplitChapters:
+					return fmt.Errorf("several formats cannot be combined with --split-chapters")
+				}
+			}
+
+			// Whisper's own subtitles are written as they are, so nothing may
+			// change their timeline or text
+			if opts.NativeSubtitles {
+				formats := opts.Formats
+				if len(formats) == 0 {
+					formats = []string{opts.Format}
+				}
+
+				switch {
+				case !slices.Contains(formats, "srt") && !slices.Contains(formats, "vtt"):
+					return fmt.Errorf("--native-subtitles needs --format srt or vtt")
+				case opts.Start > 0 || opts.SplitChapters:
+					return fmt.Errorf("--native-subtitles cannot be combined with --start or --split-chapters")
+				case opts.VAD || opts.SplitChannels || opts.Diarize || opts.WordTimestamps:
+					return fmt.Errorf("--native-subtitles cannot be combined with --vad, --channels split, --diarize " +
+						"or --word-timestamps")
+				case opts.Subtitles.MaxLineChars > 0 || opts.Subtitles.MaxCueDuration > 0:
+					return fmt.Errorf("--native-subtitles cannot be combined with --max-line-chars or --max-cue-seconds")
+				case opts.RepeatGuard || opts.RepairPunctuation || opts.NormalizeText || opts.ReplaceFile != "" || opts.Censor:
+					return fmt.Errorf("--native-subtitles keeps whisper's text and cannot be combined with --repeat-guard, " +
+						"--repair-punctuation, --normalize-text, --replace-file or --censor")
+				}
+			}
+
+			if opts.SplitChapters {
+				switch {
+				case opts.MergePath != "":
+					return fmt.Errorf("--split-chapters cannot be combined with --merge")
+				case opts.Stdout:
+					return fmt.Errorf("--split-chapters cannot be combined with --stdout")
+				case opts.Embed:
+					return fmt.Errorf("--split-chapters cannot be combined with --embed")
+				case opts.Start > 0 || opts.End > 0:
+					return fmt.Errorf("--split-chapters cannot be combined with --start, --end or --duration")
+				}
 			}
 
 			// Get input files/directories
 			inputs := make([]string, c.NArg())
 			for i := 0; i < c.NArg(); i++ {
+				if transcription.IsURL(c.Args().Get(i)) {
+					inputs[i] = c.Args().Get(i)
+					continue
+				}
 				inputs[i], _ = filepath.Abs(c.Args().Get(i))
 			}
 
+			// A dry run changes nothing, not even the cache
+			if !opts.DryRun {
+				cleanup := *global
+				if c.IsSet("cache-dir") {
+					cleanup.CacheDir = c.String("cache-dir")
+				}
+				autoCleanup(&cleanup)
+			}
+
 			// Create transcription service
 			service := transcription.NewService(opts)
+			defer service.Close()
+
+			// The progress bar observes the batch, unless JSON events, quiet or
+			// structured output take stderr or several models run at once
+			if progress == transcription.ProgressBar && !opts.Quiet && !logging.Structured() && len(opts.Models) == 0 {
+				service.AddObserver(newBatchProgress(service))
+			}
+
+			if c.Bool("watch") {
+				if opts.DryRun {
+					return fmt.Errorf("--dry-run cannot be combined with --watch")
+				}
+
+				ctx, stop := signal.NotifyContext(c.Context, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+
+				return service.Watch(ctx, inputs)
+			}
+
+			// Interrupted runs remove their temporary files too; ffmpeg and
+			// whisper get the same signal from the terminal
+			signals := make(chan os.Signal, 1)
+			signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+			defer signal.Stop(signals)
+
+			go func() {
+				<-signals
+				service.Close()
+				os.Exit(130)
+			}()
+
+			if c.Bool("language-detect-only") {
+				return service.DetectLanguages(inputs)
+			}
+
+			if opts.MergePath != "" {
+				return service.MergeFiles(inputs)
+			}
+
+			if opts.AppendPath != "" {
+				return service.AppendFiles(inputs)
+			}
 
 			// Start transcription
 			return service.TranscribeFiles(inputs)
 		},
 	}
 }
+
+// applyMergePath validates --merge and takes the output format from the
+// merge file's extension unless --format is given
+func applyMergePath(c *cli.Context, opts *transcription.Options) error {
+	path := c.String("merge")
+	if path == "" {
+		return fmt.Errorf("--merge needs an output file")
+	}
+
+	switch {
+	case c.Bool("watch"):
+		return fmt.Errorf("--merge cannot be combined with --watch")
+	case opts.Stdout:
+		return fmt.Errorf("--merge cannot be combined with --stdout")
+	case opts.Embed:
+		return fmt.Errorf("--merge cannot be combined with --embed")
+	case opts.Start > 0 || opts.End > 0:
+		return fmt.Errorf("--merge cannot be combined with --start, --end or --duration")
+	}
+
+	if err := formatFromPath(c, opts, "merge", path); err != nil {
+		return err
+	}
+
+	var err error
+	if opts.MergePath, err = filepath.Abs(path); err != nil {
+		return fmt.Errorf("invalid --merge path: %w", err)
+	}
+
+	return nil
+}
+
+// applyPromptFile reads the prompt from --prompt-file, or from the
+// prompt_file config key unless --prompt is given
+func applyPromptFile(c *cli.Context, opts *transcription.Options, cfg *config.Config) error {
+	if c.IsSet("prompt") && c.IsSet("prompt-file") {
+		return fmt.Errorf("--prompt cannot be combined with --prompt-file")
+	}
+
+	path := c.String("prompt-file")
+	if !c.IsSet("prompt-file") && !c.IsSet("prompt") {
+		path = cfg.PromptFile
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	prompt, err := transcription.LoadPrompt(path)
+	if err != nil {
+		return err
+	}
+
+	if words := len(strings.Fields(prompt)); words > transcription.PromptWordLimit {
+		slog.Warn(fmt.Sprintf("⚠️  The prompt in %s has %d words, whisper only uses about the last %d",
+			path, words, transcription.PromptWordLimit), "path", path, "words", words)
+	}
+
+	opts.Prompt = prompt
+
+	return nil
+}
+
+// applyAppendPath validates --append and takes the output format from the
+// file's extension unless --format is given. Entries are plain text or
+// Markdown, other formats can't be appended to.
+func applyAppendPath(c *cli.Context, opts *transcription.Options) error {
+	path := c.String("append")
+	if path == "" {
+		return fmt.Errorf("--append needs an output file")
+	}
+
+	switch {
+	case c.Bool("watch"):
+		return fmt.Errorf("--append cannot be combined with --watch")
+	case opts.Stdout:
+		return fmt.Errorf("--append cannot be combined with --stdout")
+	case opts.MergePath != "":
+		return fmt.Errorf("--append cannot be combined with --merge")
+	case opts.OutputPath != "" || opts.OutputDir != "":
+		return fmt.Errorf("--append cannot be combined with --output or --output-dir")
+	case opts.Embed || opts.SplitChapters || opts.SegmentOutput:
+		return fmt.Errorf("--append cannot be combined with --embed, --split-chapters or --segment-output")
+	case opts.FrontMatter:
+		return fmt.Errorf("--append cannot be combined with --front-matter")
+	}
+
+	if err := formatFromPath(c, opts, "append", path); err != nil {
+		return err
+	}
+
+	if format := strings.ToLower(opts.Format); format != "txt" && format != "md" {
+		return fmt.Errorf("--append writes txt or md, not %s", opts.Format)
+	}
+
+	if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+		return fmt.Errorf("--append %s is a directory", path)
+	}
+
+	var err error
+	if opts.AppendPath, err = filepath.Abs(path); err != nil {
+		return fmt.Errorf("invalid --append path: %w", err)
+	}
+
+	return nil
+}
+
+// applyOutputPath validates --output, which names the transcript of a
+// single input, and takes the output format from its extension unless
+// --format is given
+func applyOutputPath(c *cli.Context, opts *transcription.Options) error {
+	path := c.String("output")
+	if path == "" {
+		return fmt.Errorf("--output needs a file name")
+	}
+
+	switch {
+	case c.NArg() != 1:
+		return fmt.Errorf("--output needs exactly one input, got %d; use --output-dir for several", c.NArg())
+	case c.Bool("watch"):
+		return fmt.Errorf("--output cannot be combined with --watch")
+	case opts.Stdout:
+		return fmt.Errorf("--output cannot be combined with --stdout")
+	case opts.MergePath != "":
+		return fmt.Errorf("--output cannot be combined with --merge, which names its output itself")
+	case opts.OutputDir != "":
+		return fmt.Errorf("--output cannot be combined with --output-dir")
+	}
+
+	if stat, err := os.Stat(path); err == nil && stat.IsDir() {
+		return fmt.Errorf("--output %s is a directory, use --output-dir", path)
+	}
+
+	if err := formatFromPath(c, opts, "output", path); err != nil {
+		return err
+	}
+
+	var err error
+	if opts.OutputPath, err = filepath.Abs(path); err != nil {
+		return fmt.Errorf("invalid --output path: %w", err)
+	}
+
+	return nil
+}
+
+// formatFromPath sets the output format from the extension of the path given
+// with flag, unless --format is given, in which case it has to be one of its
+// formats and becomes the primary one
+func formatFromPath(c *cli.Context, opts *transcription.Options, flag, path string) error {
+	ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(path), "."))
+	switch {
+	case !c.IsSet("format") && slices.Contains(transcription.ValidFormats, ext):
+		opts.Format = ext
+		opts.Formats = nil
+	case c.IsSet("format") && ext != "" && len(opts.Formats) > 1:
+		i := slices.Index(opts.Formats, ext)
+		if i < 0 {
+			return fmt.Errorf("--%s %s does not match --format %s", flag, path, strings.Join(opts.Formats, ","))
+		}
+
+		opts.Formats = append([]string{ext}, slices.Delete(slices.Clone(opts.Formats), i, i+1)...)
+		opts.Format = ext
+	case c.IsSet("format") && ext != "" && !strings.EqualFold(ext, opts.Format):
+		return fmt.Errorf("--%s %s does not match --format %s", flag, path, opts.Format)
+	}
+
+	return nil
+}
+
+// applyConfig fills in config file values for every option that was not
+// given on the command line or through its environment variable. Empty
+// config strings and non-positive counts keep the flag default.
+func applyConfig(c *cli.Context, opts *transcription.Options, cfg *config.Config) {
+	setString := func(flag string, dst *string, value string) {
+		if !c.IsSet(flag) && value != "" {
+			*dst = value
+		}
+	}
+	setCount := func(flag string, dst *int, value int) {
+		if !c.IsSet(flag) && value > 0 {
+			*dst = value
+		}
+	}
+	setBool := func(flag string, dst *bool, value bool) {
+		if !c.IsSet(flag) {
+			*dst = value
+		}
+	}
+
+	setString("model", &opts.Model, cfg.Model)
+	setString("language", &opts.Language, cfg.Language)
+	setString("prompt", &opts.Prompt, cfg.Prompt)
+	setString("format", &opts.Format, cfg.OutputFormat)
+	setString("cache-dir", &opts.CacheDir, cfg.CacheDir)
+	setString("temp-dir", &opts.TempDir, cfg.TempDir)
+	setString("header-template", &opts.HeaderTemplate, cfg.HeaderTemplate)
+	setString("censor-words", &opts.CensorWords, cfg.CensorWords)
+	setString("replace-file", &opts.ReplaceFile, cfg.ReplaceFile)
+	setString("whisper-path", &opts.WhisperPath, cfg.WhisperPath)
+	setString("resample-quality", &opts.Resample, cfg.ResampleQuality)
+	setString("encoding", &opts.Encoding, cfg.Encoding)
+	setCount("workers", &opts.Workers, cfg.Workers)
+	setCount("beam-size", &opts.BeamSize, cfg.BeamSize)
+	setCount("highpass", &opts.HighPass, cfg.HighPass)
+	setCount("paragraph-words", &opts.Formatter.TargetWordCount, cfg.ParagraphWords)
+	setCount("paragraph-sentences", &opts.Formatter.MaxSentencesPerChunk, cfg.ParagraphSentences)
+	setCount("min-sentence-words", &opts.Formatter.MinWordsForSignificantSentence, cfg.MinSentenceWords)
+	setBool("timestamps", &opts.Timestamps, cfg.IncludeTimestamps)
+	setBool("no-format", &opts.NoFormat, cfg.NoFormat)
+	setBool("normalize", &opts.Normalize, cfg.Normalize)
+	setBool("denoise", &opts.Denoise, cfg.Denoise)
+	setBool("no-header", &opts.NoHeader, cfg.NoHeader)
+	setBool("censor", &opts.Censor, cfg.Censor)
+	setBool("normalize-text", &opts.NormalizeText, cfg.NormalizeText)
+	setBool("repair-punctuation", &opts.RepairPunctuation, cfg.RepairPunctuation)
+	setBool("repeat-guard", &opts.RepeatGuard, cfg.RepeatGuard)
+	if !c.IsSet("repeat-similarity") {
+		opts.RepeatSimilarity = cfg.RepeatSimilarity
+	}
+
+	if !c.IsSet("min-duration") {
+		opts.MinDuration = cfg.MinDuration
+	}
+	if !c.IsSet("max-duration") {
+		opts.MaxDuration = cfg.MaxDuration
+	}
+
+	if !c.IsSet("retries") {
+		opts.Retries = cfg.Retries
+	}
+	if !c.IsSet("temperature") {
+		opts.Temperature = cfg.Temperature
+	}
+	if !c.IsSet("entropy-threshold") {
+		opts.EntropyThreshold = cfg.EntropyThreshold
+	}
+	if !c.IsSet("logprob-threshold") {
+		opts.LogprobThreshold = cfg.LogprobThreshold
+	}
+	if !c.IsSet("no-speech-threshold") {
+		opts.NoSpeechThreshold = cfg.NoSpeechThreshold
+	}
+	setBool("no-fallback", &opts.NoFallback, cfg.NoFallback)
+	setBool("suppress-non-speech", &opts.SuppressNonSpeech, cfg.SuppressNonSpeech)
+
+	opts.AudioExtensions = audioExtensions(cfg.AudioExtensions, c.String("include-ext"), c.String("exclude-ext"))
+
+	// These have no flags and always come from the config
+	opts.ModelsDir = cfg.ModelsDir
+	opts.ModelBaseURL = cfg.ModelBaseURL
+	opts.FFmpegPath = cfg.FFmpegPath
+}
+
+// audioExtensions is the configured extension list, the default one if
+// empty, with --include-ext added and --exclude-ext removed
+func audioExtensions(configured []string, include, exclude string) []string {
+	exts := audio.NormalizeExtensions(configured)
+	if len(exts) == 0 {
+		exts = slices.Clone(transcribe.AudioExtensions)
+	}
+
+	for _, ext := range audio.ParseExtensions(include) {
+		if !slices.Contains(exts, ext) {
+			exts = append(exts, ext)
+		}
+	}
+
+	excluded := audio.ParseExtensions(exclude)
+
+	return slices.DeleteFunc(exts, func(ext string) bool {
+		return slices.Contains(excluded, ext)
+	})
+}
+
+// checkOutputDir rejects an --output-dir that is an existing file, e.g. a
+// transcript passed by mistake, before any work starts. Missing directories
+// are created later.
+func checkOutputDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+
+	if stat, err := os.Stat(dir); err == nil && !stat.IsDir() {
+		return fmt.Errorf("--output-dir %s is a file, not a directory; use --output to write a single transcript to a given file", dir)
+	}
+
+	return nil
+}
+
+// checkExecutable returns an error unless path is a file that can be run
+func checkExecutable(path string) error {
+	stat, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	if stat.IsDir() || stat.Mode().Perm()&0o111 == 0 {
+		return fmt.Errorf("%s is not an executable file", path)
+	}
+
+	return nil
+}
+
+// inputDir returns the directory of the first local input, where the
+// per-directory config lookup starts, or "" if all inputs are URLs
+func inputDir(inputs []string) string {
+	for _, input := range inputs {
+		if transcription.IsURL(input) {
+			continue
+		}
+
+		if stat, err := os.Stat(input); err == nil && stat.IsDir() {
+			return input
+		}
+
+		// Files and glob patterns
+		return filepath.Dir(input)
+	}
+
+	return ""
+}
+
+// parseModelList parses a comma-separated list of models such as
+// "tiny,base,small", with short names resolved, e.g. "turbo" to
+// "large-v3-turbo"
+func parseModelList(value string) ([]string, error) {
+	var names []string
+
+	for _, name := range strings.Split(value, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		if slices.Contains(names, models.Resolve(name)) {
+			return nil, fmt.Errorf("%s is listed twice", name)
+		}
+
+		names = append(names, models.Resolve(name))
+	}
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no model given")
+	}
+
+	return names, nil
+}
+
+// parseChannels parses the --channels value into a channel number (0 for the
+// mono downmix) and whether to transcribe each channel separately
+func parseChannels(value string) (int, bool, error) {
+	switch strings.ToLower(value) {
+	case "", "mix":
+		return 0, false, nil
+	case "split":
+		return 0, true, nil
+	}
+
+	channel, err := strconv.Atoi(value)
+	if err != nil || channel < 1 {
+		return 0, false, fmt.Errorf("invalid --channels: %s (use mix, split or a channel number starting at 1)", value)
+	}
+
+	return channel, false, nil
+}
+
+// parseAudioTrack parses the --audio-track value into a track number or a
+// language tag, both empty for the first track
+func parseAudioTrack(value string) (int, string, error) {
+	if value == "" {
+		return 0, "", nil
+	}
+
+	if track, err := strconv.Atoi(value); err == nil {
+		if track < 1 {
+			return 0, "", fmt.Errorf("invalid --audio-track: %s (tracks are numbered from 1)", value)
+		}
+
+		return track, "", nil
+	}
+
+	if !languageTagRegex.MatchString(value) {
+		return 0, "", fmt.Errorf("invalid --audio-track: %s (use a track number or a language tag such as eng)", value)
+	}
+
+	return 0, value, nil
+}
+
+// languageTagRegex matches the two- or three-letter language tags ffmpeg
+// reports for audio streams
+var languageTagRegex = regexp.MustCompile(`^[A-Za-z]{2,3}$`)
+
+// sinceDateLayouts are the absolute forms accepted by --since, in local time
+// unless they carry a zone
+var sinceDateLayouts = []string{"2006-01-02", "2006-01-02 15:04", "2006-01-02T15:04", time.RFC3339}
+
+// parseSince parses a --since value, either a duration before now such as
+// "24h" or "7d", or a date such as "2024-01-01"
+func parseSince(value string, now time.Time) (time.Time, error) {
+	for _, layout := range sinceDateLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.Local); err == nil {
+			return t, nil
+		}
+	}
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%s is neither a duration (24h, 7d) nor a date (2024-01-01)", value)
+		}
+
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return time.Time{}, fmt.Errorf("%s is neither a duration (24h, 7d) nor a date (2024-01-01)", value)
+		}
+	}
+
+	if d <= 0 {
+		return time.Time{}, fmt.Errorf("duration must be positive, got %s", value)
+	}
+
+	return now.Add(-d), nil
+}
+
+// parseTimeSpec parses a time offset given as clock time (HH:MM:SS, MM:SS,
+// optionally with fractional seconds), plain seconds, or a Go duration (90s, 5m)
+func parseTimeSpec(spec string) (time.Duration, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return 0, nil
+	}
+
+	if seconds, err := strconv.ParseFloat(spec, 64); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("negative offset: %s", spec)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+
+	if !strings.Contains(spec, ":") {
+		d, err := time.ParseDuration(spec)
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("unrecognized time: %s", spec)
+		}
+		return d, nil
+	}
+
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 {
+		return 0, fmt.Errorf("unrecognized time: %s", spec)
+	}
+
+	var total float64
+	for _, part := range parts {
+		value, err := strconv.ParseFloat(part, 64)
+		if err != nil || value < 0 {
+			return 0, fmt.Errorf("unrecognized time: %s", spec)
+		}
+		total = total*60 + value
+	}
+
+	return time.Duration(total * float64(time.Second)), nil
+}
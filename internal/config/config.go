@@ -2,36 +2,106 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
+	"math"
 	"os"
 	"path/filepath"
-
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/internal/audio"
+	"github.com/pascalwhoop/ghospel/internal/models"
 	"gopkg.in/yaml.v3"
 )
 
 // Config represents the application configuration
 type Config struct {
 	// Model settings
-	Model    string `yaml:"model"`
-	Language string `yaml:"language"`
-	Prompt   string `yaml:"prompt"`
+	Model      string `yaml:"model"`
+	Language   string `yaml:"language"`
+	Prompt     string `yaml:"prompt"`
+	PromptFile string `yaml:"prompt_file"` // UTF-8 file holding the prompt, instead of prompt
+
+	// Decoding settings
+	BeamSize    int     `yaml:"beam_size"`
+	Temperature float64 `yaml:"temperature"`
+
+	// Hallucination guards on silence and music
+	EntropyThreshold  float64 `yaml:"entropy_threshold"`
+	LogprobThreshold  float64 `yaml:"logprob_threshold"`
+	NoSpeechThreshold float64 `yaml:"no_speech_threshold"`
+	NoFallback        bool    `yaml:"no_fallback"`
+	SuppressNonSpeech bool    `yaml:"suppress_non_speech"`
 
 	// Processing settings
 	Workers   int    `yaml:"workers"`
 	ChunkSize string `yaml:"chunk_size"`
+	Retries   int    `yaml:"retries"`
+
+	// Files outside these limits are skipped, 0 disables a limit
+	MinDuration time.Duration `yaml:"min_duration"`
+	MaxDuration time.Duration `yaml:"max_duration"`
 
 	// Cache settings
 	CacheDir       string `yaml:"cache_dir"`
+	ModelsDir      string `yaml:"models_dir"`     // Defaults to cache_dir when empty
+	ModelBaseURL   string `yaml:"model_base_url"` // Mirror for model downloads, defaults to Hugging Face
 	CacheRetention string `yaml:"cache_retention"`
+	CacheMaxSize   string `yaml:"cache_max_size"` // e.g. "10G", cache clean evicts down to it, empty disables
 	AutoCleanup    bool   `yaml:"auto_cleanup"`
 
 	// Output settings
 	OutputFormat      string `yaml:"output_format"`
 	IncludeTimestamps bool   `yaml:"include_timestamps"`
 	PreserveStructure bool   `yaml:"preserve_structure"`
+	NoHeader          bool   `yaml:"no_header"`
+	HeaderTemplate    string `yaml:"header_template"` // Go template for the txt header, empty uses the default
+	RepairPunctuation bool   `yaml:"repair_punctuation"`
+	NormalizeText     bool   `yaml:"normalize_text"`
+	ReplaceFile       string `yaml:"replace_file"` // YAML or CSV file fixing known misrecognitions
+	Censor            bool   `yaml:"censor"`
+	CensorWords       string `yaml:"censor_words"` // Word list file for censor, empty uses the built-in list
+	Encoding          string `yaml:"encoding"`     // Transcript file encoding: utf-8, utf-8-bom, utf-16le or utf-16be
+
+	// Repeat guard against hallucinated loops
+	RepeatGuard      bool    `yaml:"repeat_guard"`
+	RepeatSimilarity float64 `yaml:"repeat_similarity"`
+
+	// Paragraph formatting
+	ParagraphWords     int  `yaml:"paragraph_words"`
+	ParagraphSentences int  `yaml:"paragraph_sentences"`
+	MinSentenceWords   int  `yaml:"min_sentence_words"`
+	NoFormat           bool `yaml:"no_format"`
 
 	// Audio processing
-	FFmpegPath string `yaml:"ffmpeg_path"`
-	TempDir    string `yaml:"temp_dir"`
+	FFmpegPath      string `yaml:"ffmpeg_path"`
+	WhisperPath     string `yaml:"whisper_path"` // Empty looks for whisper-cli automatically
+	TempDir         string `yaml:"temp_dir"`
+	Normalize       bool   `yaml:"normalize"`
+	Denoise         bool   `yaml:"denoise"`
+	HighPass        int    `yaml:"highpass"`
+	ResampleQuality string `yaml:"resample_quality"` // Resampler for the conversion to 16kHz: fast, high or best
+
+	// AudioExtensions are the file extensions picked up from input
+	// directories and watched folders
+	AudioExtensions []string `yaml:"audio_extensions"`
+}
+
+// Keys lists the configuration keys supported by Set and Get
+var Keys = []string{
+	"model", "cache_dir", "models_dir", "model_base_url", "workers", "language", "output_format", "ffmpeg_path",
+	"whisper_path",
+	"paragraph_words", "paragraph_sentences", "min_sentence_words", "no_format",
+	"normalize", "denoise", "highpass", "retries",
+	"beam_size", "temperature", "no_header", "header_template", "min_duration", "max_duration",
+	"censor", "censor_words", "replace_file", "normalize_text",
+	"repair_punctuation", "cache_max_size", "audio_extensions", "resample_quality",
+	"encoding", "prompt_file", "entropy_threshold", "logprob_threshold", "no_speech_threshold",
+	"no_fallback", "suppress_non_speech", "repeat_guard", "repeat_similarity",
 }
 
 // DefaultConfig returns the default configuration
@@ -48,10 +118,33 @@ func DefaultConfig() *Config {
 		CacheRetention:    "30d",
 		AutoCleanup:       true,
 		OutputFormat:      "txt",
+		Encoding:          "utf-8",
 		IncludeTimestamps: false,
 		PreserveStructure: true,
 		FFmpegPath:        "/opt/homebrew/bin/ffmpeg",
-		TempDir:           "/tmp/ghospel",
+		TempDir:           filepath.Join(os.TempDir(), "ghospel"),
+		AudioExtensions:   slices.Clone(audio.DefaultExtensions),
+
+		ParagraphWords:     50,
+		ParagraphSentences: 4,
+		MinSentenceWords:   4,
+		NoFormat:           false,
+
+		Normalize:       false,
+		Denoise:         false,
+		HighPass:        0,
+		ResampleQuality: audio.ResampleFast,
+
+		Retries: 1,
+
+		BeamSize:    5,
+		Temperature: 0,
+
+		EntropyThreshold:  2.4,
+		LogprobThreshold:  -1,
+		NoSpeechThreshold: 0.6,
+
+		RepeatSimilarity: 0.9,
 	}
 }
 
@@ -85,8 +178,9 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	if err := yaml.Unmarshal(data, cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	cfg, err = Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath, err)
 	}
 
 	return cfg, nil
@@ -136,46 +230,250 @@ func Set(configPath, key, value string) error {
 
 	switch key {
 	case "model":
-		validModels := []string{"tiny", "base", "small", "medium", "large-v3", "large-v3-turbo"}
-		valid := false
-
-		for _, m := range validModels {
-			if value == m {
-				valid = true
-				break
-			}
+		if filepath.IsAbs(value) {
+			// A custom ggml model file instead of a registry name
+			cfg.Model = value
+			break
 		}
 
-		if !valid {
-			return fmt.Errorf("invalid model: %s (valid: tiny, base, small, medium, large-v3, large-v3-turbo)", value)
+		if !isValidModel(value) {
+			return fmt.Errorf("invalid model: %s (valid: %s, or an absolute path to a model file)",
+				value, strings.Join(validModels, ", "))
 		}
 
-		cfg.Model = value
+		cfg.Model = models.Resolve(value)
 	case "cache_dir":
 		cfg.CacheDir = value
+	case "models_dir":
+		cfg.ModelsDir = value
+	case "cache_max_size":
+		if value != "" {
+			if _, err := ParseSize(value); err != nil {
+				return fmt.Errorf("invalid value for %s: %w", key, err)
+			}
+		}
+
+		cfg.CacheMaxSize = value
+	case "model_base_url":
+		if value != "" && !strings.HasPrefix(value, "http://") && !strings.HasPrefix(value, "https://") {
+			return fmt.Errorf("invalid value for %s: %s (expected an http(s) URL)", key, value)
+		}
+
+		cfg.ModelBaseURL = value
 	case "workers":
-		// Simple validation - you might want to use strconv.Atoi for proper conversion
-		cfg.Workers = 4 // placeholder
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxWorkers {
+			return fmt.Errorf("invalid value for %s: %s (expected 1 to %d)", key, value, maxWorkers)
+		}
+
+		cfg.Workers = n
 	case "language":
 		cfg.Language = value
-	case "output_format":
-		validFormats := []string{"txt", "srt", "vtt"}
-		valid := false
-
-		for _, f := range validFormats {
-			if value == f {
-				valid = true
-				break
-			}
+	case "prompt_file":
+		if value != "" && cfg.Prompt != "" {
+			return fmt.Errorf("%s cannot be combined with prompt, remove prompt from the config first", key)
 		}
 
-		if !valid {
-			return fmt.Errorf("invalid format: %s (valid: txt, srt, vtt)", value)
+		cfg.PromptFile = value
+	case "output_format":
+		if !isValidFormat(value) {
+			return fmt.Errorf("invalid format: %s (valid: %s, a comma-separated list of them, or all)", value,
+				strings.Join(validFormats, ", "))
 		}
 
 		cfg.OutputFormat = value
+	case "encoding":
+		if !slices.Contains(validEncodings, value) {
+			return fmt.Errorf("invalid value for %s: %s (expected %s)", key, value, strings.Join(validEncodings, ", "))
+		}
+
+		cfg.Encoding = value
 	case "ffmpeg_path":
 		cfg.FFmpegPath = value
+	case "whisper_path":
+		cfg.WhisperPath = value
+	case "paragraph_words":
+		n, err := parsePositiveInt(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.ParagraphWords = n
+	case "paragraph_sentences":
+		n, err := parsePositiveInt(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.ParagraphSentences = n
+	case "min_sentence_words":
+		n, err := parsePositiveInt(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.MinSentenceWords = n
+	case "no_format":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.NoFormat = b
+	case "normalize":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.Normalize = b
+	case "denoise":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.Denoise = b
+	case "highpass":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s: %s (expected a frequency in Hz, 0 disables)", key, value)
+		}
+
+		cfg.HighPass = n
+	case "resample_quality":
+		if !slices.Contains(audio.ResampleQualities, value) {
+			return fmt.Errorf("invalid value for %s: %s (expected %s)", key, value, strings.Join(audio.ResampleQualities, ", "))
+		}
+
+		cfg.ResampleQuality = value
+	case "audio_extensions":
+		exts := audio.ParseExtensions(value)
+		if len(exts) == 0 {
+			return fmt.Errorf("invalid value for %s: %s (expected extensions such as .mp3,.opus)", key, value)
+		}
+
+		cfg.AudioExtensions = exts
+	case "retries":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("invalid value for %s: %s (expected 0 or more)", key, value)
+		}
+
+		cfg.Retries = n
+	case "beam_size":
+		// 8 is the most decoders whisper.cpp supports
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 8 {
+			return fmt.Errorf("invalid value for %s: %s (expected 1 to 8)", key, value)
+		}
+
+		cfg.BeamSize = n
+	case "temperature":
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t < 0 || t > 1 {
+			return fmt.Errorf("invalid value for %s: %s (expected 0 to 1)", key, value)
+		}
+
+		cfg.Temperature = t
+	case "entropy_threshold":
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t <= 0 {
+			return fmt.Errorf("invalid value for %s: %s (expected a positive number)", key, value)
+		}
+
+		cfg.EntropyThreshold = t
+	case "logprob_threshold":
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t >= 0 {
+			return fmt.Errorf("invalid value for %s: %s (expected a negative number)", key, value)
+		}
+
+		cfg.LogprobThreshold = t
+	case "no_speech_threshold":
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t <= 0 || t > 1 {
+			return fmt.Errorf("invalid value for %s: %s (expected above 0, at most 1)", key, value)
+		}
+
+		cfg.NoSpeechThreshold = t
+	case "no_fallback":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.NoFallback = b
+	case "suppress_non_speech":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.SuppressNonSpeech = b
+	case "no_header":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.NoHeader = b
+	case "min_duration", "max_duration":
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return fmt.Errorf("invalid value for %s: %s (expected a duration such as 1s or 2h, 0 disables)", key, value)
+		}
+
+		if key == "min_duration" {
+			cfg.MinDuration = d
+		} else {
+			cfg.MaxDuration = d
+		}
+	case "censor":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.Censor = b
+	case "censor_words":
+		cfg.CensorWords = value
+	case "replace_file":
+		cfg.ReplaceFile = value
+	case "normalize_text":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.NormalizeText = b
+	case "repair_punctuation":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.RepairPunctuation = b
+	case "repeat_guard":
+		b, err := parseBool(key, value)
+		if err != nil {
+			return err
+		}
+
+		cfg.RepeatGuard = b
+	case "repeat_similarity":
+		t, err := strconv.ParseFloat(value, 64)
+		if err != nil || t <= 0 || t > 1 {
+			return fmt.Errorf("invalid value for %s: %s (expected above 0, at most 1)", key, value)
+		}
+
+		cfg.RepeatSimilarity = t
+	case "header_template":
+		if _, err := template.New("header").Parse(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+
+		cfg.HeaderTemplate = value
 	default:
 		return fmt.Errorf("unknown config key: %s", key)
 	}
@@ -184,7 +482,7 @@ func Set(configPath, key, value string) error {
 		return fmt.Errorf("failed to save config: %w", err)
 	}
 
-	fmt.Printf("Set %s = %s\n", key, value)
+	slog.Info(fmt.Sprintf("Set %s = %s", key, value), "key", key, "value", value)
 
 	return nil
 }
@@ -196,14 +494,82 @@ func Get(cfg *Config, key string) error {
 		fmt.Println(cfg.Model)
 	case "cache_dir":
 		fmt.Println(cfg.CacheDir)
+	case "models_dir":
+		fmt.Println(cfg.ModelsDir)
+	case "model_base_url":
+		fmt.Println(cfg.ModelBaseURL)
 	case "workers":
 		fmt.Println(cfg.Workers)
 	case "language":
 		fmt.Println(cfg.Language)
+	case "prompt_file":
+		fmt.Println(cfg.PromptFile)
 	case "output_format":
 		fmt.Println(cfg.OutputFormat)
+	case "encoding":
+		fmt.Println(cfg.Encoding)
 	case "ffmpeg_path":
 		fmt.Println(cfg.FFmpegPath)
+	case "whisper_path":
+		fmt.Println(cfg.WhisperPath)
+	case "paragraph_words":
+		fmt.Println(cfg.ParagraphWords)
+	case "paragraph_sentences":
+		fmt.Println(cfg.ParagraphSentences)
+	case "min_sentence_words":
+		fmt.Println(cfg.MinSentenceWords)
+	case "no_format":
+		fmt.Println(cfg.NoFormat)
+	case "normalize":
+		fmt.Println(cfg.Normalize)
+	case "denoise":
+		fmt.Println(cfg.Denoise)
+	case "highpass":
+		fmt.Println(cfg.HighPass)
+	case "resample_quality":
+		fmt.Println(cfg.ResampleQuality)
+	case "audio_extensions":
+		fmt.Println(strings.Join(cfg.AudioExtensions, ","))
+	case "retries":
+		fmt.Println(cfg.Retries)
+	case "beam_size":
+		fmt.Println(cfg.BeamSize)
+	case "temperature":
+		fmt.Println(cfg.Temperature)
+	case "entropy_threshold":
+		fmt.Println(cfg.EntropyThreshold)
+	case "logprob_threshold":
+		fmt.Println(cfg.LogprobThreshold)
+	case "no_speech_threshold":
+		fmt.Println(cfg.NoSpeechThreshold)
+	case "no_fallback":
+		fmt.Println(cfg.NoFallback)
+	case "suppress_non_speech":
+		fmt.Println(cfg.SuppressNonSpeech)
+	case "no_header":
+		fmt.Println(cfg.NoHeader)
+	case "header_template":
+		fmt.Println(cfg.HeaderTemplate)
+	case "censor":
+		fmt.Println(cfg.Censor)
+	case "censor_words":
+		fmt.Println(cfg.CensorWords)
+	case "replace_file":
+		fmt.Println(cfg.ReplaceFile)
+	case "normalize_text":
+		fmt.Println(cfg.NormalizeText)
+	case "repair_punctuation":
+		fmt.Println(cfg.RepairPunctuation)
+	case "repeat_guard":
+		fmt.Println(cfg.RepeatGuard)
+	case "repeat_similarity":
+		fmt.Println(cfg.RepeatSimilarity)
+	case "cache_max_size":
+		fmt.Println(cfg.CacheMaxSize)
+	case "min_duration":
+		fmt.Println(cfg.MinDuration)
+	case "max_duration":
+		fmt.Println(cfg.MaxDuration)
 	default:
 		return fmt.Errorf("unknown config key: %s", key)
 	}
@@ -211,6 +577,51 @@ func Get(cfg *Config, key string) error {
 	return nil
 }
 
+// parsePositiveInt parses a config value that must be a positive integer
+func parsePositiveInt(key, value string) (int, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid value for %s: %s (expected a positive integer)", key, value)
+	}
+
+	return n, nil
+}
+
+// sizeRegex matches sizes such as "500M", "10G", "1.5GB" or "2TiB"
+var sizeRegex = regexp.MustCompile(`(?i)^(\d+(?:\.\d+)?)\s*([KMGT]?)(?:i?B)?$`)
+
+// ParseSize parses a size such as "10G" or "500MB" into bytes. Units are
+// powers of 1024, like the sizes ghospel prints.
+func ParseSize(value string) (int64, error) {
+	match := sizeRegex.FindStringSubmatch(strings.TrimSpace(value))
+	if match == nil {
+		return 0, fmt.Errorf("invalid size: %s (expected e.g. 500M or 10G)", value)
+	}
+
+	n, err := strconv.ParseFloat(match[1], 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size: %s (expected e.g. 500M or 10G)", value)
+	}
+
+	// No unit means bytes
+	exp := 0
+	if match[2] != "" {
+		exp = strings.Index("KMGT", strings.ToUpper(match[2])) + 1
+	}
+
+	return int64(n * math.Pow(1024, float64(exp))), nil
+}
+
+// parseBool parses a config value that must be a boolean
+func parseBool(key, value string) (bool, error) {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("invalid value for %s: %s (expected true or false)", key, value)
+	}
+
+	return b, nil
+}
+
 // Reset resets configuration to defaults
 func Reset(configPath string) error {
 	cfg := DefaultConfig()
@@ -218,7 +629,7 @@ func Reset(configPath string) error {
 		return fmt.Errorf("failed to reset config: %w", err)
 	}
 
-	fmt.Println("Configuration reset to defaults")
+	slog.Info("Configuration reset to defaults", "path", configPath)
 
 	return nil
 }
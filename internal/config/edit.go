@@ -0,0 +1,115 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Edit opens the configuration file in $EDITOR, creating it from defaults
+// first if needed. Changes are made to a copy and only saved once they parse;
+// otherwise the user can reopen the editor to fix them or discard them.
+func Edit(configPath string) error {
+	if _, err := os.Stat(configPath); os.IsNotExist(err) {
+		if err := Save(DefaultConfig(), configPath); err != nil {
+			return fmt.Errorf("failed to create default config: %w", err)
+		}
+	}
+
+	original, err := os.ReadFile(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	editor, err := findEditor()
+	if err != nil {
+		return err
+	}
+
+	// Keep the .yaml extension so editors pick the right syntax highlighting
+	draft, err := os.CreateTemp(filepath.Dir(configPath), ".config-*.yaml")
+	if err != nil {
+		return fmt.Errorf("failed to create draft: %w", err)
+	}
+	defer os.Remove(draft.Name())
+
+	if _, err := draft.Write(original); err != nil {
+		draft.Close()
+		return fmt.Errorf("failed to write draft: %w", err)
+	}
+	draft.Close()
+
+	stdin := bufio.NewReader(os.Stdin)
+
+	for {
+		if err := runEditor(editor, draft.Name()); err != nil {
+			return err
+		}
+
+		edited, err := os.ReadFile(draft.Name())
+		if err != nil {
+			return fmt.Errorf("failed to read draft: %w", err)
+		}
+
+		if _, err := Parse(edited); err != nil {
+			fmt.Fprintf(os.Stderr, "❌ Invalid configuration: %v\n", err)
+			fmt.Fprint(os.Stderr, "Reopen the editor to fix it? [Y/n] ")
+
+			answer, _ := stdin.ReadString('\n')
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(answer)), "n") {
+				return errors.New("configuration not saved")
+			}
+
+			continue
+		}
+
+		if bytes.Equal(edited, original) {
+			slog.Info("No changes made")
+			return nil
+		}
+
+		if err := os.WriteFile(configPath, edited, 0o644); err != nil {
+			return fmt.Errorf("failed to write config file: %w", err)
+		}
+
+		slog.Info(fmt.Sprintf("✅ Configuration saved to %s", configPath), "path", configPath)
+
+		return nil
+	}
+}
+
+// findEditor returns $EDITOR, falling back to nano and then vi
+func findEditor() (string, error) {
+	if editor := os.Getenv("EDITOR"); editor != "" {
+		return editor, nil
+	}
+
+	for _, editor := range []string{"nano", "vi"} {
+		if _, err := exec.LookPath(editor); err == nil {
+			return editor, nil
+		}
+	}
+
+	return "", errors.New("no editor found, set $EDITOR")
+}
+
+// runEditor opens path in the editor. $EDITOR may carry arguments (e.g.
+// "code --wait"), so it is run through the shell with the path appended.
+func runEditor(editor, path string) error {
+	cmd := exec.Command("sh", "-c", editor+` "$@"`, "ghospel-editor", path)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("editor %q failed: %w", editor, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OverrideFileName is the per-directory config file layered over the global one
+const OverrideFileName = ".ghospel.yaml"
+
+// overrideKeys are the keys a .ghospel.yaml may set: model, language,
+// decoding and output settings. Program paths, directories and download URLs
+// stay in the global config, so a folder from elsewhere can't make ghospel
+// run another binary or write and delete files outside it.
+var overrideKeys = []string{
+	"model", "language", "prompt", "prompt_file",
+	"beam_size", "temperature", "entropy_threshold", "logprob_threshold", "no_speech_threshold",
+	"no_fallback", "suppress_non_speech", "repeat_guard", "repeat_similarity",
+	"output_format", "include_timestamps", "preserve_structure", "no_header", "header_template",
+	"repair_punctuation", "normalize_text", "replace_file", "censor", "censor_words", "encoding",
+	"paragraph_words", "paragraph_sentences", "min_sentence_words", "no_format",
+}
+
+// FindOverride returns the nearest .ghospel.yaml in dir or one of its
+// parents, or "" if there is none
+func FindOverride(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		path := filepath.Join(dir, OverrideFileName)
+		if stat, err := os.Stat(path); err == nil && !stat.IsDir() {
+			return path
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+
+		dir = parent
+	}
+}
+
+// ApplyOverride returns a copy of cfg with the keys set in the override file
+// at path replacing the global values. The file accepts the overrideKeys of
+// the global config and is validated the same way.
+func ApplyOverride(cfg *Config, path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	merged := *cfg
+
+	result, err := parseOnto(&merged, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	if err := checkOverrideKeys(data); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	return result, nil
+}
+
+// checkOverrideKeys rejects keys outside overrideKeys, naming each with its
+// line. Unknown keys are left to parseOnto.
+func checkOverrideKeys(data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return err
+	}
+
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return nil
+	}
+
+	var errs []error
+
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key := mapping.Content[i]
+		if slices.Contains(overrideKeys, key.Value) {
+			continue
+		}
+
+		errs = append(errs, fmt.Errorf("line %d: %s can only be set in the global config", key.Line, key.Value))
+	}
+
+	return errors.Join(errs...)
+}
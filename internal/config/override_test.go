@@ -0,0 +1,52 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyOverride(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+	}{
+		{"model and language", "model: large-v3\nlanguage: de\n", ""},
+		{"output settings", "output_format: srt,txt\nno_header: true\n", ""},
+		{"whisper binary", "language: de\nwhisper_path: /tmp/evil\n", "line 2: whisper_path can only be set in the global config"},
+		{"ffmpeg binary", "ffmpeg_path: ./ffmpeg\n", "ffmpeg_path can only be set"},
+		{"cache directory", "cache_dir: .\n", "cache_dir can only be set"},
+		{"model mirror", "model_base_url: https://example.com\n", "model_base_url can only be set"},
+		{"unknown key", "modle: base\n", "not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), OverrideFileName)
+			if err := os.WriteFile(path, []byte(tt.yaml), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg := DefaultConfig()
+
+			merged, err := ApplyOverride(cfg, path)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("ApplyOverride: %v", err)
+				}
+
+				if merged == cfg {
+					t.Error("ApplyOverride changed the global config in place")
+				}
+
+				return
+			}
+
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ApplyOverride error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
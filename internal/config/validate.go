@@ -0,0 +1,144 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/pascalwhoop/ghospel/internal/models"
+	"gopkg.in/yaml.v3"
+)
+
+// validModels are the registry model names accepted for the model key
+var validModels = []string{
+	"tiny", "tiny.en", "base", "base.en", "small", "small.en", "small.en-tdrz",
+	"medium", "medium.en", "large-v2", "large-v3", "large-v3-turbo", "distil-large-v3",
+}
+
+// validFormats are the accepted output formats
+var validFormats = []string{"txt", "md", "srt", "vtt", "json"}
+
+// validEncodings are the accepted transcript file encodings
+var validEncodings = []string{"utf-8", "utf-8-bom", "utf-16le", "utf-16be"}
+
+// maxWorkers caps the workers setting
+const maxWorkers = 64
+
+// Parse decodes configuration YAML on top of the defaults and validates it.
+// Unknown keys, bad enum values and out-of-range numbers are all reported,
+// with the line they appear on.
+func Parse(data []byte) (*Config, error) {
+	return parseOnto(DefaultConfig(), data)
+}
+
+// parseOnto decodes YAML over cfg, so keys missing from data keep cfg's values
+func parseOnto(cfg *Config, data []byte) (*Config, error) {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	// Decode again into a node tree to locate keys for error messages
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+
+	if err := cfg.validate(&root); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// isValidModel reports whether model is a registry name, an alias of one or
+// a custom model path
+func isValidModel(model string) bool {
+	return filepath.IsAbs(model) || slices.Contains(validModels, models.Resolve(model))
+}
+
+// isValidFormat accepts an output format, several separated by commas, or
+// "all" for every format
+func isValidFormat(value string) bool {
+	for _, format := range strings.Split(value, ",") {
+		format = strings.TrimSpace(format)
+		if format != "all" && !slices.Contains(validFormats, format) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// validate checks enums and numeric ranges. root is the parsed document and
+// is used to point at the line of an offending key.
+func (c *Config) validate(root *yaml.Node) error {
+	var errs []error
+
+	check := func(ok bool, key, format string, args ...any) {
+		if ok {
+			return
+		}
+
+		msg := fmt.Sprintf("invalid %s: %s", key, fmt.Sprintf(format, args...))
+		if line := keyLine(root, key); line > 0 {
+			msg = fmt.Sprintf("line %d: %s", line, msg)
+		}
+
+		errs = append(errs, errors.New(msg))
+	}
+
+	check(isValidModel(c.Model), "model", "%q (valid: %s, or an absolute path to a model file)",
+		c.Model, strings.Join(validModels, ", "))
+	check(isValidFormat(c.OutputFormat), "output_format", "%q (valid: %s, a comma-separated list of them, or all)",
+		c.OutputFormat, strings.Join(validFormats, ", "))
+	check(slices.Contains(validEncodings, c.Encoding), "encoding", "%q (valid: %s)",
+		c.Encoding, strings.Join(validEncodings, ", "))
+	check(c.Prompt == "" || c.PromptFile == "", "prompt_file", "%q cannot be combined with prompt", c.PromptFile)
+	check(c.Workers >= 1 && c.Workers <= maxWorkers, "workers", "%d (expected 1 to %d)", c.Workers, maxWorkers)
+	check(c.Retries >= 0, "retries", "%d (expected 0 or more)", c.Retries)
+	check(c.BeamSize >= 1 && c.BeamSize <= 8, "beam_size", "%d (expected 1 to 8)", c.BeamSize)
+	check(c.Temperature >= 0 && c.Temperature <= 1, "temperature", "%g (expected 0 to 1)", c.Temperature)
+	check(c.RepeatSimilarity > 0 && c.RepeatSimilarity <= 1, "repeat_similarity", "%g (expected above 0, at most 1)",
+		c.RepeatSimilarity)
+	check(c.EntropyThreshold > 0, "entropy_threshold", "%g (expected a positive number)", c.EntropyThreshold)
+	check(c.LogprobThreshold < 0, "logprob_threshold", "%g (expected a negative number)", c.LogprobThreshold)
+	check(c.NoSpeechThreshold > 0 && c.NoSpeechThreshold <= 1, "no_speech_threshold", "%g (expected above 0, at most 1)",
+		c.NoSpeechThreshold)
+	check(c.MinDuration >= 0, "min_duration", "%s (expected 0 or a positive duration)", c.MinDuration)
+	check(c.MaxDuration >= 0, "max_duration", "%s (expected 0 or a positive duration)", c.MaxDuration)
+	check(c.MaxDuration == 0 || c.MinDuration <= c.MaxDuration, "min_duration", "%s is longer than max_duration %s",
+		c.MinDuration, c.MaxDuration)
+	check(c.HighPass >= 0, "highpass", "%d (expected 0 or a frequency in Hz)", c.HighPass)
+	check(c.ParagraphWords > 0, "paragraph_words", "%d (expected a positive integer)", c.ParagraphWords)
+	check(c.ParagraphSentences > 0, "paragraph_sentences", "%d (expected a positive integer)", c.ParagraphSentences)
+	_, templateErr := template.New("header").Parse(c.HeaderTemplate)
+	check(templateErr == nil, "header_template", "%v", templateErr)
+	check(c.MinSentenceWords > 0, "min_sentence_words", "%d (expected a positive integer)", c.MinSentenceWords)
+
+	return errors.Join(errs...)
+}
+
+// keyLine returns the line of a top-level key in the document, or 0 if the
+// key isn't present (e.g. the value came from the defaults)
+func keyLine(root *yaml.Node, key string) int {
+	if root.Kind != yaml.DocumentNode || len(root.Content) == 0 {
+		return 0
+	}
+
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i].Line
+		}
+	}
+
+	return 0
+}
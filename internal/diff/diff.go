@@ -0,0 +1,185 @@
+// Package diff compares transcripts word by word or segment by segment, to
+// show how changing the model or prompt changed the output.
+package diff
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/sergi/go-diff/diffmatchpatch"
+)
+
+// Supported comparison levels
+const (
+	LevelWord    = "word"
+	LevelSegment = "segment"
+)
+
+// Op is the kind of a change
+type Op string
+
+// Change operations
+const (
+	OpEqual  Op = "equal"
+	OpInsert Op = "insert"
+	OpDelete Op = "delete"
+)
+
+// Change is a run of words, or a single segment, present in both
+// transcripts (equal), only the second (insert) or only the first (delete)
+type Change struct {
+	Op   Op     `json:"op"`
+	Text string `json:"text"`
+}
+
+// Stats summarizes a comparison, counted in words or segments depending on
+// the level
+type Stats struct {
+	CountA     int     `json:"count_a"`
+	CountB     int     `json:"count_b"`
+	Equal      int     `json:"equal"`
+	Inserted   int     `json:"inserted"`
+	Deleted    int     `json:"deleted"`
+	Similarity float64 `json:"similarity"` // Share of matching units, 0 to 1
+}
+
+// Result is the outcome of comparing two transcripts
+type Result struct {
+	Level   string   `json:"level"`
+	Changes []Change `json:"changes"`
+	Stats   Stats    `json:"stats"`
+}
+
+// Compare diffs the segments of two transcripts at the given level
+func Compare(a, b []string, level string) (*Result, error) {
+	switch level {
+	case LevelWord:
+		return compare(strings.Fields(strings.Join(a, " ")), strings.Fields(strings.Join(b, " ")), level, true), nil
+	case LevelSegment:
+		return compare(a, b, level, false), nil
+	default:
+		return nil, fmt.Errorf("invalid level: %s (valid: %s, %s)", level, LevelWord, LevelSegment)
+	}
+}
+
+// compare diffs two token sequences. Each distinct token is mapped to a
+// single rune so diffmatchpatch's character diff works on whole tokens.
+// With merge, consecutive tokens of the same change are joined into one.
+func compare(a, b []string, level string, merge bool) *Result {
+	ids := make(map[string]rune)
+	tokens := []string{}
+
+	encode := func(seq []string) []rune {
+		runes := make([]rune, len(seq))
+		for i, token := range seq {
+			id, ok := ids[token]
+			if !ok {
+				// Start past the surrogate range so every id is a valid rune
+				id = rune(0xE000 + len(tokens))
+				ids[token] = id
+				tokens = append(tokens, token)
+			}
+
+			runes[i] = id
+		}
+
+		return runes
+	}
+
+	dmp := diffmatchpatch.New()
+	dmp.DiffTimeout = 0
+
+	diffs := dmp.DiffMainRunes(encode(a), encode(b), false)
+
+	result := &Result{Level: level, Changes: []Change{}}
+	result.Stats.CountA = len(a)
+	result.Stats.CountB = len(b)
+
+	for _, d := range diffs {
+		op := OpEqual
+		switch d.Type {
+		case diffmatchpatch.DiffInsert:
+			op = OpInsert
+		case diffmatchpatch.DiffDelete:
+			op = OpDelete
+		}
+
+		var texts []string
+		for _, id := range d.Text {
+			texts = append(texts, tokens[id-0xE000])
+		}
+
+		switch op {
+		case OpEqual:
+			result.Stats.Equal += len(texts)
+		case OpInsert:
+			result.Stats.Inserted += len(texts)
+		case OpDelete:
+			result.Stats.Deleted += len(texts)
+		}
+
+		if merge {
+			result.Changes = append(result.Changes, Change{Op: op, Text: strings.Join(texts, " ")})
+			continue
+		}
+
+		for _, text := range texts {
+			result.Changes = append(result.Changes, Change{Op: op, Text: text})
+		}
+	}
+
+	if total := len(a) + len(b); total > 0 {
+		result.Stats.Similarity = float64(2*result.Stats.Equal) / float64(total)
+	} else {
+		result.Stats.Similarity = 1
+	}
+
+	return result
+}
+
+// ANSI escape codes used for colored output
+const (
+	colorRed   = "\033[31m"
+	colorGreen = "\033[32m"
+	colorReset = "\033[0m"
+)
+
+// Render writes a human-readable diff followed by a summary line. Without
+// color, words are marked like git's word diff: [-deleted-] and {+added+};
+// segments are prefixed with "-" and "+".
+func Render(w io.Writer, result *Result, color bool) {
+	mark := func(op Op, text string) string {
+		switch {
+		case op == OpEqual:
+			return text
+		case color && op == OpDelete:
+			return colorRed + text + colorReset
+		case color:
+			return colorGreen + text + colorReset
+		case op == OpDelete:
+			return "[-" + text + "-]"
+		default:
+			return "{+" + text + "+}"
+		}
+	}
+
+	if result.Level == LevelWord {
+		parts := make([]string, len(result.Changes))
+		for i, change := range result.Changes {
+			parts[i] = mark(change.Op, change.Text)
+		}
+
+		fmt.Fprintln(w, strings.Join(parts, " "))
+	} else {
+		prefixes := map[Op]string{OpEqual: "  ", OpDelete: "- ", OpInsert: "+ "}
+		for _, change := range result.Changes {
+			fmt.Fprintln(w, mark(change.Op, prefixes[change.Op]+change.Text))
+		}
+	}
+
+	unit := result.Level + "s"
+	fmt.Fprintf(w, "\n📊 %d %s deleted, %d inserted, %.1f%% similar (%d vs %d %s)\n",
+		result.Stats.Deleted, unit, result.Stats.Inserted, result.Stats.Similarity*100,
+		result.Stats.CountA, result.Stats.CountB, unit)
+}
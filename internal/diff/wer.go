@@ -0,0 +1,65 @@
+package diff
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WordErrorRate is the word error rate of a hypothesis transcript against a
+// reference: the substituted, deleted and inserted words of the cheapest
+// alignment divided by the words of the reference. 0 is a perfect match, it
+// can exceed 1 when the hypothesis has many extra words. Case and
+// punctuation are ignored, so only the recognized words count.
+func WordErrorRate(reference, hypothesis string) float64 {
+	ref := werWords(reference)
+	hyp := werWords(hypothesis)
+
+	if len(ref) == 0 {
+		if len(hyp) == 0 {
+			return 0
+		}
+
+		return 1
+	}
+
+	// Levenshtein distance over words, keeping one row at a time
+	prev := make([]int, len(hyp)+1)
+	curr := make([]int, len(hyp)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ref); i++ {
+		curr[0] = i
+		for j := 1; j <= len(hyp); j++ {
+			cost := 1
+			if ref[i-1] == hyp[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return float64(prev[len(hyp)]) / float64(len(ref))
+}
+
+// werWords splits a transcript into lower-cased words without the
+// punctuation around them, so "Hello," and "hello" are the same word
+func werWords(text string) []string {
+	var words []string
+
+	for _, field := range strings.Fields(strings.ToLower(text)) {
+		word := strings.TrimFunc(field, func(r rune) bool {
+			return unicode.IsPunct(r) || unicode.IsSymbol(r)
+		})
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+
+	return words
+}
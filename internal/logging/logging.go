@@ -0,0 +1,160 @@
+// Package logging routes ghospel's status output through log/slog.
+//
+// By default messages are written to stderr exactly as given, which keeps
+// the friendly emoji console output. With the json format every message
+// becomes a structured record including its attributes, so runs can be
+// parsed by other tools. Transcripts and other command results are not
+// logs and keep going to stdout.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Supported log formats
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Formats lists the values accepted by --log-format
+var Formats = []string{FormatText, FormatJSON}
+
+var (
+	level = new(slog.LevelVar)
+
+	// console is the human handler, nil when logging structured records
+	console *consoleHandler
+)
+
+// Overlay is a line kept below the console messages, such as a progress
+// bar. It is cleared before each message and drawn again after it.
+type Overlay interface {
+	Clear() error
+	RenderBlank() error
+}
+
+// SetOverlay keeps o below the console messages until it is replaced or
+// removed with nil. It has no effect on structured logs.
+func SetOverlay(o Overlay) {
+	if console == nil {
+		return
+	}
+
+	console.mu.Lock()
+	defer console.mu.Unlock()
+
+	console.overlay = o
+}
+
+// HasOverlay reports whether an overlay is shown, in which case other
+// interactive output like a second progress bar would garble it
+func HasOverlay() bool {
+	if console == nil {
+		return false
+	}
+
+	console.mu.Lock()
+	defer console.mu.Unlock()
+
+	return console.overlay != nil
+}
+
+func init() {
+	console = &consoleHandler{w: os.Stderr}
+	slog.SetDefault(slog.New(console))
+}
+
+// Setup installs the default logger writing the given format to w
+func Setup(w io.Writer, format string) error {
+	switch strings.ToLower(format) {
+	case "", FormatText:
+		console = &consoleHandler{w: w}
+		slog.SetDefault(slog.New(console))
+	case FormatJSON:
+		console = nil
+		slog.SetDefault(slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: level})))
+	default:
+		return fmt.Errorf("invalid log format: %s (valid: %s)", format, strings.Join(Formats, ", "))
+	}
+
+	return nil
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return 0, fmt.Errorf("invalid log level: %s (valid: debug, info, warn, error)", name)
+	}
+
+	return l, nil
+}
+
+// SetLevel sets the minimum level of messages that are logged
+func SetLevel(l slog.Level) {
+	level.Set(l)
+}
+
+// Structured reports whether structured records are logged, in which case
+// interactive output like progress bars should be left out
+func Structured() bool {
+	return console == nil
+}
+
+// Blank writes an empty line to separate sections of the console output.
+// Structured logs have no use for it and skip it.
+func Blank() {
+	if console == nil || level.Level() > slog.LevelInfo {
+		return
+	}
+
+	console.mu.Lock()
+	defer console.mu.Unlock()
+
+	console.print("")
+}
+
+// consoleHandler writes just the message of each record, attributes are
+// for structured output and already part of the human-readable message
+type consoleHandler struct {
+	mu      sync.Mutex
+	w       io.Writer
+	overlay Overlay
+}
+
+// print writes a line above the overlay. The caller holds h.mu.
+func (h *consoleHandler) print(line string) error {
+	if h.overlay != nil {
+		_ = h.overlay.Clear()
+	}
+
+	_, err := fmt.Fprintln(h.w, decorate(line))
+
+	if h.overlay != nil {
+		_ = h.overlay.RenderBlank()
+	}
+
+	return err
+}
+
+func (h *consoleHandler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func (h *consoleHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	return h.print(r.Message)
+}
+
+func (h *consoleHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *consoleHandler) WithGroup(string) slog.Handler { return h }
@@ -0,0 +1,88 @@
+package logging
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Style is how console messages are decorated
+type Style int
+
+// Console styles
+const (
+	StyleEmoji Style = iota // Emoji as written, the default on terminals
+	StylePlain              // Emoji left out, for logs and CI
+	StyleASCII              // Emoji replaced by ASCII markers such as [OK]
+)
+
+var (
+	style = StyleEmoji
+
+	// colorDisabled is set by --no-color and the NO_COLOR conventions
+	colorDisabled bool
+)
+
+// emojiRegex matches an emoji with its variation selector and the spaces
+// separating it from the text
+var emojiRegex = regexp.MustCompile(`[\x{1F000}-\x{1FFFF}\x{2300}-\x{23FF}\x{2600}-\x{27BF}\x{2B00}-\x{2BFF}]\x{FE0F}?\s*`)
+
+// asciiMarkers replace the emoji that carry meaning in StyleASCII, other
+// emoji are left out
+var asciiMarkers = map[string]string{
+	"✅": "[OK]",
+	"❌": "[FAIL]",
+	"⚠": "[WARN]",
+	"🛑": "[STOP]",
+	"⏭": "[SKIP]",
+	"🎉": "[DONE]",
+	"📥": "[DOWNLOAD]",
+	"⬇": "[DOWNLOAD]",
+	"🗑": "[REMOVE]",
+	"🧹": "[CLEAN]",
+	"📋": "[PLAN]",
+}
+
+// ConfigureStyle picks the console style: ASCII markers when ascii is set,
+// no emoji when noColor is set, NO_COLOR or GHOSPEL_NO_COLOR is in the
+// environment or stderr isn't a terminal, and emoji otherwise. noColor and
+// the environment also turn off colored output, see Color.
+func ConfigureStyle(noColor, ascii bool) {
+	colorDisabled = noColor || os.Getenv("NO_COLOR") != "" || os.Getenv("GHOSPEL_NO_COLOR") != ""
+
+	switch {
+	case ascii:
+		style = StyleASCII
+	case colorDisabled || !term.IsTerminal(int(os.Stderr.Fd())):
+		style = StylePlain
+	default:
+		style = StyleEmoji
+	}
+}
+
+// Color reports whether colored output is allowed. Callers still check
+// that they write to a terminal.
+func Color() bool {
+	return !colorDisabled && style != StyleASCII
+}
+
+// decorate adapts a message to the console style
+func decorate(message string) string {
+	switch style {
+	case StylePlain:
+		return emojiRegex.ReplaceAllString(message, "")
+	case StyleASCII:
+		return emojiRegex.ReplaceAllStringFunc(message, func(emoji string) string {
+			symbol := strings.TrimRight(strings.TrimSpace(emoji), "️")
+			if marker, ok := asciiMarkers[symbol]; ok {
+				return marker + " "
+			}
+
+			return ""
+		})
+	default:
+		return message
+	}
+}
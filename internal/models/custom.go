@@ -0,0 +1,77 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ggmlMagic is the header whisper.cpp writes at the start of ggml model files
+// (0x67676d6c stored little-endian)
+var ggmlMagic = []byte("lmgg")
+
+// Aliases maps friendly model names to the registry models they stand for
+var Aliases = map[string]string{
+	"large": "large-v3",
+	"turbo": "large-v3-turbo",
+}
+
+// Resolve returns the registry name of a model alias and any other model
+// name or path unchanged
+func Resolve(model string) string {
+	if name, ok := Aliases[model]; ok {
+		return name
+	}
+
+	return model
+}
+
+// IsRegistryModel reports whether model is the name of a registry model or
+// an alias of one, rather than a custom model path or an unknown name
+func IsRegistryModel(model string) bool {
+	name := Resolve(model)
+
+	// Only the names are read, a zero Manager lists them without touching disk
+	for _, info := range (&Manager{}).AvailableModels() {
+		if info.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsCustomPath reports whether model refers to a model file on disk rather
+// than a name from the registry
+func IsCustomPath(model string) bool {
+	return filepath.IsAbs(model)
+}
+
+// SupportsDiarization reports whether model is a tinydiarize ("tdrz") model,
+// the only kind whisper.cpp can detect speaker turns with
+func SupportsDiarization(model string) bool {
+	return strings.Contains(filepath.Base(model), "tdrz")
+}
+
+// ValidateModelFile checks that path exists and starts with the ggml header
+func ValidateModelFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("model file not found: %s", path)
+		}
+
+		return fmt.Errorf("failed to open model file: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, len(ggmlMagic))
+	if _, err := io.ReadFull(f, header); err != nil || !bytes.Equal(header, ggmlMagic) {
+		return fmt.Errorf("%s does not look like a ggml whisper model", path)
+	}
+
+	return nil
+}
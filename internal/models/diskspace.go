@@ -0,0 +1,60 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ErrNotEnoughSpace is returned when the models directory lacks the free
+// space for a download
+var ErrNotEnoughSpace = errors.New("not enough disk space")
+
+// spaceMargin is kept free on top of a download: 10% of its size, at least
+// 64 MB
+func spaceMargin(size int64) int64 {
+	return max(size/10, 64<<20)
+}
+
+// checkDiskSpace returns ErrNotEnoughSpace unless the volume holding dir has
+// room for size bytes plus a margin. Where free space can't be determined
+// the download goes ahead.
+func checkDiskSpace(dir, what string, size int64) error {
+	free, ok := freeSpace(dir)
+	if !ok || size <= 0 {
+		return nil
+	}
+
+	if need := size + spaceMargin(size); free < need {
+		return fmt.Errorf("%w for %s: it needs %s including a safety margin, but only %s is free in %s",
+			ErrNotEnoughSpace, what, formatBytes(need), formatBytes(free), dir)
+	}
+
+	return nil
+}
+
+// registrySize converts a registry size such as "142 MB" or "2.9 GB" to
+// bytes, 0 if it can't be parsed
+func registrySize(size string) int64 {
+	value, unit, _ := strings.Cut(strings.TrimSpace(size), " ")
+
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+
+	exp := 0
+	if prefix := strings.ToUpper(strings.TrimSuffix(unit, "B")); prefix != "" {
+		exp = strings.Index("KMGT", prefix) + 1
+		if exp == 0 || len(prefix) > 1 {
+			return 0
+		}
+	}
+
+	for range exp {
+		n *= 1024
+	}
+
+	return int64(n)
+}
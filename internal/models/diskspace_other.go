@@ -0,0 +1,10 @@
+//go:build !(linux || darwin || freebsd)
+
+package models
+
+// Free space is only checked on Linux, macOS and FreeBSD, elsewhere
+// downloads start without the check
+
+func freeSpace(dir string) (int64, bool) {
+	return 0, false
+}
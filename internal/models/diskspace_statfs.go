@@ -0,0 +1,16 @@
+//go:build linux || darwin || freebsd
+
+package models
+
+import "syscall"
+
+// freeSpace returns the bytes available to unprivileged users on the volume
+// holding dir
+func freeSpace(dir string) (int64, bool) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(dir, &stat); err != nil {
+		return 0, false
+	}
+
+	return int64(uint64(stat.Bavail) * uint64(stat.Bsize)), true
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+)
+
+// acquireDownloadLock takes the per-model lock guarding downloads to path, so
+// concurrent ghospel processes don't write the same model file. The lock is
+// held by the OS on an open file and released automatically if the process
+// dies, so a leftover .lock sidecar never blocks later runs.
+func acquireDownloadLock(path string) (func(), error) {
+	f, err := os.OpenFile(path+".lock", os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	locked, err := tryLockFile(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+	}
+
+	if !locked {
+		slog.Info(fmt.Sprintf("⏳ Another ghospel process is downloading %s, waiting for it to finish...", filepath.Base(path)),
+			"path", path)
+
+		if err := lockFile(f); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to lock %s: %w", f.Name(), err)
+		}
+	}
+
+	return func() {
+		unlockFile(f)
+		f.Close()
+	}, nil
+}
@@ -0,0 +1,19 @@
+//go:build !unix
+
+package models
+
+import "os"
+
+// File locking is only implemented on Unix, elsewhere downloads are unguarded
+
+func tryLockFile(f *os.File) (bool, error) {
+	return true, nil
+}
+
+func lockFile(f *os.File) error {
+	return nil
+}
+
+func unlockFile(f *os.File) error {
+	return nil
+}
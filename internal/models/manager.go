@@ -1,24 +1,39 @@
 package models
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/pascalwhoop/ghospel/internal/logging"
 	"github.com/schollz/progressbar/v3"
 )
 
+// DefaultBaseURL is where models are downloaded from unless a mirror is configured
+const DefaultBaseURL = "https://huggingface.co/ggerganov/whisper.cpp/resolve/main"
+
+// BaseURLEnvVar names the environment variable overriding the download base URL
+const BaseURLEnvVar = "GHOSPEL_MODEL_BASE_URL"
+
 // Manager handles Whisper model operations
 type Manager struct {
 	cacheDir string
+	baseURL  string
 }
 
 // ModelInfo represents information about a Whisper model
 type ModelInfo struct {
 	Name        string
+	Group       string // Family the model is listed under, e.g. "English only"
 	Size        string
 	Downloaded  bool
 	Path        string
@@ -36,16 +51,48 @@ func NewManager(cacheDir string) *Manager {
 	// Ensure cache directory exists
 	os.MkdirAll(cacheDir, 0o755)
 
-	return &Manager{cacheDir: cacheDir}
+	return &Manager{cacheDir: cacheDir, baseURL: ResolveBaseURL("")}
+}
+
+// SetBaseURL points downloads at a mirror serving the ggml-*.bin files.
+// GHOSPEL_MODEL_BASE_URL still takes precedence when set.
+func (m *Manager) SetBaseURL(baseURL string) {
+	m.baseURL = ResolveBaseURL(baseURL)
+}
+
+// ResolveBaseURL picks the download base URL: the environment variable wins
+// over the configured value, which wins over Hugging Face
+func ResolveBaseURL(configured string) string {
+	baseURL := DefaultBaseURL
+	if env := os.Getenv(BaseURLEnvVar); env != "" {
+		baseURL = env
+	} else if configured != "" {
+		baseURL = configured
+	}
+
+	return strings.TrimRight(baseURL, "/")
 }
 
 // AvailableModels returns all available Whisper models with their download URLs
 func (m *Manager) AvailableModels() []ModelInfo {
-	baseURL := "https://huggingface.co/ggerganov/whisper.cpp/resolve/main"
+	baseURL := m.baseURL
+
+	// The tinydiarize model is published separately, mirrors host it alongside the others
+	tinyDiarizeURL := baseURL
+	if baseURL == DefaultBaseURL {
+		tinyDiarizeURL = "https://huggingface.co/akashmjn/tinydiarize-whisper.cpp/resolve/main"
+	}
+
+	// So are the distil-whisper models
+	distilURL := baseURL
+	if baseURL == DefaultBaseURL {
+		distilURL = "https://huggingface.co/distil-whisper/distil-large-v3-ggml/resolve/main"
+	}
 
 	return []ModelInfo{
 		{
 			Name:        "tiny",
+			Group:       "Multilingual",
 			Size:        "39 MB",
 			Description: "Fastest, least accurate",
 			Path:        filepath.Join(m.cacheDir, "ggml-tiny.bin"),
@@ -53,6 +100,7 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "tiny.en",
+			Group:       "English only",
 			Size:        "39 MB",
 			Description: "Fastest, least accurate (English only)",
 			Path:        filepath.Join(m.cacheDir, "ggml-tiny.en.bin"),
@@ -60,6 +108,7 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "base",
+			Group:       "Multilingual",
 			Size:        "142 MB",
 			Description: "Good balance of speed and accuracy",
 			Path:        filepath.Join(m.cacheDir, "ggml-base.bin"),
@@ -67,6 +116,7 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "base.en",
+			Group:       "English only",
 			Size:        "142 MB",
 			Description: "Good balance of speed and accuracy (English only)",
 			Path:        filepath.Join(m.cacheDir, "ggml-base.en.bin"),
@@ -74,6 +124,7 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "small",
+			Group:       "Multilingual",
 			Size:        "488 MB",
 			Description: "Better accuracy, moderate speed",
 			Path:        filepath.Join(m.cacheDir, "ggml-small.bin"),
@@ -81,6 +132,7 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "small.en",
+			Group:       "English only",
 			Size:        "488 MB",
 			Description: "Better accuracy, moderate speed (English only)",
 			Path:        filepath.Join(m.cacheDir, "ggml-small.en.bin"),
@@ -88,6 +140,7 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "medium",
+			Group:       "Multilingual",
 			Size:        "1.5 GB",
 			Description: "High accuracy, slower",
 			Path:        filepath.Join(m.cacheDir, "ggml-medium.bin"),
@@ -95,13 +148,31 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "medium.en",
+			Group:       "English only",
 			Size:        "1.5 GB",
 			Description: "High accuracy, slower (English only)",
 			Path:        filepath.Join(m.cacheDir, "ggml-medium.en.bin"),
 			DownloadURL: fmt.Sprintf("%s/ggml-medium.en.bin", baseURL),
 		},
+		{
+			Name:        "small.en-tdrz",
+			Group:       "Speaker detection",
+			Size:        "465 MB",
+			Description: "Small with speaker-turn detection for --diarize (English only)",
+			Path:        filepath.Join(m.cacheDir, "ggml-small.en-tdrz.bin"),
+			DownloadURL: fmt.Sprintf("%s/ggml-small.en-tdrz.bin", tinyDiarizeURL),
+		},
+		{
+			Name:        "large-v2",
+			Group:       "Multilingual",
+			Size:        "2.9 GB",
+			Description: "Previous large model, still preferred for some languages",
+			Path:        filepath.Join(m.cacheDir, "ggml-large-v2.bin"),
+			DownloadURL: fmt.Sprintf("%s/ggml-large-v2.bin", baseURL),
+		},
 		{
 			Name:        "large-v3",
+			Group:       "Multilingual",
 			Size:        "2.9 GB",
 			Description: "Latest large model with improvements",
 			Path:        filepath.Join(m.cacheDir, "ggml-large-v3.bin"),
@@ -109,11 +180,20 @@ func (m *Manager) AvailableModels() []ModelInfo {
 		},
 		{
 			Name:        "large-v3-turbo",
+			Group:       "Multilingual",
 			Size:        "1.5 GB",
 			Description: "Large v3 Turbo - faster with similar accuracy",
 			Path:        filepath.Join(m.cacheDir, "ggml-large-v3-turbo.bin"),
 			DownloadURL: fmt.Sprintf("%s/ggml-large-v3-turbo.bin", baseURL),
 		},
+		{
+			Name:        "distil-large-v3",
+			Group:       "Distilled",
+			Size:        "1.5 GB",
+			Description: "Distilled large-v3, several times faster with close accuracy (English only)",
+			Path:        filepath.Join(m.cacheDir, "ggml-distil-large-v3.bin"),
+			DownloadURL: fmt.Sprintf("%s/ggml-distil-large-v3.bin", distilURL),
+		},
 	}
 }
 
@@ -121,10 +201,26 @@ func (m *Manager) AvailableModels() []ModelInfo {
 func (m *Manager) List() error {
 	models := m.AvailableModels()
 
+	// Group models by family, in the order the families first appear
+	groups := []string{}
+	for _, model := range models {
+		if !slices.Contains(groups, model.Group) {
+			groups = append(groups, model.Group)
+		}
+	}
+
+	slices.SortStableFunc(models, func(a, b ModelInfo) int {
+		return slices.Index(groups, a.Group) - slices.Index(groups, b.Group)
+	})
+
 	fmt.Println("Available Whisper Models:")
 	fmt.Println("=========================")
 
-	for _, model := range models {
+	for i, model := range models {
+		if i == 0 || model.Group != models[i-1].Group {
+			fmt.Printf("\n%s:\n", model.Group)
+		}
+
 		downloaded := ""
 		if _, err := os.Stat(model.Path); err == nil {
 			downloaded = "✅ Downloaded"
@@ -132,7 +228,7 @@ func (m *Manager) List() error {
 			downloaded = "⬇️  Not downloaded"
 		}
 
-		fmt.Printf("%-12s | %-12s | %s | %s\n",
+		fmt.Printf("%-16s | %-8s | %s | %s\n",
 			model.Name, model.Size, downloaded, model.Description)
 	}
 
@@ -141,41 +237,140 @@ func (m *Manager) List() error {
 	return nil
 }
 
+// MarkUsed records that a downloaded model is in use by updating its
+// modification time, which size-based cache eviction reads as last use.
+// Model files never change once downloaded, so nothing else relies on it.
+func (m *Manager) MarkUsed(modelName string) {
+	model := m.findModel(modelName)
+	if model == nil {
+		return
+	}
+
+	now := time.Now()
+	_ = os.Chtimes(model.Path, now, now)
+}
+
 // Download downloads a specific model
 func (m *Manager) Download(modelName string) error {
-	// Validate model name
-	models := m.AvailableModels()
+	_, err := m.download(modelName, "")
+	return err
+}
+
+// DownloadMany downloads the given models one after another, skipping those
+// already present, and reports how much was fetched. All names, and whether
+// the missing models fit on disk, are checked before anything is downloaded.
+func (m *Manager) DownloadMany(modelNames []string) error {
+	var missingSize int64
+
+	for _, name := range modelNames {
+		model := m.findModel(name)
+		if model == nil {
+			return fmt.Errorf("unknown model: %s", name)
+		}
+
+		if _, err := os.Stat(model.Path); err != nil {
+			missingSize += registrySize(model.Size)
+		}
+	}
+
+	// Check the whole batch fits before fetching the first model
+	if err := checkDiskSpace(m.cacheDir, fmt.Sprintf("%d models", len(modelNames)), missingSize); err != nil {
+		return err
+	}
 
-	var targetModel *ModelInfo
+	var totalBytes int64
+
+	downloaded := 0
+
+	for i, name := range modelNames {
+		n, err := m.download(name, fmt.Sprintf("[%d/%d] ", i+1, len(modelNames)))
+		if err != nil {
+			return fmt.Errorf("failed to download %s: %w", name, err)
+		}
 
+		if n > 0 {
+			downloaded++
+			totalBytes += n
+		}
+	}
+
+	logging.Blank()
+	slog.Info(fmt.Sprintf("📦 Downloaded %d model(s) (%s), %d already present",
+		downloaded, formatBytes(totalBytes), len(modelNames)-downloaded),
+		"downloaded", downloaded, "bytes", totalBytes, "present", len(modelNames)-downloaded)
+
+	return nil
+}
+
+// findModel looks up a registry model by name
+func (m *Manager) findModel(modelName string) *ModelInfo {
+	modelName = Resolve(modelName)
+
+	models := m.AvailableModels()
 	for i, model := range models {
 		if model.Name == modelName {
-			targetModel = &models[i]
-			break
+			return &models[i]
 		}
 	}
 
+	return nil
+}
+
+// download fetches a model and returns the number of bytes written, which
+// is 0 when the model was already downloaded. Status messages start with
+// prefix, e.g. the position in a batch.
+func (m *Manager) download(modelName, prefix string) (int64, error) {
+	// Validate model name
+	targetModel := m.findModel(modelName)
 	if targetModel == nil {
-		return fmt.Errorf("unknown model: %s", modelName)
+		return 0, fmt.Errorf("unknown model: %s", modelName)
 	}
 
 	// Check if already downloaded
 	if _, err := os.Stat(targetModel.Path); err == nil {
-		fmt.Printf("✅ Model %s is already downloaded\n", modelName)
-		return nil
+		slog.Info(fmt.Sprintf("%s✅ Model %s is already downloaded", prefix, modelName), "model", modelName)
+		return 0, nil
+	}
+
+	// Serialize downloads of the same model across processes
+	release, err := acquireDownloadLock(targetModel.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	// Another process may have finished the download while we waited
+	if _, err := os.Stat(targetModel.Path); err == nil {
+		slog.Info(fmt.Sprintf("%s✅ Model %s was downloaded by another process", prefix, modelName), "model", modelName)
+		return 0, nil
 	}
 
-	fmt.Printf("📥 Downloading %s model (%s) from Hugging Face...\n", modelName, targetModel.Size)
+	return m.fetch(targetModel, prefix)
+}
+
+// fetch downloads a registry model, replacing the local file only once the
+// download is complete, and records what was downloaded (see
+// downloadRecord). The caller holds the model's download lock.
+func (m *Manager) fetch(targetModel *ModelInfo, prefix string) (int64, error) {
+	modelName := targetModel.Name
+
+	source := "Hugging Face"
+	if m.baseURL != DefaultBaseURL {
+		source = m.baseURL
+	}
+
+	slog.Info(fmt.Sprintf("%s📥 Downloading %s model (%s) from %s...", prefix, modelName, targetModel.Size, source),
+		"model", modelName, "url", targetModel.DownloadURL)
 
 	// Create HTTP request
 	resp, err := http.Get(targetModel.DownloadURL)
 	if err != nil {
-		return fmt.Errorf("failed to start download: %w", err)
+		return 0, fmt.Errorf("failed to start download: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("download failed with status: %s", resp.Status)
+		return 0, fmt.Errorf("download failed with status: %s", resp.Status)
 	}
 
 	// Get content length for progress bar
@@ -189,81 +384,136 @@ func (m *Manager) Download(modelName string) error {
 		}
 	}
 
-	// Create output file
-	out, err := os.Create(targetModel.Path)
+	// Refuse up front rather than failing with a write error halfway
+	size := contentLength
+	if size <= 0 {
+		size = registrySize(targetModel.Size)
+	}
+
+	if err := checkDiskSpace(m.cacheDir, modelName, size); err != nil {
+		return 0, err
+	}
+
+	// Download to a .part file that is renamed once complete, so an
+	// interrupted download never leaves a truncated model behind
+	partPath := targetModel.Path + ".part"
+
+	out, err := os.Create(partPath)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return 0, fmt.Errorf("failed to create output file: %w", err)
 	}
 	defer out.Close()
 
-	// Create progress bar
-	var progressReader io.Reader = resp.Body
-
-	if contentLength > 0 {
-		bar := progressbar.NewOptions64(
-			contentLength,
-			progressbar.OptionSetDescription(fmt.Sprintf("Downloading %s", modelName)),
-			progressbar.OptionSetWriter(os.Stderr),
-			progressbar.OptionShowBytes(true),
-			progressbar.OptionSetWidth(40),
-			progressbar.OptionThrottle(65*1000000), // 65ms
-			progressbar.OptionShowCount(),
-			progressbar.OptionOnCompletion(func() {
-				fmt.Fprint(os.Stderr, "\n")
-			}),
-			progressbar.OptionSpinnerType(14),
-			progressbar.OptionFullWidth(),
-			progressbar.OptionSetRenderBlankState(true),
-		)
-		reader := progressbar.NewReader(resp.Body, bar)
-		progressReader = &reader
-	}
-
-	// Copy data with progress
-	_, err = io.Copy(out, progressReader)
+	// Create progress bar showing transfer rate and ETA. Without a known
+	// length (-1) it falls back to a spinner that still shows the rate.
+	if contentLength <= 0 {
+		contentLength = -1
+	}
+
+	writer := io.Writer(os.Stderr)
+	if logging.Structured() {
+		// Keep the structured log on stderr parseable
+		writer = io.Discard
+	}
+
+	bar := progressbar.NewOptions64(
+		contentLength,
+		progressbar.OptionSetDescription(fmt.Sprintf("Downloading %s", modelName)),
+		progressbar.OptionSetWriter(writer),
+		progressbar.OptionShowBytes(true),
+		progressbar.OptionShowIts(),
+		progressbar.OptionSetPredictTime(true),
+		progressbar.OptionShowElapsedTimeOnFinish(),
+		progressbar.OptionSetWidth(40),
+		progressbar.OptionThrottle(65*1000000), // 65ms
+		progressbar.OptionShowCount(),
+		progressbar.OptionOnCompletion(func() {
+			fmt.Fprint(writer, "\n")
+		}),
+		progressbar.OptionSpinnerType(14),
+		progressbar.OptionFullWidth(),
+		progressbar.OptionSetRenderBlankState(true),
+	)
+	reader := progressbar.NewReader(resp.Body, bar)
+	var progressReader io.Reader = &reader
+
+	// Copy data with progress, hashing it on the way for models update
+	hash := sha256.New()
+	written, err := io.Copy(io.MultiWriter(out, hash), progressReader)
+	if err == nil {
+		err = out.Close()
+	}
 	if err != nil {
 		// Clean up partial download
-		os.Remove(targetModel.Path)
-		return fmt.Errorf("download failed: %w", err)
+		os.Remove(partPath)
+		return 0, fmt.Errorf("download failed: %w", err)
 	}
 
-	fmt.Printf("✅ Successfully downloaded %s model\n", modelName)
+	// A connection dropped right at the end can look like a clean EOF
+	if contentLength > 0 && written != contentLength {
+		os.Remove(partPath)
+		return 0, fmt.Errorf("download of %s incomplete: got %d of %d bytes, please retry", modelName, written, contentLength)
+	}
 
-	return nil
+	if err := os.Rename(partPath, targetModel.Path); err != nil {
+		os.Remove(partPath)
+		return 0, fmt.Errorf("failed to move downloaded model into place: %w", err)
+	}
+
+	record := downloadRecord{
+		SHA256:       hex.EncodeToString(hash.Sum(nil)),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Size:         written,
+		DownloadedAt: time.Now().UTC(),
+	}
+	if err := writeDownloadRecord(targetModel.Path, record); err != nil {
+		slog.Warn(fmt.Sprintf("⚠️  %v", err), "model", modelName, "error", err)
+	}
+
+	slog.Info(fmt.Sprintf("✅ Successfully downloaded %s model", modelName), "model", modelName, "bytes", written)
+
+	return written, nil
+}
+
+// formatBytes formats byte count as human readable string
+func formatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
 }
 
 // Cleanup removes unused cached models
 func (m *Manager) Cleanup() error {
-	fmt.Println("🧹 Cleaning up unused models...")
+	slog.Info("🧹 Cleaning up unused models...")
 
 	// TODO: Implement cleanup logic
 	// - Check last access times
 	// - Remove models not used in X days
 	// - Keep at least one model
 
-	fmt.Println("✅ Cache cleanup complete")
+	slog.Info("✅ Cache cleanup complete")
 
 	return nil
 }
 
 // Info shows information about a specific model
 func (m *Manager) Info(modelName string) error {
-	models := m.AvailableModels()
-
-	var targetModel *ModelInfo
-
-	for i, model := range models {
-		if model.Name == modelName {
-			targetModel = &models[i]
-			break
-		}
-	}
-
+	targetModel := m.findModel(modelName)
 	if targetModel == nil {
 		return fmt.Errorf("unknown model: %s", modelName)
 	}
 
-	fmt.Printf("Model Information: %s\n", modelName)
+	fmt.Printf("Model Information: %s\n", targetModel.Name)
 	fmt.Println("===================")
 	fmt.Printf("Size: %s\n", targetModel.Size)
 	fmt.Printf("Description: %s\n", targetModel.Description)
@@ -0,0 +1,21 @@
+package models
+
+// MemoryNeeded estimates how much memory whisper uses to run a model whose
+// file has the given size: the weights plus about a third for the
+// computation buffers and a fixed overhead. This matches the figures
+// published by whisper.cpp, e.g. about 390 MB for base and 4 GB for large.
+func MemoryNeeded(modelSize int64) int64 {
+	return modelSize + modelSize/3 + 200<<20
+}
+
+// MemoryBudget is how much memory concurrent whisper runs may take in
+// total: half of the physical memory, leaving the rest to the system and
+// other programs. ok is false where the memory can't be determined.
+func MemoryBudget() (budget int64, ok bool) {
+	total, ok := totalMemory()
+	if !ok {
+		return 0, false
+	}
+
+	return total / 2, true
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"encoding/binary"
+	"syscall"
+)
+
+// totalMemory returns the physical memory of the machine in bytes
+func totalMemory() (int64, bool) {
+	// hw.memsize is a 64-bit integer, returned as its raw bytes with the
+	// trailing zero byte cut off
+	value, err := syscall.Sysctl("hw.memsize")
+	if err != nil {
+		return 0, false
+	}
+
+	data := make([]byte, 8)
+	copy(data, value)
+
+	return int64(binary.LittleEndian.Uint64(data)), true
+}
@@ -0,0 +1,13 @@
+package models
+
+import "syscall"
+
+// totalMemory returns the physical memory of the machine in bytes
+func totalMemory() (int64, bool) {
+	var info syscall.Sysinfo_t
+	if err := syscall.Sysinfo(&info); err != nil {
+		return 0, false
+	}
+
+	return int64(uint64(info.Totalram) * uint64(info.Unit)), true
+}
@@ -0,0 +1,10 @@
+//go:build !(linux || darwin)
+
+package models
+
+// Physical memory is only read on Linux and macOS, elsewhere models run
+// without a memory budget
+
+func totalMemory() (int64, bool) {
+	return 0, false
+}
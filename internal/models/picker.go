@@ -0,0 +1,79 @@
+package models
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ErrNoModelPicked is returned when the user leaves the model picker without
+// choosing a model
+var ErrNoModelPicked = errors.New("no model chosen")
+
+// Pick asks the user to choose a model from the registry, showing sizes,
+// descriptions and which models are already downloaded. The prompt is
+// written to w and the answer read from r. requested is the model that was
+// asked for and is the default when it is a registry model.
+func (m *Manager) Pick(r io.Reader, w io.Writer, requested string) (string, error) {
+	models := m.AvailableModels()
+	requested = Resolve(requested)
+
+	defaultIndex := -1
+	if requested != "" {
+		fmt.Fprintf(w, "Model %s is not available. Choose a model to use:\n", requested)
+	} else {
+		fmt.Fprintln(w, "Choose a model to use:")
+	}
+
+	for i, model := range models {
+		status := "  "
+		if _, err := os.Stat(model.Path); err == nil {
+			status = "✅"
+		}
+
+		if model.Name == requested {
+			defaultIndex = i
+		}
+
+		fmt.Fprintf(w, "  %2d) %s %-16s %-8s %s\n", i+1, status, model.Name, model.Size, model.Description)
+	}
+
+	input := bufio.NewReader(r)
+
+	for {
+		if defaultIndex >= 0 {
+			fmt.Fprintf(w, "Model [1-%d, Enter for %s]: ", len(models), requested)
+		} else {
+			fmt.Fprintf(w, "Model [1-%d]: ", len(models))
+		}
+
+		answer, err := input.ReadString('\n')
+		answer = strings.TrimSpace(answer)
+
+		if answer == "" && defaultIndex >= 0 && err == nil {
+			return models[defaultIndex].Name, nil
+		}
+
+		if n, convErr := strconv.Atoi(answer); convErr == nil && n >= 1 && n <= len(models) {
+			return models[n-1].Name, nil
+		}
+
+		// A model can also be picked by name
+		for _, model := range models {
+			if answer != "" && model.Name == Resolve(answer) {
+				return model.Name, nil
+			}
+		}
+
+		if err != nil {
+			fmt.Fprintln(w)
+			return "", ErrNoModelPicked
+		}
+
+		fmt.Fprintf(w, "Please enter a number between 1 and %d\n", len(models))
+	}
+}
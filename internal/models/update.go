@@ -0,0 +1,326 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/internal/logging"
+)
+
+// downloadRecord is stored next to a downloaded model as <model>.json. It
+// holds the model's checksum and the validators the server sent with it, so
+// models update can tell whether the published file changed.
+type downloadRecord struct {
+	SHA256       string    `json:"sha256"`
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	Size         int64     `json:"size"`
+	DownloadedAt time.Time `json:"downloaded_at"`
+}
+
+// recordPath is where the download record of the model at path is kept
+func recordPath(path string) string {
+	return path + ".json"
+}
+
+// readDownloadRecord reads the download record of the model at path. Models
+// downloaded by older versions have none.
+func readDownloadRecord(path string) (*downloadRecord, error) {
+	data, err := os.ReadFile(recordPath(path))
+	if err != nil {
+		return nil, err
+	}
+
+	var record downloadRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("invalid download record %s: %w", recordPath(path), err)
+	}
+
+	return &record, nil
+}
+
+// writeDownloadRecord stores the download record of the model at path
+func writeDownloadRecord(path string, record downloadRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(recordPath(path), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write download record: %w", err)
+	}
+
+	return nil
+}
+
+// remoteModel is what the server tells about a published model file
+type remoteModel struct {
+	SHA256       string // Content checksum, when the server publishes one
+	ETag         string
+	LastModified string
+	Size         int64
+}
+
+// sha256Regex matches a hex-encoded SHA-256 checksum
+var sha256Regex = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// etagChecksum returns the SHA-256 an ETag holds, as Hugging Face sends for
+// large files, or "" for other ETags
+func etagChecksum(etag string) string {
+	etag = strings.ToLower(strings.Trim(strings.TrimPrefix(etag, "W/"), `"`))
+	if sha256Regex.MatchString(etag) {
+		return etag
+	}
+
+	return ""
+}
+
+// maxRedirects bounds the redirects followed when checking a model
+const maxRedirects = 10
+
+// headModel asks the server about the model at url without downloading it.
+// Redirects are followed one by one because Hugging Face only sends the
+// checksum (X-Linked-Etag) with the redirect to its storage.
+func headModel(url string) (*remoteModel, error) {
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	remote := &remoteModel{}
+
+	for range maxRedirects {
+		resp, err := client.Head(url)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check %s: %w", url, err)
+		}
+		resp.Body.Close()
+
+		if remote.SHA256 == "" {
+			remote.SHA256 = etagChecksum(resp.Header.Get("X-Linked-Etag"))
+		}
+		if remote.Size == 0 {
+			remote.Size, _ = strconv.ParseInt(resp.Header.Get("X-Linked-Size"), 10, 64)
+		}
+
+		if resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			location, err := resp.Location()
+			if err != nil {
+				return nil, fmt.Errorf("failed to check %s: %w", url, err)
+			}
+
+			url = location.String()
+
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to check %s: %s", url, resp.Status)
+		}
+
+		remote.ETag = resp.Header.Get("ETag")
+		remote.LastModified = resp.Header.Get("Last-Modified")
+		if remote.Size == 0 && resp.ContentLength > 0 {
+			remote.Size = resp.ContentLength
+		}
+		if remote.SHA256 == "" {
+			remote.SHA256 = etagChecksum(remote.ETag)
+		}
+
+		return remote, nil
+	}
+
+	return nil, fmt.Errorf("failed to check %s: too many redirects", url)
+}
+
+// fileChecksum computes the SHA-256 of the file at path
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// changed compares the local model with what the server publishes. It
+// returns why the model needs downloading again, or "" if it's current. The
+// checksum is the most reliable signal; without one the validators recorded
+// at download time are compared, and the size as a last resort. Models
+// without a download record are hashed once, which takes a while for large
+// ones, and get a record so later checks are quick.
+func (m *Manager) changed(model *ModelInfo, remote *remoteModel) (string, error) {
+	stat, err := os.Stat(model.Path)
+	if err != nil {
+		return "", err
+	}
+
+	record, err := readDownloadRecord(model.Path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Debug(fmt.Sprintf("⚠️  %v", err), "model", model.Name, "error", err)
+	}
+
+	// Records describe the file as downloaded, not a file replaced since
+	if record != nil && record.Size != stat.Size() {
+		record = nil
+	}
+
+	switch {
+	case remote.SHA256 != "":
+		local := ""
+		if record != nil {
+			local = record.SHA256
+		}
+
+		if local == "" {
+			slog.Info(fmt.Sprintf("🔍 Computing the checksum of %s (%s)...", model.Name, formatBytes(stat.Size())),
+				"model", model.Name, "path", model.Path)
+
+			if local, err = fileChecksum(model.Path); err != nil {
+				return "", err
+			}
+
+			if local == remote.SHA256 {
+				record := downloadRecord{
+					SHA256:       local,
+					ETag:         remote.ETag,
+					LastModified: remote.LastModified,
+					Size:         stat.Size(),
+					DownloadedAt: stat.ModTime().UTC(),
+				}
+				if err := writeDownloadRecord(model.Path, record); err != nil {
+					slog.Debug(fmt.Sprintf("⚠️  %v", err), "model", model.Name, "error", err)
+				}
+			}
+		}
+
+		if local != remote.SHA256 {
+			return "checksum changed", nil
+		}
+	case record != nil && record.ETag != "" && remote.ETag != "":
+		if record.ETag != remote.ETag {
+			return "ETag changed", nil
+		}
+	case record != nil && record.LastModified != "" && remote.LastModified != "":
+		if record.LastModified != remote.LastModified {
+			return fmt.Sprintf("published %s", remote.LastModified), nil
+		}
+	case remote.Size > 0:
+		if remote.Size != stat.Size() {
+			return "size changed", nil
+		}
+	default:
+		return "", fmt.Errorf("the server sends no checksum, ETag, Last-Modified or size for %s", model.Name)
+	}
+
+	return "", nil
+}
+
+// Update checks downloaded models against the published files and
+// downloads those that changed again. Current models are left alone, so
+// multi-GB files are only fetched when needed. Models that aren't
+// downloaded are skipped.
+func (m *Manager) Update(modelNames []string) error {
+	targets := make([]*ModelInfo, 0, len(modelNames))
+	for _, name := range modelNames {
+		model := m.findModel(name)
+		if model == nil {
+			return fmt.Errorf("unknown model: %s", name)
+		}
+
+		targets = append(targets, model)
+	}
+
+	var updated, current, skipped, failed int
+
+	for i, model := range targets {
+		prefix := ""
+		if len(targets) > 1 {
+			prefix = fmt.Sprintf("[%d/%d] ", i+1, len(targets))
+		}
+
+		if _, err := os.Stat(model.Path); err != nil {
+			slog.Info(fmt.Sprintf("%s⬇️  %s is not downloaded, use ghospel models download %s", prefix, model.Name,
+				model.Name), "model", model.Name)
+			skipped++
+
+			continue
+		}
+
+		didUpdate, err := m.update(model, prefix)
+		switch {
+		case err != nil:
+			slog.Error(fmt.Sprintf("%s❌ %s: %v", prefix, model.Name, err), "model", model.Name, "error", err)
+			failed++
+		case didUpdate:
+			updated++
+		default:
+			current++
+		}
+	}
+
+	if len(targets) > 1 {
+		logging.Blank()
+		slog.Info(fmt.Sprintf("📦 Updated %d model(s), %d up to date, %d not downloaded", updated, current, skipped),
+			"updated", updated, "current", current, "skipped", skipped, "failed", failed)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("update failed for %d of %d model(s)", failed, len(targets))
+	}
+
+	return nil
+}
+
+// update downloads one model again if it changed and reports whether it did
+func (m *Manager) update(model *ModelInfo, prefix string) (bool, error) {
+	remote, err := headModel(model.DownloadURL)
+	if err != nil {
+		return false, err
+	}
+
+	// Serialize with downloads of the same model by other processes
+	release, err := acquireDownloadLock(model.Path)
+	if err != nil {
+		return false, err
+	}
+	defer release()
+
+	reason, err := m.changed(model, remote)
+	if err != nil {
+		return false, err
+	}
+
+	if reason == "" {
+		slog.Info(fmt.Sprintf("%s✅ %s is up to date", prefix, model.Name), "model", model.Name)
+		return false, nil
+	}
+
+	slog.Info(fmt.Sprintf("%s🔄 %s changed upstream (%s)", prefix, model.Name, reason),
+		"model", model.Name, "reason", reason)
+
+	if _, err := m.fetch(model, prefix); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
@@ -0,0 +1,250 @@
+// Package server exposes transcription over HTTP so other programs can POST
+// audio to a long-running ghospel process.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/internal/models"
+	"github.com/pascalwhoop/ghospel/internal/transcription"
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// uploadField is the multipart form field carrying the audio file
+const uploadField = "file"
+
+// Options configures the server. The transcription options act as defaults
+// that requests can override with query parameters.
+type Options struct {
+	Addr          string
+	MaxConcurrent int   // Transcriptions running at once, further requests wait
+	MaxUploadSize int64 // Largest accepted upload in bytes
+	TempDir       string
+
+	Transcription transcription.Options
+}
+
+// Server transcribes uploaded audio files
+type Server struct {
+	opts        Options
+	transcriber *transcribe.Transcriber
+
+	// slots bounds the number of concurrent transcriptions
+	slots chan struct{}
+}
+
+// New creates a new server
+func New(opts Options, transcriber *transcribe.Transcriber) *Server {
+	if opts.MaxConcurrent < 1 {
+		opts.MaxConcurrent = 1
+	}
+
+	if opts.TempDir == "" {
+		opts.TempDir = os.TempDir()
+	}
+
+	return &Server{
+		opts:        opts,
+		transcriber: transcriber,
+		slots:       make(chan struct{}, opts.MaxConcurrent),
+	}
+}
+
+// Handler returns the HTTP routes of the server
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /health", s.handleHealth)
+	mux.HandleFunc("POST /transcribe", s.handleTranscribe)
+
+	return mux
+}
+
+// ListenAndServe serves requests until ctx is cancelled, then waits for
+// running transcriptions to finish
+func (s *Server) ListenAndServe(ctx context.Context) error {
+	srv := &http.Server{
+		Addr:              s.opts.Addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- srv.ListenAndServe()
+	}()
+
+	slog.Info(fmt.Sprintf("🌐 Listening on %s (POST /transcribe)", s.opts.Addr),
+		"addr", s.opts.Addr, "max_concurrent", s.opts.MaxConcurrent)
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("server failed: %w", err)
+	case <-ctx.Done():
+	}
+
+	slog.Info("👋 Shutting down, waiting for running transcriptions...")
+
+	if err := srv.Shutdown(context.Background()); err != nil {
+		return fmt.Errorf("shutdown failed: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleTranscribe accepts a multipart upload in the "file" field and
+// responds with the transcript in the requested format (json by default).
+// The model, language, prompt and format query parameters override the
+// server's defaults.
+func (s *Server) handleTranscribe(w http.ResponseWriter, r *http.Request) {
+	opts := s.opts.Transcription
+	opts.Format = "json"
+
+	query := r.URL.Query()
+	// Requests pick registry models by name, never a file on the server
+	if model := query.Get("model"); model != "" {
+		if !models.IsRegistryModel(model) {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid model: %s (expected a model name, see ghospel models list)", model))
+			return
+		}
+
+		opts.Model = models.Resolve(model)
+	}
+
+	if language := query.Get("language"); language != "" {
+		opts.Language = language
+	}
+
+	if prompt := query.Get("prompt"); prompt != "" {
+		opts.Prompt = prompt
+	}
+
+	if format := query.Get("format"); format != "" {
+		opts.Format = strings.ToLower(format)
+	}
+
+	if !slices.Contains(transcription.ValidFormats, opts.Format) {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid format: %s (valid: %s)",
+			opts.Format, strings.Join(transcription.ValidFormats, ", ")))
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, s.opts.MaxUploadSize)
+
+	path, name, err := s.saveUpload(r)
+	if err != nil {
+		status := http.StatusBadRequest
+
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			status = http.StatusRequestEntityTooLarge
+		}
+
+		writeError(w, status, err)
+		return
+	}
+	defer os.Remove(path)
+
+	// Wait for a free slot, or give up when the client goes away
+	select {
+	case s.slots <- struct{}{}:
+		defer func() { <-s.slots }()
+	case <-r.Context().Done():
+		return
+	}
+
+	slog.Info(fmt.Sprintf("🔄 Transcribing upload %s with %s", name, opts.Model), "file", name, "model", opts.Model)
+
+	result, err := s.transcriber.Transcribe(r.Context(), path, transcribe.Options{
+		Model:       opts.Model,
+		Language:    opts.Language,
+		Prompt:      opts.Prompt,
+		Normalize:   opts.Normalize,
+		Denoise:     opts.Denoise,
+		HighPass:    opts.HighPass,
+		VAD:         opts.VAD,
+		BeamSize:    opts.BeamSize,
+		Temperature: opts.Temperature,
+	})
+	if err != nil {
+		if r.Context().Err() != nil {
+			return
+		}
+
+		status := http.StatusInternalServerError
+		if errors.Is(err, transcribe.ErrModelNotFound) {
+			// Models are only downloaded explicitly
+			status = http.StatusUnprocessableEntity
+			err = fmt.Errorf("%w; download it with: ghospel models download %s", err, opts.Model)
+		}
+
+		slog.Warn(fmt.Sprintf("❌ Failed to transcribe upload %s: %v", name, err), "file", name, "error", err)
+		writeError(w, status, err)
+
+		return
+	}
+
+	content, err := transcription.NewService(opts).Render(result, name)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	slog.Info(fmt.Sprintf("✅ Transcribed upload %s (%s, %s)", name, result.Duration.Round(time.Second), result.Language),
+		"file", name, "duration", result.Duration, "elapsed", result.Elapsed, "language", result.Language)
+
+	w.Header().Set("Content-Type", transcription.ContentType(opts.Format))
+	io.WriteString(w, content)
+}
+
+// saveUpload stores the uploaded audio in a temporary file, keeping its
+// extension so the format can be recognized, and returns its path and the
+// uploaded file name
+func (s *Server) saveUpload(r *http.Request) (string, string, error) {
+	file, header, err := r.FormFile(uploadField)
+	if err != nil {
+		return "", "", fmt.Errorf("expected a multipart upload in the %q field: %w", uploadField, err)
+	}
+	defer file.Close()
+
+	name := filepath.Base(header.Filename)
+
+	out, err := os.CreateTemp(s.opts.TempDir, "ghospel-upload-*"+filepath.Ext(name))
+	if err != nil {
+		return "", "", fmt.Errorf("failed to store upload: %w", err)
+	}
+	defer out.Close()
+
+	if _, err := io.Copy(out, file); err != nil {
+		os.Remove(out.Name())
+		return "", "", fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	return out.Name(), name, nil
+}
+
+// writeJSON writes v as a JSON response
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes an error as a JSON response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
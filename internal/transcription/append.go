@@ -0,0 +1,208 @@
+package transcription
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// AppendFiles transcribes the inputs in order and appends each transcript to
+// AppendPath below a header line naming the source and its date, for a log
+// that grows with every recording. Inputs whose entry is already in the file
+// are skipped unless forced, so the same command can run again as new
+// recordings arrive. A failed file stops the run to keep the entries in
+// input order.
+func (s *Service) AppendFiles(inputs []string) error {
+	slog.Info(fmt.Sprintf("🎵 Ghospel v0.1.0 - Appending transcripts to %s with model: %s", s.opts.AppendPath, s.opts.Model),
+		"model", s.opts.Model, "output", s.opts.AppendPath)
+
+	audioFiles, err := s.findAudioFiles(inputs)
+	if err != nil {
+		return fmt.Errorf("failed to find audio files: %w", err)
+	}
+
+	if len(audioFiles) == 0 {
+		return ErrNoAudioFiles
+	}
+
+	existing, err := os.ReadFile(s.opts.AppendPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("cannot read %s: %w", s.opts.AppendPath, err)
+	}
+
+	// Entries in another encoding would garble the file
+	if len(existing) > 0 && detectEncoding(existing) != s.encoding() {
+		return fmt.Errorf("%s is encoded as %s, not %s; use --encoding %s",
+			s.opts.AppendPath, detectEncoding(existing), s.encoding(), detectEncoding(existing))
+	}
+
+	text := decodeText(existing)
+
+	var filesToProcess, skippedFiles []string
+
+	for _, file := range audioFiles {
+		if s.overwritePolicy() == OverwriteSkip && strings.Contains(text, s.appendHeader(file)+"\n") {
+			skippedFiles = append(skippedFiles, file)
+			continue
+		}
+
+		filesToProcess = append(filesToProcess, file)
+	}
+
+	if s.opts.DryRun {
+		fmt.Println("📋 Dry run - no files will be transcribed")
+		for _, file := range filesToProcess {
+			fmt.Printf("   append      %s\n", file)
+		}
+		for _, file := range skippedFiles {
+			fmt.Printf("   skip        %s (already in %s)\n", file, s.opts.AppendPath)
+		}
+		fmt.Printf("   to          %s\n", s.opts.AppendPath)
+
+		return nil
+	}
+
+	if len(filesToProcess) == 0 {
+		slog.Info(fmt.Sprintf("✅ All %d file(s) are already in %s! Use --force to append them again.",
+			len(audioFiles), s.opts.AppendPath), "files", len(audioFiles), "output", s.opts.AppendPath)
+		return nil
+	}
+
+	if err := s.checkWhisper(); err != nil {
+		return err
+	}
+
+	if err := s.checkFFmpeg(filesToProcess); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.opts.AppendPath), 0o755); err != nil {
+		return fmt.Errorf("cannot create output directory: %w", err)
+	}
+
+	if err := s.ensureModelDownloaded(); err != nil {
+		return fmt.Errorf("model preparation failed: %w", err)
+	}
+
+	ctx := context.Background()
+	if s.opts.BatchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.BatchTimeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	totalWords := 0
+
+	for i, file := range filesToProcess {
+		result, err := s.transcribePart(ctx, file)
+		if err != nil {
+			return &FileError{Path: file, Err: err}
+		}
+
+		entry, err := s.appendEntry(result, file)
+		if err != nil {
+			return &FileError{Path: file, Err: err}
+		}
+
+		if err := s.appendToFile(entry); err != nil {
+			return err
+		}
+
+		if err := s.runHook(file, s.opts.AppendPath); err != nil {
+			return err
+		}
+
+		words := s.countWords(result.Text, result.Language)
+		totalWords += words
+
+		slog.Info(fmt.Sprintf("✅ [%d/%d] %s (%s, %d words)", i+1, len(filesToProcess), filepath.Base(file),
+			result.Duration.Round(time.Second), words),
+			"file", file, "duration", result.Duration, "words", words, "language", result.Language)
+	}
+
+	slog.Info(fmt.Sprintf("🎉 Appended %d transcript(s) to %s (%d words, %d already there, %s)", len(filesToProcess),
+		s.opts.AppendPath, totalWords, len(skippedFiles), time.Since(startTime).Round(time.Second)),
+		"files", len(filesToProcess), "skipped", len(skippedFiles), "output", s.opts.AppendPath, "words", totalWords)
+
+	return nil
+}
+
+// appendHeader is the line starting the entry of an input: its name and the
+// date it was recorded, taken from its modification time. A recording that
+// changes gets a new entry. Remote inputs are dated now.
+func (s *Service) appendHeader(inputPath string) string {
+	name := filepath.Base(inputPath)
+	date := time.Now()
+
+	if IsURL(inputPath) {
+		name = remoteFileName(inputPath)
+	} else if info, err := os.Stat(inputPath); err == nil {
+		date = info.ModTime()
+	}
+
+	label := fmt.Sprintf("%s · %s", name, date.Format("2006-01-02 15:04"))
+	if strings.EqualFold(s.opts.Format, "md") {
+		return "## " + label
+	}
+
+	return "=== " + label + " ==="
+}
+
+// appendEntry renders the entry of an input: its header line and the
+// transcript, without the per-file header of a standalone transcript
+func (s *Service) appendEntry(result *transcribe.Result, inputPath string) (string, error) {
+	result, err := s.postProcess(result)
+	if err != nil {
+		return "", err
+	}
+
+	markerFormat, speakerFormat := "[%s]", "%s:"
+	if strings.EqualFold(s.opts.Format, "md") {
+		markerFormat, speakerFormat = "**[%s]**", "**%s:**"
+	}
+
+	var entry strings.Builder
+
+	entry.WriteString(s.appendHeader(inputPath))
+	entry.WriteString("\n\n")
+	entry.WriteString(wrapText(s.formatParagraphs(result, markerFormat, speakerFormat), s.opts.Wrap))
+	entry.WriteString("\n")
+
+	return entry.String(), nil
+}
+
+// appendToFile adds an entry to the end of AppendPath, separated from the
+// previous one by a blank line
+func (s *Service) appendToFile(entry string) error {
+	file, err := os.OpenFile(s.opts.AppendPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", s.opts.AppendPath, err)
+	}
+
+	data := encodeText(entry, s.encoding())
+
+	// The byte order mark only starts the file
+	if info, err := file.Stat(); err == nil && info.Size() > 0 {
+		data = bytes.TrimPrefix(encodeText("\n"+entry, s.encoding()), byteOrderMark(s.encoding()))
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to append to %s: %w", s.opts.AppendPath, err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to append to %s: %w", s.opts.AppendPath, err)
+	}
+
+	return nil
+}
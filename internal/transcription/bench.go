@@ -0,0 +1,135 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/pascalwhoop/ghospel/internal/diff"
+	"github.com/pascalwhoop/ghospel/internal/models"
+)
+
+// BenchResult is how one model did on the benchmark sample
+type BenchResult struct {
+	Model          string  `json:"model"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	AudioSeconds   float64 `json:"audio_seconds"`
+	RealtimeFactor float64 `json:"realtime_factor"`
+	Words          int     `json:"words"`
+	Language       string  `json:"language,omitempty"`
+
+	// WordErrorRate is measured against the transcript of the reference
+	// model, only set when comparing with one
+	WordErrorRate *float64 `json:"word_error_rate,omitempty"`
+	Reference     bool     `json:"reference,omitempty"`
+
+	Error string `json:"error,omitempty"`
+
+	text      string
+	modelSize int64
+}
+
+// Bench transcribes a sample with each of the models one after another and
+// measures how long each took. With reference, the transcripts are compared
+// to the one of the largest model, taken as the most accurate, by word error
+// rate. A model that fails is reported with its error, the others still run.
+func (s *Service) Bench(input string, modelNames []string, reference bool) ([]BenchResult, error) {
+	if err := s.checkWhisper(); err != nil {
+		return nil, err
+	}
+
+	if err := s.checkFFmpeg([]string{input}); err != nil {
+		return nil, err
+	}
+
+	results := make([]BenchResult, len(modelNames))
+	for i, name := range modelNames {
+		name = models.Resolve(name)
+
+		size, err := s.prepareModel(name)
+		if err != nil {
+			return nil, err
+		}
+
+		results[i] = BenchResult{Model: name, modelSize: size}
+	}
+
+	samplePath := input
+	if IsURL(input) {
+		localPath, err := s.downloadAudio(context.Background(), input)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(localPath)
+
+		samplePath = localPath
+	}
+
+	failures := 0
+
+	for i := range results {
+		result := &results[i]
+
+		slog.Info(fmt.Sprintf("⏱️  Transcribing %s with model %s (%d/%d)...", filepath.Base(input), result.Model,
+			i+1, len(results)), "file", input, "model", result.Model)
+
+		opts := s.transcribeOptions()
+		opts.Model = result.Model
+
+		transcript, err := s.transcribeWithRetry(context.Background(), samplePath, opts)
+		if err != nil {
+			failures++
+			result.Error = err.Error()
+			slog.Error(fmt.Sprintf("❌ %s: %v", result.Model, err), "model", result.Model, "error", err)
+
+			continue
+		}
+
+		result.ElapsedSeconds = transcript.Elapsed.Seconds()
+		result.AudioSeconds = transcript.Duration.Seconds()
+		result.RealtimeFactor = realtimeFactor(transcript.Duration, transcript.Elapsed)
+		result.Words = s.countWords(transcript.Text, transcript.Language)
+		result.Language = transcript.Language
+		result.text = transcript.Text
+	}
+
+	if reference {
+		compareToReference(results)
+	}
+
+	if failures > 0 {
+		return results, fmt.Errorf("benchmark failed for %d of %d model(s)", failures, len(results))
+	}
+
+	return results, nil
+}
+
+// compareToReference marks the largest model that succeeded as the
+// reference and sets the word error rate of the other ones against it
+func compareToReference(results []BenchResult) {
+	ref := -1
+	for i, result := range results {
+		if result.Error == "" && (ref < 0 || result.modelSize > results[ref].modelSize) {
+			ref = i
+		}
+	}
+
+	if ref < 0 {
+		return
+	}
+
+	results[ref].Reference = true
+	slog.Debug(fmt.Sprintf("📏 Comparing word error rates against model %s", results[ref].Model),
+		"reference", results[ref].Model)
+
+	for i := range results {
+		if i == ref || results[i].Error != "" {
+			continue
+		}
+
+		rate := diff.WordErrorRate(results[ref].text, results[i].text)
+		results[i].WordErrorRate = &rate
+	}
+}
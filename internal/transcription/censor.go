@@ -0,0 +1,129 @@
+package transcription
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// defaultCensorWords is the built-in list used by --censor. A trailing "*"
+// also matches longer words starting with the entry.
+var defaultCensorWords = []string{
+	"arse", "arsehole", "asshole*", "bastard*", "bitch*", "bollocks", "bullshit*",
+	"cock", "cocks", "cocksucker*", "crap", "cunt*", "dick", "dickhead*", "dicks",
+	"fuck*", "motherfuck*", "piss", "pissed", "prick*", "shit*", "slut*",
+	"twat*", "wank*", "whore*",
+}
+
+// Censor masks words from a word list. Only whole words match, so "assume"
+// is left alone when "ass" is listed.
+type Censor struct {
+	pattern *regexp.Regexp
+}
+
+// NewCensor creates a censor for the word list at path, one word per line
+// with # starting a comment. An empty path uses the built-in list.
+func NewCensor(path string) (*Censor, error) {
+	words := defaultCensorWords
+
+	if path != "" {
+		var err error
+		if words, err = readWordList(path); err != nil {
+			return nil, err
+		}
+	}
+
+	alternatives := make([]string, 0, len(words))
+	for _, word := range words {
+		word = strings.ToLower(strings.TrimSpace(word))
+
+		prefix, wildcard := strings.CutSuffix(word, "*")
+		if prefix == "" {
+			continue
+		}
+
+		alternative := regexp.QuoteMeta(prefix)
+		if wildcard {
+			alternative += `[\pL\pN]*`
+		}
+		alternatives = append(alternatives, alternative)
+	}
+
+	if len(alternatives) == 0 {
+		return &Censor{}, nil
+	}
+
+	// \b only knows ASCII, so word boundaries are spelled out to cover
+	// accented letters as well
+	pattern, err := regexp.Compile(`(?i)(^|[^\pL\pN])(` + strings.Join(alternatives, "|") + `)($|[^\pL\pN])`)
+	if err != nil {
+		return nil, fmt.Errorf("invalid censor word list: %w", err)
+	}
+
+	return &Censor{pattern: pattern}, nil
+}
+
+// readWordList reads a word list file
+func readWordList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read censor word list: %w", err)
+	}
+	defer file.Close()
+
+	var words []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		words = append(words, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read censor word list: %w", err)
+	}
+
+	return words, nil
+}
+
+// Apply masks every listed word in text, keeping its first letter and
+// length, e.g. "Shit" becomes "S***"
+func (c *Censor) Apply(text string) string {
+	if c.pattern == nil {
+		return text
+	}
+
+	// Matches consume the surrounding separators, so adjacent words need
+	// another pass
+	for {
+		masked := c.pattern.ReplaceAllStringFunc(text, func(match string) string {
+			groups := c.pattern.FindStringSubmatch(match)
+
+			return groups[1] + mask(groups[2]) + groups[3]
+		})
+
+		if masked == text {
+			return masked
+		}
+
+		text = masked
+	}
+}
+
+// mask replaces all but the first letter of a word with asterisks
+func mask(word string) string {
+	first, size := utf8.DecodeRuneInString(word)
+	if !unicode.IsLetter(first) {
+		return strings.Repeat("*", utf8.RuneCountInString(word))
+	}
+
+	return word[:size] + strings.Repeat("*", utf8.RuneCountInString(word[size:]))
+}
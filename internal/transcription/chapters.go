@@ -0,0 +1,137 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// unsafeFileNameChars are replaced in chapter titles used as file names
+var unsafeFileNameChars = strings.NewReplacer(
+	"/", "_", `\`, "_", ":", "_", "*", "_", "?", "_", `"`, "_", "<", "_", ">", "_", "|", "_",
+)
+
+// transcribeChapters writes one transcript per chapter of the input, each
+// covering the chapter's time range. Timestamps stay relative to the whole
+// recording, as with --start and --end.
+func (s *Service) transcribeChapters(batchCtx, ctx context.Context, inputPath, sourcePath, outputPath string, chapters []transcribe.Chapter) (*FileStats, error) {
+	startTime := time.Now()
+	stats := &FileStats{}
+
+	var outputs []string
+
+	for i, chapter := range chapters {
+		opts := s.transcribeOptions()
+		opts.Start = chapter.Start
+		opts.End = chapter.End
+
+		result, err := s.transcribeWithRetry(ctx, sourcePath, opts)
+		if err != nil {
+			switch {
+			case errors.Is(batchCtx.Err(), context.DeadlineExceeded):
+				return nil, ErrBatchTimeout
+			case errors.Is(ctx.Err(), context.DeadlineExceeded):
+				return nil, fmt.Errorf("%w after %s", ErrTimeout, s.opts.Timeout)
+			}
+
+			return nil, fmt.Errorf("chapter %d (%s): %w", i+1, displayTitle(chapter, i), err)
+		}
+
+		if i == 0 {
+			logAudioTrack(inputPath, result.AudioTrack)
+		}
+
+		logDiagnostics(inputPath, result.Diagnostics)
+
+		for _, wavPath := range result.WAVPaths {
+			slog.Info(fmt.Sprintf("🔊 Converted audio kept at %s", wavPath), "file", inputPath, "path", wavPath)
+		}
+
+		content, err := s.formatOutput(result, inputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		chapterPath := chapterOutputPath(outputPath, i, len(chapters), displayTitle(chapter, i))
+		if err := s.writeOutput(chapterPath, content); err != nil {
+			return nil, err
+		}
+
+		if s.opts.SegmentOutput {
+			if err := s.writeSegments(result, inputPath, chapterPath); err != nil {
+				return nil, err
+			}
+		}
+
+		if err := s.runHook(inputPath, chapterPath); err != nil {
+			return nil, err
+		}
+
+		slog.Info(fmt.Sprintf("📖 [%d/%d] %s (%s)", i+1, len(chapters), displayTitle(chapter, i),
+			result.Duration.Round(time.Second)),
+			"file", inputPath, "chapter", i+1, "title", chapter.Title, "output", chapterPath,
+			"start", chapter.Start, "end", chapter.End)
+
+		stats.WordCount += s.countWords(result.Text, result.Language)
+		stats.segments = append(stats.segments, s.observedSegments(result)...)
+		stats.Duration += result.Duration
+		if stats.Language == "" {
+			stats.Language = result.Language
+		}
+
+		outputs = append(outputs, chapterPath)
+	}
+
+	stats.Elapsed = time.Since(startTime)
+	stats.OutputPath = strings.Join(outputs, ", ")
+
+	return stats, nil
+}
+
+// displayTitle is the chapter's title, or "Chapter N" for untitled ones
+func displayTitle(chapter transcribe.Chapter, index int) string {
+	if title := strings.TrimSpace(chapter.Title); title != "" {
+		return title
+	}
+
+	return fmt.Sprintf("Chapter %d", index+1)
+}
+
+// chapterOutputPath names the transcript of a chapter after the input's
+// transcript, its number and title, e.g. "book - 03 - The Storm.txt". The
+// numbers are zero-padded so the files sort in order.
+func chapterOutputPath(outputPath string, index, count int, title string) string {
+	ext := filepath.Ext(outputPath)
+	width := max(2, len(strconv.Itoa(count)))
+
+	title = strings.Trim(unsafeFileNameChars.Replace(title), ". ")
+	if title == "" {
+		title = fmt.Sprintf("Chapter %d", index+1)
+	}
+
+	return fmt.Sprintf("%s - %0*d - %s%s", strings.TrimSuffix(outputPath, ext), width, index+1, title, ext)
+}
+
+// hasChapterOutputs reports whether transcripts of chapters named after
+// outputPath exist, written by an earlier run with --split-chapters
+func hasChapterOutputs(outputPath string) bool {
+	ext := filepath.Ext(outputPath)
+
+	pattern := escapeGlob(strings.TrimSuffix(outputPath, ext)) + " - [0-9][0-9]* - *" + escapeGlob(ext)
+	matches, err := filepath.Glob(pattern)
+
+	return err == nil && len(matches) > 0
+}
+
+// escapeGlob escapes the characters with a special meaning in
+// filepath.Match patterns
+func escapeGlob(path string) string {
+	return strings.NewReplacer(`\`, `\\`, "*", `\*`, "?", `\?`, "[", `\[`).Replace(path)
+}
@@ -0,0 +1,89 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// DetectLanguages prints the language whisper detects in a sample of each
+// input, without writing transcripts. Each result goes to stdout as a
+// tab-separated line of language, confidence and file, e.g. to sort a
+// multilingual archive with a script.
+func (s *Service) DetectLanguages(inputs []string) error {
+	audioFiles, err := s.findAudioFiles(inputs)
+	if err != nil {
+		return fmt.Errorf("failed to find audio files: %w", err)
+	}
+
+	if len(audioFiles) == 0 {
+		return ErrNoAudioFiles
+	}
+
+	if err := s.checkWhisper(); err != nil {
+		return err
+	}
+
+	if err := s.checkFFmpeg(audioFiles); err != nil {
+		return err
+	}
+
+	if err := s.ensureModelDownloaded(); err != nil {
+		return fmt.Errorf("model preparation failed: %w", err)
+	}
+
+	slog.Info(fmt.Sprintf("🌐 Detecting the language of %d file(s) with model %s", len(audioFiles), s.opts.Model),
+		"files", len(audioFiles), "model", s.opts.Model)
+
+	failures := 0
+
+	for _, file := range audioFiles {
+		detection, err := s.detectLanguage(file)
+		if err != nil {
+			failures++
+			slog.Error(fmt.Sprintf("❌ %s: %v", filepath.Base(file), err), "file", file, "error", err)
+
+			continue
+		}
+
+		fmt.Printf("%s\t%.2f\t%s\n", detection.Language, detection.Probability, file)
+		slog.Debug(fmt.Sprintf("🌐 %s: %s (p = %.2f, from %s of audio)", filepath.Base(file), detection.Language,
+			detection.Probability, detection.Sample.Round(time.Second)),
+			"file", file, "language", detection.Language, "probability", detection.Probability, "sample", detection.Sample)
+	}
+
+	if failures > 0 {
+		return fmt.Errorf("language detection failed for %d of %d file(s)", failures, len(audioFiles))
+	}
+
+	return nil
+}
+
+// detectLanguage detects the language of one input, downloading remote
+// inputs first
+func (s *Service) detectLanguage(inputPath string) (*transcribe.LanguageDetection, error) {
+	ctx := context.Background()
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	sourcePath := inputPath
+	if IsURL(inputPath) {
+		localPath, err := s.downloadAudio(ctx, inputPath)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(localPath)
+
+		sourcePath = localPath
+	}
+
+	return s.transcriber.DetectLanguage(ctx, sourcePath, s.transcribeOptions())
+}
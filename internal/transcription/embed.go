@@ -0,0 +1,96 @@
+package transcription
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// chapterTitleWords is how many words of a paragraph make up its chapter title
+const chapterTitleWords = 8
+
+// taggedSuffix marks the tagged copies --embed writes, <name>.tagged.<ext>
+const taggedSuffix = ".tagged"
+
+// isTaggedCopy reports whether path is a tagged copy written by --embed.
+// Directory scans and the watcher skip them, or every run would transcribe
+// and tag the previous run's copies again.
+func isTaggedCopy(path string) bool {
+	name := filepath.Base(path)
+
+	return strings.HasSuffix(strings.TrimSuffix(name, filepath.Ext(name)), taggedSuffix)
+}
+
+// embedTranscript stores the transcript in the metadata of the audio file
+// and returns the path of the tagged file. The original is only replaced
+// with --in-place; remote inputs always get a tagged copy next to the
+// transcript.
+func (s *Service) embedTranscript(ctx context.Context, inputPath, sourcePath, outputPath string, result *transcribe.Result) (string, error) {
+	taggedPath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + taggedSuffix + filepath.Ext(sourcePath)
+	if s.opts.InPlace && !IsURL(inputPath) {
+		taggedPath = sourcePath
+	}
+
+	result, err := s.postProcess(result)
+	if err != nil {
+		return "", err
+	}
+
+	text := result.Text
+	if !s.opts.NoFormat {
+		text = NewTextFormatter(s.opts.Formatter).WithLanguage(result.Language).Format(result.Text)
+	}
+
+	var chapters []transcribe.Chapter
+	if s.opts.Timestamps && !s.opts.NoFormat {
+		chapters = paragraphChapters(text, result.Segments)
+	}
+
+	if err := s.transcriber.EmbedTranscript(ctx, sourcePath, taggedPath, text, chapters); err != nil {
+		return "", fmt.Errorf("failed to embed transcript: %w", err)
+	}
+
+	return taggedPath, nil
+}
+
+// paragraphChapters turns each paragraph of the formatted transcript into a
+// chapter titled with its first words. A chapter lasts until the next one
+// starts; paragraphs starting at the same time are merged.
+func paragraphChapters(formattedText string, segments []transcribe.Segment) []transcribe.Chapter {
+	if formattedText == "" || len(segments) == 0 {
+		return nil
+	}
+
+	paragraphs := strings.Split(formattedText, "\n\n")
+	starts := paragraphStartTimes(paragraphs, segments)
+
+	var chapters []transcribe.Chapter
+	for i, paragraph := range paragraphs {
+		if len(chapters) > 0 && starts[i] <= chapters[len(chapters)-1].Start {
+			continue
+		}
+
+		if len(chapters) > 0 {
+			chapters[len(chapters)-1].End = starts[i]
+		}
+
+		chapters = append(chapters, transcribe.Chapter{Start: starts[i], Title: chapterTitle(paragraph)})
+	}
+
+	chapters[len(chapters)-1].End = segments[len(segments)-1].End
+
+	return chapters
+}
+
+// chapterTitle shortens a paragraph to its first few words
+func chapterTitle(paragraph string) string {
+	words := strings.Fields(paragraph)
+	if len(words) <= chapterTitleWords {
+		return strings.Join(words, " ")
+	}
+
+	return strings.Join(words[:chapterTitleWords], " ") + "…"
+}
@@ -0,0 +1,114 @@
+package transcription
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+)
+
+// Encodings accepted by --encoding for transcript files
+const (
+	EncodingUTF8    = "utf-8"
+	EncodingUTF8BOM = "utf-8-bom"
+	EncodingUTF16LE = "utf-16le"
+	EncodingUTF16BE = "utf-16be"
+)
+
+// Encodings lists the values accepted by --encoding
+var Encodings = []string{EncodingUTF8, EncodingUTF8BOM, EncodingUTF16LE, EncodingUTF16BE}
+
+// Byte order marks of the encodings
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// encoding is the encoding of transcript files in effect
+func (s *Service) encoding() string {
+	if s.opts.Encoding == "" {
+		return EncodingUTF8
+	}
+
+	return s.opts.Encoding
+}
+
+// byteOrderMark is the byte order mark written at the start of files in the
+// encoding, none for plain UTF-8
+func byteOrderMark(encoding string) []byte {
+	switch encoding {
+	case EncodingUTF8BOM:
+		return bomUTF8
+	case EncodingUTF16LE:
+		return bomUTF16LE
+	case EncodingUTF16BE:
+		return bomUTF16BE
+	default:
+		return nil
+	}
+}
+
+// encodeText encodes a transcript for writing. Byte order marks that came
+// with the text, e.g. from whisper or a front matter template, are dropped
+// so only the one of the encoding is written. UTF-16 always gets one, tools
+// reading it can't tell the byte order otherwise.
+func encodeText(content, encoding string) []byte {
+	content = strings.ReplaceAll(content, "\uFEFF", "")
+
+	var order binary.AppendByteOrder
+
+	switch encoding {
+	case EncodingUTF16LE:
+		order = binary.LittleEndian
+	case EncodingUTF16BE:
+		order = binary.BigEndian
+	default:
+		return append(bytes.Clone(byteOrderMark(encoding)), content...)
+	}
+
+	data := bytes.Clone(byteOrderMark(encoding))
+	for _, unit := range utf16.Encode([]rune(content)) {
+		data = order.AppendUint16(data, unit)
+	}
+
+	return data
+}
+
+// detectEncoding tells the encoding of a file written by ghospel by its byte
+// order mark
+func detectEncoding(data []byte) string {
+	for _, encoding := range Encodings {
+		if bom := byteOrderMark(encoding); bom != nil && bytes.HasPrefix(data, bom) {
+			return encoding
+		}
+	}
+
+	return EncodingUTF8
+}
+
+// decodeText reads a transcript in any of the encodings ghospel writes. The
+// encoding is told by the byte order mark, files without one are UTF-8.
+func decodeText(data []byte) string {
+	var order binary.ByteOrder
+
+	switch detectEncoding(data) {
+	case EncodingUTF8BOM:
+		return string(data[len(bomUTF8):])
+	case EncodingUTF16LE:
+		order = binary.LittleEndian
+	case EncodingUTF16BE:
+		order = binary.BigEndian
+	default:
+		return string(data)
+	}
+
+	data = data[2:]
+
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		units = append(units, order.Uint16(data[i:]))
+	}
+
+	return string(utf16.Decode(units))
+}
@@ -0,0 +1,77 @@
+package transcription
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrInputNotFound is returned when an input path doesn't exist or a glob
+// pattern matches nothing
+var ErrInputNotFound = errors.New("input not found")
+
+// ErrTimeout marks a file that didn't finish within --timeout
+var ErrTimeout = errors.New("transcription timed out")
+
+// ErrBatchTimeout marks files that didn't finish before --batch-timeout
+var ErrBatchTimeout = errors.New("batch timeout reached")
+
+// FileError records why a single file failed to transcribe
+type FileError struct {
+	Path string
+	Err  error
+}
+
+func (e *FileError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Path, e.Err)
+}
+
+func (e *FileError) Unwrap() error {
+	return e.Err
+}
+
+// BatchError aggregates the per-file failures of a batch run
+type BatchError struct {
+	Failures []*FileError
+	Total    int
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("%d of %d file(s) failed to transcribe", len(e.Failures), e.Total)
+}
+
+// Unwrap exposes the individual failures to errors.Is and errors.As
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, len(e.Failures))
+	for i, failure := range e.Failures {
+		errs[i] = failure
+	}
+
+	return errs
+}
+
+// errorLogName is the file written to the output directory listing failures
+const errorLogName = "errors.log"
+
+// writeErrorLog writes one line per failed file to errors.log in dir
+func writeErrorLog(dir string, batchErr *BatchError) (string, error) {
+	var content strings.Builder
+
+	fmt.Fprintf(&content, "# Ghospel run at %s: %s\n", time.Now().Format(time.RFC3339), batchErr.Error())
+
+	for _, failure := range batchErr.Failures {
+		// Keep each failure on one line, whisper/ffmpeg output can span many
+		cause := strings.Join(strings.Fields(failure.Err.Error()), " ")
+		fmt.Fprintf(&content, "%s\t%s\n", failure.Path, cause)
+	}
+
+	path := filepath.Join(dir, errorLogName)
+	if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+		return "", fmt.Errorf("failed to write error log: %w", err)
+	}
+
+	return path, nil
+}
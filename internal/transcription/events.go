@@ -0,0 +1,156 @@
+package transcription
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+	"time"
+)
+
+// Progress modes accepted by --progress
+const (
+	ProgressBar  = "bar"
+	ProgressJSON = "json"
+)
+
+// ProgressModes lists the values accepted by --progress
+var ProgressModes = []string{ProgressBar, ProgressJSON}
+
+// Event names of the JSON progress stream
+const (
+	eventBatchStarted   = "batch_started"
+	eventFileStarted    = "file_started"
+	eventFileCompleted  = "file_completed"
+	eventFileFailed     = "file_failed"
+	eventBatchCompleted = "batch_completed"
+)
+
+// eventStream writes progress events as JSON lines for programs running
+// ghospel as a subprocess. Field names are stable, times are in seconds.
+// It observes the batches of the service with --progress json.
+type eventStream struct {
+	NopObserver
+
+	mu sync.Mutex
+	w  io.Writer
+
+	index map[string]int // Position of each file of the running batch, from 1
+	total int
+}
+
+// event holds the fields every event has
+type event struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+}
+
+// batchStartedEvent is emitted once the files to transcribe are known
+type batchStartedEvent struct {
+	event
+	Files   int `json:"files"`
+	Skipped int `json:"skipped"`
+}
+
+// fileEvent identifies the file an event is about, Index counts from 1
+type fileEvent struct {
+	event
+	File  string `json:"file"`
+	Index int    `json:"index"`
+	Total int    `json:"total"`
+}
+
+// fileCompletedEvent is emitted when a transcript was written
+type fileCompletedEvent struct {
+	fileEvent
+	Output         string  `json:"output"`
+	Language       string  `json:"language,omitempty"`
+	Words          int     `json:"words"`
+	AudioSeconds   float64 `json:"audio_seconds"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// fileFailedEvent is emitted when a file couldn't be transcribed
+type fileFailedEvent struct {
+	fileEvent
+	Error string `json:"error"`
+}
+
+// batchCompletedEvent summarizes the run
+type batchCompletedEvent struct {
+	event
+	Succeeded      int     `json:"succeeded"`
+	Failed         int     `json:"failed"`
+	Skipped        int     `json:"skipped"`
+	Words          int     `json:"words"`
+	AudioSeconds   float64 `json:"audio_seconds"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+}
+
+// OnBatchStart emits batch_started and remembers the position of each file
+func (e *eventStream) OnBatchStart(files []string, skipped int) {
+	e.index = make(map[string]int, len(files))
+	for i, file := range files {
+		e.index[file] = i + 1
+	}
+	e.total = len(files)
+
+	e.emit(batchStartedEvent{event: newEvent(eventBatchStarted), Files: len(files), Skipped: skipped})
+}
+
+// OnFileStart emits file_started
+func (e *eventStream) OnFileStart(file string, index, total int) {
+	e.emit(fileEvent{event: newEvent(eventFileStarted), File: file, Index: index, Total: total})
+}
+
+// OnFileDone emits file_completed
+func (e *eventStream) OnFileDone(file string, stats *FileStats) {
+	e.emit(fileCompletedEvent{
+		fileEvent:      e.fileEvent(eventFileCompleted, file),
+		Output:         stats.OutputPath,
+		Language:       stats.Language,
+		Words:          stats.WordCount,
+		AudioSeconds:   stats.Duration.Seconds(),
+		ElapsedSeconds: stats.Elapsed.Seconds(),
+	})
+}
+
+// OnError emits file_failed
+func (e *eventStream) OnError(file string, err error) {
+	e.emit(fileFailedEvent{fileEvent: e.fileEvent(eventFileFailed, file), Error: err.Error()})
+}
+
+// OnBatchDone emits batch_completed with the totals of the report
+func (e *eventStream) OnBatchDone(report *Report) {
+	e.emit(batchCompletedEvent{
+		event:          newEvent(eventBatchCompleted),
+		Succeeded:      report.Succeeded,
+		Failed:         report.Failed,
+		Skipped:        report.Skipped,
+		Words:          report.Words,
+		AudioSeconds:   report.AudioSeconds,
+		ElapsedSeconds: report.ElapsedSeconds,
+	})
+}
+
+// fileEvent starts an event about a file of the running batch
+func (e *eventStream) fileEvent(name, file string) fileEvent {
+	return fileEvent{event: newEvent(name), File: file, Index: e.index[file], Total: e.total}
+}
+
+// newEvent starts an event of the given name at the current time
+func newEvent(name string) event {
+	return event{Event: name, Time: time.Now()}
+}
+
+// emit writes one event per line
+func (e *eventStream) emit(v any) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	e.w.Write(append(data, '\n'))
+}
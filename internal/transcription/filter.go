@@ -0,0 +1,54 @@
+package transcription
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+// CheckNamePatterns validates --include and --exclude patterns. They are
+// matched against file names, so patterns with a directory never match.
+func CheckNamePatterns(flag string, patterns []string) error {
+	for _, pattern := range patterns {
+		if strings.ContainsAny(pattern, `/\`) {
+			return fmt.Errorf("invalid --%s: %s (patterns match file names, not paths)", flag, pattern)
+		}
+
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid --%s: %s: %w", flag, pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// matchesAny reports whether name matches one of the glob patterns,
+// ignoring case like file systems on macOS and Windows do
+func matchesAny(name string, patterns []string) bool {
+	name = strings.ToLower(name)
+	for _, pattern := range patterns {
+		if ok, _ := filepath.Match(strings.ToLower(pattern), name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// nameIncluded reports whether the file name of path passes --include and
+// --exclude, logging the files it filters out. Exclude wins over include.
+func (s *Service) nameIncluded(path string) bool {
+	name := filepath.Base(path)
+
+	switch {
+	case len(s.opts.Include) > 0 && !matchesAny(name, s.opts.Include):
+		slog.Debug(fmt.Sprintf("⏭️  Skipping %s (not matched by --include)", name), "file", path)
+		return false
+	case matchesAny(name, s.opts.Exclude):
+		slog.Debug(fmt.Sprintf("⏭️  Skipping %s (matched by --exclude)", name), "file", path)
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,104 @@
+package transcription
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FormatAll selects every output format in --format
+const FormatAll = "all"
+
+// ParseFormats parses a --format value: one format, several separated by
+// commas such as "txt,srt", or "all" for every one of ValidFormats. The
+// first format is the primary one, see Options.Formats.
+func ParseFormats(value string) ([]string, error) {
+	var formats []string
+
+	for _, format := range strings.Split(strings.ToLower(value), ",") {
+		format = strings.TrimSpace(format)
+
+		switch {
+		case format == "":
+			continue
+		case format == FormatAll:
+			for _, f := range ValidFormats {
+				if !slices.Contains(formats, f) {
+					formats = append(formats, f)
+				}
+			}
+		case !slices.Contains(ValidFormats, format):
+			return nil, fmt.Errorf("invalid format: %s (valid: %s or %s)", format, strings.Join(ValidFormats, ", "),
+				FormatAll)
+		case !slices.Contains(formats, format):
+			formats = append(formats, format)
+		}
+	}
+
+	if len(formats) == 0 {
+		return nil, fmt.Errorf("invalid format: %q (valid: %s or %s)", value, strings.Join(ValidFormats, ", "), FormatAll)
+	}
+
+	return formats, nil
+}
+
+// formats are the formats every input is written in, the primary Format
+// first
+func (s *Service) formats() []string {
+	if len(s.opts.Formats) > 1 {
+		return s.opts.Formats
+	}
+
+	return []string{s.opts.Format}
+}
+
+// outputPathFor is the transcript path of an input in one of the formats.
+// An exact OutputPath is used for the primary format; the other formats go
+// next to it, with their extension instead of its.
+func (s *Service) outputPathFor(inputPath, format string) string {
+	if s.opts.OutputPath != "" {
+		if format == s.opts.Format {
+			return s.availablePath(s.opts.OutputPath)
+		}
+
+		base := strings.TrimSuffix(s.opts.OutputPath, filepath.Ext(s.opts.OutputPath))
+
+		return s.availablePath(base + "." + format)
+	}
+
+	dir := filepath.Dir(inputPath)
+	if IsURL(inputPath) {
+		dir = "."
+		inputPath = remoteFileName(inputPath)
+	}
+
+	if s.opts.OutputDir != "" {
+		dir = s.opts.OutputDir
+	}
+
+	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+	if s.modelSuffix {
+		base += "." + modelLabel(s.opts.Model)
+	}
+
+	return s.availablePath(filepath.Join(dir, base+"."+format))
+}
+
+// outputPaths lists the transcript paths of an input in every format, as
+// reported to the user
+func (s *Service) outputPaths(inputPath string) string {
+	formats := s.formats()
+
+	paths := make([]string, len(formats))
+	for i, format := range formats {
+		paths[i] = s.outputPathFor(inputPath, format)
+	}
+
+	return strings.Join(paths, ", ")
+}
+
+// writesSubtitles tells whether one of the formats is srt or vtt
+func (s *Service) writesSubtitles() bool {
+	return slices.Contains(s.formats(), "srt") || slices.Contains(s.formats(), "vtt")
+}
@@ -3,6 +3,8 @@ package transcription
 import (
 	"regexp"
 	"strings"
+	"unicode"
+	"unicode/utf8"
 )
 
 // TextFormatter handles formatting transcribed text into readable paragraphs
@@ -10,15 +12,36 @@ type TextFormatter struct {
 	targetWordCount                int
 	maxSentencesPerChunk           int
 	minWordsForSignificantSentence int
+	language                       string // Language words are counted in
 }
 
-// NewTextFormatter creates a new text formatter with default settings
-func NewTextFormatter() *TextFormatter {
-	return &TextFormatter{
+// FormatterOptions controls paragraph sizing. Zero values use the defaults.
+type FormatterOptions struct {
+	TargetWordCount                int // Target words per paragraph (default 50)
+	MaxSentencesPerChunk           int // Maximum significant sentences per paragraph (default 4)
+	MinWordsForSignificantSentence int // Words needed for a sentence to count as significant (default 4)
+}
+
+// NewTextFormatter creates a new text formatter, falling back to the default
+// settings for any option left at zero
+func NewTextFormatter(opts FormatterOptions) *TextFormatter {
+	f := &TextFormatter{
 		targetWordCount:                50, // Target ~50 words per paragraph
 		maxSentencesPerChunk:           4,  // Maximum 4 sentences per paragraph
 		minWordsForSignificantSentence: 4,  // Sentences with 4+ words are "significant"
 	}
+
+	if opts.TargetWordCount > 0 {
+		f.targetWordCount = opts.TargetWordCount
+	}
+	if opts.MaxSentencesPerChunk > 0 {
+		f.maxSentencesPerChunk = opts.MaxSentencesPerChunk
+	}
+	if opts.MinWordsForSignificantSentence > 0 {
+		f.minWordsForSignificantSentence = opts.MinWordsForSignificantSentence
+	}
+
+	return f
 }
 
 // Format takes raw transcription text and formats it into readable paragraphs
@@ -84,7 +107,7 @@ func (f *TextFormatter) Format(text string) string {
 
 		// Add the chunk to final text
 		if len(sentencesForFinalChunk) > 0 {
-			chunkText := strings.Join(sentencesForFinalChunk, " ")
+			chunkText := joinSentences(sentencesForFinalChunk)
 			chunkText = f.cleanText(chunkText)
 
 			if finalFormattedText.Len() > 0 {
@@ -103,50 +126,118 @@ func (f *TextFormatter) Format(text string) string {
 	return strings.TrimSpace(finalFormattedText.String())
 }
 
+// sentenceBoundaryRegex matches sentence-ending punctuation, ideographic
+// ones included, and the start of the next sentence. Latin punctuation only
+// ends a sentence before whitespace and a capital letter (see
+// splitIntoSentences), so decimals like "3.5 million" never split.
+var sentenceBoundaryRegex = regexp.MustCompile(`([.!?]+|[。！？]+)(\s*)(\S)`)
+
+// initialismRegex matches dotted initialisms such as "U.S" or "e.g" (the
+// final period is part of the boundary match)
+var initialismRegex = regexp.MustCompile(`^([A-Za-z]\.)+[A-Za-z]$`)
+
+// abbreviations are words that end in a period without ending a sentence.
+// "etc." is deliberately missing since it usually does end one.
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "prof": true, "sr": true,
+	"jr": true, "st": true, "mt": true, "vs": true, "inc": true, "ltd": true,
+	"co": true, "corp": true, "no": true, "approx": true, "dept": true,
+	"fig": true, "gen": true, "gov": true, "lt": true, "col": true, "sgt": true,
+	"capt": true, "rev": true, "jan": true, "feb": true, "mar": true, "apr": true,
+	"jun": true, "jul": true, "aug": true, "sep": true, "sept": true, "oct": true,
+	"nov": true, "dec": true,
+}
+
 // splitIntoSentences splits text into sentences using punctuation patterns
 func (f *TextFormatter) splitIntoSentences(text string) []string {
 	// Clean up the text first
 	text = strings.TrimSpace(text)
 	text = regexp.MustCompile(`\s+`).ReplaceAllString(text, " ")
 
-	// Split on sentence-ending punctuation followed by whitespace and capital letter
-	// This regex looks for: . ! ? followed by space(s) and capital letter
-	sentenceRegex := regexp.MustCompile(`([.!?]+)\s+([A-Z])`)
+	var sentences []string
 
-	// Replace matches with sentence ending + newline + capital letter
-	text = sentenceRegex.ReplaceAllString(text, "$1\n$2")
+	start := 0
 
-	// Split on newlines and clean up
-	rawSentences := strings.Split(text, "\n")
+	for _, match := range sentenceBoundaryRegex.FindAllStringSubmatchIndex(text, -1) {
+		punctStart, punctEnd, nextStart := match[2], match[3], match[6]
 
-	var sentences []string
+		// Latin punctuation ends a sentence before a space and a capital
+		// letter, "。" and its kin anywhere but inside quotes: 「本当に？」
+		next, _ := utf8.DecodeRuneInString(text[nextStart:])
+		if text[punctStart] < utf8.RuneSelf {
+			if match[5] == match[4] || !unicode.IsUpper(next) {
+				continue
+			}
+		} else if unicode.In(next, unicode.Pe, unicode.Pf) {
+			continue
+		}
 
-	for _, sentence := range rawSentences {
-		sentence = strings.TrimSpace(sentence)
-		if sentence != "" {
+		// "Dr. Smith" or "U.S. Navy" don't end a sentence
+		if text[punctStart:punctEnd] == "." && f.isAbbreviation(text[start:punctStart]) {
+			continue
+		}
+
+		if sentence := strings.TrimSpace(text[start:punctEnd]); sentence != "" {
 			sentences = append(sentences, sentence)
 		}
+
+		start = nextStart
+	}
+
+	if sentence := strings.TrimSpace(text[start:]); sentence != "" {
+		sentences = append(sentences, sentence)
 	}
 
 	// If no sentence splits were found, treat the whole text as one sentence
-	if len(sentences) <= 1 && len(rawSentences) == 1 {
+	if len(sentences) == 0 {
 		sentences = []string{text}
 	}
 
 	return sentences
 }
 
-// countWords counts the number of words in a sentence
-func (f *TextFormatter) countWords(sentence string) int {
-	sentence = strings.TrimSpace(sentence)
-	if sentence == "" {
-		return 0
+// isAbbreviation reports whether the last word of the text preceding a period
+// is a known abbreviation, a dotted initialism, or a single-letter initial
+func (f *TextFormatter) isAbbreviation(preceding string) bool {
+	words := strings.Fields(preceding)
+	if len(words) == 0 {
+		return false
+	}
+
+	word := strings.TrimLeft(words[len(words)-1], `"'([`)
+
+	if len(word) == 1 && word[0] >= 'A' && word[0] <= 'Z' {
+		return true
 	}
 
-	// Split on whitespace and count non-empty parts
-	words := strings.Fields(sentence)
+	return abbreviations[strings.ToLower(word)] || initialismRegex.MatchString(word)
+}
 
-	return len(words)
+// WithLanguage sets the language of the text, so paragraph sizes are
+// measured in characters for languages written without spaces
+func (f *TextFormatter) WithLanguage(language string) *TextFormatter {
+	f.language = language
+	return f
+}
+
+// countWords counts the number of words in a sentence, see wordCount
+func (f *TextFormatter) countWords(sentence string) int {
+	return wordCount(sentence, f.language)
+}
+
+// joinSentences joins sentences with spaces, except after Chinese and
+// Japanese ones, which aren't separated by spaces
+func joinSentences(sentences []string) string {
+	var text strings.Builder
+
+	for i, sentence := range sentences {
+		if i > 0 && !endsWithCJK(sentences[i-1]) {
+			text.WriteByte(' ')
+		}
+		text.WriteString(sentence)
+	}
+
+	return text.String()
 }
 
 // cleanText performs basic text cleanup
@@ -167,3 +258,49 @@ func (f *TextFormatter) cleanText(text string) string {
 
 	return strings.TrimSpace(text)
 }
+
+// wrapText hard-wraps every line of text at width columns without breaking
+// words; words longer than the width get a line of their own. Blank lines
+// between paragraphs are kept and a width of 0 disables wrapping.
+// Timestamp markers contain no spaces, so they are never split. Chinese and
+// Japanese text breaks between characters, which take two columns each.
+func wrapText(text string, width int) string {
+	if width <= 0 {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		var wrapped strings.Builder
+
+		column := 0
+		for _, word := range strings.Fields(line) {
+			for j, piece := range breakablePieces(word) {
+				length := textWidth(piece)
+
+				// Pieces of a word follow each other without a space
+				space := 1
+				if j > 0 {
+					space = 0
+				}
+
+				switch {
+				case column == 0:
+				case column+space+length > width:
+					wrapped.WriteByte('\n')
+					column = 0
+				case space > 0:
+					wrapped.WriteByte(' ')
+					column++
+				}
+
+				wrapped.WriteString(piece)
+				column += length
+			}
+		}
+
+		lines[i] = wrapped.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,100 @@
+package transcription
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitIntoSentences(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{
+			name: "plain sentences",
+			text: "This is one. This is two! Is this three?",
+			want: []string{"This is one.", "This is two!", "Is this three?"},
+		},
+		{
+			name: "title abbreviation",
+			text: "We spoke to Dr. Smith yesterday. He agreed.",
+			want: []string{"We spoke to Dr. Smith yesterday.", "He agreed."},
+		},
+		{
+			name: "dotted initialism",
+			text: "He served in the U.S. Navy for years. Then he retired.",
+			want: []string{"He served in the U.S. Navy for years.", "Then he retired."},
+		},
+		{
+			name: "decimal number",
+			text: "The city has 3.5 million people. It keeps growing.",
+			want: []string{"The city has 3.5 million people.", "It keeps growing."},
+		},
+		{
+			name: "single-letter initial",
+			text: "The novel by George R. Martin is long. Fans wait.",
+			want: []string{"The novel by George R. Martin is long.", "Fans wait."},
+		},
+		{
+			name: "abbreviation in quotes",
+			text: `"Mr. Brown is here," she said. Nobody moved.`,
+			want: []string{`"Mr. Brown is here," she said.`, "Nobody moved."},
+		},
+		{
+			name: "lower case after period",
+			text: "Prices rose by approx. ten percent. Sales fell.",
+			want: []string{"Prices rose by approx. ten percent.", "Sales fell."},
+		},
+		{
+			name: "etc ends a sentence",
+			text: "We bought apples, pears, etc. Then we left.",
+			want: []string{"We bought apples, pears, etc.", "Then we left."},
+		},
+		{
+			name: "ideographic punctuation",
+			text: "今日は晴れです。明日は雨です。",
+			want: []string{"今日は晴れです。", "明日は雨です。"},
+		},
+		{
+			name: "no punctuation",
+			text: "just some words without an end",
+			want: []string{"just some words without an end"},
+		},
+	}
+
+	f := NewTextFormatter(FormatterOptions{})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := f.splitIntoSentences(tt.text); !slices.Equal(got, tt.want) {
+				t.Errorf("splitIntoSentences(%q)\n got %q\nwant %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAbbreviation(t *testing.T) {
+	tests := []struct {
+		preceding string
+		want      bool
+	}{
+		{"We spoke to Dr", true},
+		{"in the U.S", true},
+		{"for example, e.g", true},
+		{"George R", true},
+		{`quoted "Mr`, true},
+		{"it grew by 3", false},
+		{"the end", false},
+		{"apples, pears, etc", false},
+		{"", false},
+	}
+
+	f := NewTextFormatter(FormatterOptions{})
+
+	for _, tt := range tests {
+		if got := f.isAbbreviation(tt.preceding); got != tt.want {
+			t.Errorf("isAbbreviation(%q) = %v, want %v", tt.preceding, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package transcription
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+	"gopkg.in/yaml.v3"
+)
+
+// frontMatter is the YAML header written with --front-matter and the data
+// passed to header templates. Field order here is the order in the output.
+type frontMatter struct {
+	Title    string `yaml:"title"`
+	Source   string `yaml:"source"`
+	Model    string `yaml:"model"`
+	Duration string `yaml:"duration"`
+	Language string `yaml:"language"`
+	Date     string `yaml:"date"`
+}
+
+// titleFromPath derives a human-readable title from the source filename
+func titleFromPath(inputPath string) string {
+	base := filepath.Base(inputPath)
+	if IsURL(inputPath) {
+		base = remoteFileName(inputPath)
+	}
+
+	title := strings.TrimSuffix(base, filepath.Ext(base))
+
+	return strings.TrimSpace(strings.NewReplacer("_", " ", "-", " ").Replace(title))
+}
+
+// buildFrontMatter renders the YAML front-matter block for a transcript,
+// including the closing delimiter and a blank line before the body
+func (s *Service) buildFrontMatter(result *transcribe.Result, inputPath string) (string, error) {
+	data, err := yaml.Marshal(s.metadata(result, inputPath))
+	if err != nil {
+		return "", fmt.Errorf("failed to render front matter: %w", err)
+	}
+
+	return "---\n" + string(data) + "---\n\n", nil
+}
+
+// metadata collects the descriptive fields of a transcript
+func (s *Service) metadata(result *transcribe.Result, inputPath string) frontMatter {
+	return frontMatter{
+		Title:    titleFromPath(inputPath),
+		Source:   filepath.Base(inputPath),
+		Model:    s.opts.Model,
+		Duration: result.Duration.Round(time.Second).String(),
+		Language: result.Language,
+		Date:     time.Now().Format("2006-01-02"),
+	}
+}
@@ -0,0 +1,63 @@
+package transcription
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// DefaultHeaderTemplate renders the comment block at the top of txt
+// transcripts. Templates see the same fields as the front matter: Title,
+// Source, Model, Duration, Language and Date.
+const DefaultHeaderTemplate = `# Transcription of: {{.Source}}
+# Model: {{.Model}}
+{{if .Language}}# Language: {{.Language}}
+{{end}}# Generated with Ghospel v0.1.0`
+
+// ParseHeaderTemplate parses and checks a header template, falling back to
+// the default for an empty one
+func ParseHeaderTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		text = DefaultHeaderTemplate
+	}
+
+	tmpl, err := template.New("header").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid header template: %w", err)
+	}
+
+	// Unknown fields only surface when executing, catch them upfront
+	if err := tmpl.Execute(io.Discard, frontMatter{}); err != nil {
+		return nil, fmt.Errorf("invalid header template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// buildHeader renders the txt header followed by a blank line, or nothing
+// when headers are disabled or the template renders empty
+func (s *Service) buildHeader(result *transcribe.Result, inputPath string) (string, error) {
+	if s.opts.NoHeader {
+		return "", nil
+	}
+
+	tmpl, err := ParseHeaderTemplate(s.opts.HeaderTemplate)
+	if err != nil {
+		return "", err
+	}
+
+	var header strings.Builder
+	if err := tmpl.Execute(&header, s.metadata(result, inputPath)); err != nil {
+		return "", fmt.Errorf("failed to render header: %w", err)
+	}
+
+	text := strings.TrimRight(header.String(), "\n")
+	if text == "" {
+		return "", nil
+	}
+
+	return text + "\n\n", nil
+}
@@ -0,0 +1,59 @@
+package transcription
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// HookError is returned when the on-complete hook fails under --strict-hooks
+type HookError struct {
+	Command string
+	Err     error
+}
+
+func (e *HookError) Error() string {
+	return fmt.Sprintf("on-complete hook %q failed: %v", e.Command, e.Err)
+}
+
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// runHook runs the on-complete command for a finished transcript. The command
+// is run through sh with the output path appended as its argument, and the
+// source path and model are exposed as GHOSPEL_SOURCE / GHOSPEL_MODEL.
+func (s *Service) runHook(inputPath, outputPath string) error {
+	if s.opts.OnComplete == "" {
+		return nil
+	}
+
+	cmd := exec.Command("sh", "-c", s.opts.OnComplete+` "$@"`, "ghospel-hook", outputPath)
+	cmd.Env = append(os.Environ(),
+		"GHOSPEL_OUTPUT="+outputPath,
+		"GHOSPEL_SOURCE="+inputPath,
+		"GHOSPEL_MODEL="+s.opts.Model,
+	)
+
+	output, err := cmd.CombinedOutput()
+
+	if len(output) > 0 {
+		slog.Debug(fmt.Sprintf("🪝 Hook output:\n%s", strings.TrimRight(string(output), "\n")),
+			"command", s.opts.OnComplete, "output", string(output))
+	}
+
+	if err == nil {
+		return nil
+	}
+
+	if s.opts.StrictHooks {
+		return &HookError{Command: s.opts.OnComplete, Err: err}
+	}
+
+	slog.Warn(fmt.Sprintf("⚠️  On-complete hook failed for %s: %v", outputPath, err),
+		"command", s.opts.OnComplete, "output", outputPath, "error", err)
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package transcription
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Statuses of a file in the batch journal
+const (
+	journalDone   = "done"
+	journalFailed = "failed"
+)
+
+// journalEntry is one line of the batch journal
+type journalEntry struct {
+	File   string    `json:"file"`
+	Status string    `json:"status"`
+	Output string    `json:"output,omitempty"`
+	Error  string    `json:"error,omitempty"`
+	Time   time.Time `json:"time"`
+}
+
+// journal records the outcome of every file of a batch in the cache
+// directory as it happens, so a batch that crashed or was interrupted can be
+// resumed with --resume. A nil journal records nothing.
+type journal struct {
+	path string
+	file *os.File
+	done map[string]bool // Absolute paths of the files already done
+}
+
+// journalPath is where the journal of a batch is kept. Batches are told
+// apart by their inputs and the options that decide what is written, so
+// runs over different folders or into different formats don't mix.
+func (s *Service) journalPath(inputs []string) string {
+	key := make([]string, 0, len(inputs))
+	for _, input := range inputs {
+		key = append(key, journalKey(input))
+	}
+	slices.Sort(key)
+
+	key = append(key, s.opts.Model, strings.Join(s.formats(), ","), journalKey(s.opts.OutputDir),
+		journalKey(s.opts.OutputPath))
+	sum := sha256.Sum256([]byte(strings.Join(key, "\n")))
+
+	return filepath.Join(s.opts.CacheDir, "journals", hex.EncodeToString(sum[:8])+".jsonl")
+}
+
+// journalKey identifies a file in the journal independent of the working
+// directory
+func journalKey(path string) string {
+	if path == "" || IsURL(path) {
+		return path
+	}
+
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+
+	return path
+}
+
+// loadJournal looks up the journal of a batch. With Resume the files an
+// earlier run finished are loaded, otherwise the batch starts over.
+func (s *Service) loadJournal(inputs []string) (*journal, error) {
+	if s.opts.CacheDir == "" {
+		return nil, nil
+	}
+
+	j := &journal{path: s.journalPath(inputs), done: make(map[string]bool)}
+	if !s.opts.Resume {
+		return j, nil
+	}
+
+	entries, err := readJournal(j.path)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(entries) == 0 {
+		slog.Info("📓 No unfinished run of these inputs to resume, starting from the beginning")
+	}
+
+	for _, entry := range entries {
+		j.done[entry.File] = entry.Status == journalDone
+	}
+
+	return j, nil
+}
+
+// open prepares the journal for recording. Resumed batches add to it, others
+// replace the journal of an earlier run.
+func (j *journal) open(resume bool) error {
+	if j == nil {
+		return nil
+	}
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_APPEND
+	if !resume {
+		if _, err := os.Stat(j.path); err == nil {
+			slog.Info("📓 Starting over, use --resume to continue the unfinished run of these inputs instead",
+				"journal", j.path)
+		}
+
+		flags = os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	}
+
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o755); err != nil {
+		return fmt.Errorf("cannot create journal directory: %w", err)
+	}
+
+	file, err := os.OpenFile(j.path, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("cannot open batch journal: %w", err)
+	}
+	j.file = file
+
+	return nil
+}
+
+// readJournal reads the entries of a journal, none if it doesn't exist. A
+// line cut short by a crash is ignored.
+func readJournal(path string) ([]journalEntry, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read batch journal: %w", err)
+	}
+	defer file.Close()
+
+	var entries []journalEntry
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry journalEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read batch journal %s: %w", path, err)
+	}
+
+	return entries, nil
+}
+
+// isDone reports whether an earlier run of the batch finished the file
+func (j *journal) isDone(file string) bool {
+	return j != nil && j.done[journalKey(file)]
+}
+
+// record adds the outcome of a file to the journal and syncs it to disk, so
+// it survives a crash right after
+func (j *journal) record(file, output string, err error) {
+	if j == nil || j.file == nil {
+		return
+	}
+
+	entry := journalEntry{File: journalKey(file), Status: journalDone, Output: output, Time: time.Now()}
+	if err != nil {
+		entry.Status = journalFailed
+		entry.Error = err.Error()
+	}
+
+	data, marshalErr := json.Marshal(entry)
+	if marshalErr != nil {
+		return
+	}
+
+	if _, writeErr := j.file.Write(append(data, '\n')); writeErr != nil {
+		slog.Warn(fmt.Sprintf("⚠️  Cannot write batch journal: %v", writeErr), "journal", j.path, "error", writeErr)
+		return
+	}
+
+	_ = j.file.Sync()
+}
+
+// close closes the journal. A batch that completed without failures removes
+// it, otherwise it's kept for --resume.
+func (j *journal) close(completed bool) {
+	if j == nil || j.file == nil {
+		return
+	}
+
+	j.file.Close()
+	j.file = nil
+
+	if !completed {
+		j.hint()
+		return
+	}
+
+	j.remove()
+}
+
+// hint tells how to continue the batch of the journal
+func (j *journal) hint() {
+	slog.Info("📓 Progress is saved, run the same command with --resume to skip the files already done",
+		"journal", j.path)
+}
+
+// remove deletes the journal of a batch that has nothing left to do
+func (j *journal) remove() {
+	if j == nil {
+		return
+	}
+
+	if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		slog.Debug(fmt.Sprintf("⚠️  Cannot remove batch journal: %v", err), "journal", j.path, "error", err)
+	}
+}
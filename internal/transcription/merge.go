@@ -0,0 +1,243 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// partNumberRegex matches the last number in a file name, e.g. the 2 in
+// "talk-part2" or "02 - Q&A"
+var partNumberRegex = regexp.MustCompile(`(\d+)\D*$`)
+
+// MergeFiles transcribes the inputs in the given order and writes a single
+// transcript to MergePath. Each file's timestamps are offset by the combined
+// duration of the files before it, so the result reads like one recording.
+func (s *Service) MergeFiles(inputs []string) error {
+	slog.Info(fmt.Sprintf("🎵 Ghospel v0.1.0 - Merging transcription with model: %s", s.opts.Model),
+		"model", s.opts.Model)
+
+	audioFiles, err := s.findAudioFiles(inputs)
+	if err != nil {
+		return fmt.Errorf("failed to find audio files: %w", err)
+	}
+
+	if len(audioFiles) == 0 {
+		return ErrNoAudioFiles
+	}
+
+	seen := make(map[string]bool)
+	for _, file := range audioFiles {
+		if seen[file] {
+			return fmt.Errorf("%s is given more than once", file)
+		}
+		seen[file] = true
+	}
+
+	checkPartOrder(audioFiles)
+
+	mergePath := s.availablePath(s.opts.MergePath)
+
+	if s.opts.DryRun {
+		fmt.Println("📋 Dry run - no files will be transcribed")
+		for _, file := range audioFiles {
+			fmt.Printf("   merge       %s\n", file)
+		}
+		fmt.Printf("   into        %s\n", mergePath)
+
+		return nil
+	}
+
+	if _, err := os.Stat(mergePath); err == nil && s.overwritePolicy() == OverwriteSkip {
+		slog.Info(fmt.Sprintf("✅ %s already exists! Use --force to merge again.", mergePath),
+			"output", mergePath)
+		return nil
+	}
+
+	if err := s.checkWhisper(); err != nil {
+		return err
+	}
+
+	if err := s.checkFFmpeg(audioFiles); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(mergePath), 0o755); err != nil {
+		return fmt.Errorf("cannot create output directory: %w", err)
+	}
+
+	if err := s.ensureModelDownloaded(); err != nil {
+		return fmt.Errorf("model preparation failed: %w", err)
+	}
+
+	ctx := context.Background()
+	if s.opts.BatchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.BatchTimeout)
+		defer cancel()
+	}
+
+	startTime := time.Now()
+	merged := &transcribe.Result{Segments: []transcribe.Segment{}}
+
+	var texts []string
+
+	for i, file := range audioFiles {
+		result, err := s.transcribePart(ctx, file)
+		if err != nil {
+			// A transcript with a part missing would have wrong timestamps
+			return &FileError{Path: file, Err: err}
+		}
+
+		for _, seg := range result.Segments {
+			seg.Start += merged.Duration
+			seg.End += merged.Duration
+
+			// Copy the words, they are shared with the part's result
+			seg.Words = slices.Clone(seg.Words)
+			for j := range seg.Words {
+				seg.Words[j].Start += merged.Duration
+				seg.Words[j].End += merged.Duration
+			}
+
+			merged.Segments = append(merged.Segments, seg)
+		}
+
+		if text := strings.TrimSpace(result.Text); text != "" {
+			texts = append(texts, text)
+		}
+
+		if merged.Language == "" {
+			merged.Language = result.Language
+		}
+
+		slog.Info(fmt.Sprintf("✅ [%d/%d] %s (%s, starts at %s)", i+1, len(audioFiles), filepath.Base(file),
+			result.Duration.Round(time.Second), formatClockTime(merged.Duration)),
+			"file", file, "duration", result.Duration, "offset", merged.Duration, "language", result.Language)
+
+		merged.Duration += result.Duration
+	}
+
+	merged.Text = strings.Join(texts, " ")
+	merged.Elapsed = time.Since(startTime)
+
+	content, err := s.formatOutput(merged, mergePath)
+	if err != nil {
+		return err
+	}
+
+	if err := s.writeOutput(mergePath, content); err != nil {
+		return err
+	}
+
+	if s.opts.SegmentOutput {
+		if err := s.writeSegments(merged, audioFiles[0], mergePath); err != nil {
+			return err
+		}
+	}
+
+	if err := s.runHook(audioFiles[0], mergePath); err != nil {
+		return err
+	}
+
+	wordCount := s.countWords(merged.Text, merged.Language)
+	slog.Info(fmt.Sprintf("🎉 Merged %d file(s) into %s (%d words, %s)", len(audioFiles), mergePath,
+		wordCount, merged.Duration.Round(time.Second)),
+		"files", len(audioFiles), "output", mergePath, "words", wordCount,
+		"duration", merged.Duration, "elapsed", merged.Elapsed)
+
+	return nil
+}
+
+// transcribePart transcribes one input of a merge, downloading remote inputs
+// first
+func (s *Service) transcribePart(batchCtx context.Context, inputPath string) (*transcribe.Result, error) {
+	ctx := batchCtx
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(batchCtx, s.opts.Timeout)
+		defer cancel()
+	}
+
+	sourcePath := inputPath
+	if IsURL(inputPath) {
+		localPath, err := s.downloadAudio(ctx, inputPath)
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(localPath)
+
+		sourcePath = localPath
+	}
+
+	slog.Debug(fmt.Sprintf("🔄 Transcribing %s...", filepath.Base(inputPath)), "file", inputPath)
+
+	result, err := s.transcribeWithRetry(ctx, sourcePath, s.transcribeOptions())
+	if err != nil {
+		switch {
+		case errors.Is(batchCtx.Err(), context.DeadlineExceeded):
+			return nil, ErrBatchTimeout
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, fmt.Errorf("%w after %s", ErrTimeout, s.opts.Timeout)
+		}
+
+		return nil, err
+	}
+
+	logAudioTrack(inputPath, result.AudioTrack)
+	logDiagnostics(inputPath, result.Diagnostics)
+
+	for _, wavPath := range result.WAVPaths {
+		slog.Info(fmt.Sprintf("🔊 Converted audio kept at %s", wavPath), "file", inputPath, "path", wavPath)
+	}
+
+	return result, nil
+}
+
+// checkPartOrder warns when numbered inputs are not in ascending order or
+// when numbers are skipped, which usually means a part is missing. Inputs
+// are still merged in the given order.
+func checkPartOrder(files []string) {
+	numbers := make([]int, len(files))
+	for i, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), filepath.Ext(file))
+
+		match := partNumberRegex.FindStringSubmatch(name)
+		if match == nil {
+			return
+		}
+
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return
+		}
+		numbers[i] = n
+	}
+
+	for i := 1; i < len(files); i++ {
+		if numbers[i] <= numbers[i-1] {
+			slog.Warn(fmt.Sprintf("⚠️  %s comes after %s, check the order of the parts",
+				filepath.Base(files[i]), filepath.Base(files[i-1])), "file", files[i], "previous", files[i-1])
+		}
+	}
+
+	sorted := slices.Clone(numbers)
+	slices.Sort(sorted)
+
+	for i := 1; i < len(sorted); i++ {
+		if sorted[i] > sorted[i-1]+1 {
+			slog.Warn(fmt.Sprintf("⚠️  No part numbered %d, a part may be missing", sorted[i-1]+1),
+				"after", sorted[i-1], "next", sorted[i])
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package transcription
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// isCJK reports whether r belongs to a script written without spaces
+// between words: Chinese characters and Japanese kana. Each of them counts
+// as a word and lines may break between them. Korean separates words with
+// spaces and is treated like other scripts.
+func isCJK(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana) || r == 'ー'
+}
+
+// isCJKPunct reports whether r is an ideographic or fullwidth punctuation
+// mark such as "。" or "！"
+func isCJKPunct(r rune) bool {
+	return (r >= 0x3000 && r <= 0x303F) || (r >= 0xFF01 && r <= 0xFF60 && unicode.IsPunct(r))
+}
+
+// isWide reports whether r takes two columns in a terminal or a monospaced
+// editor, as CJK characters and fullwidth forms do
+func isWide(r rune) bool {
+	return isCJK(r) || isCJKPunct(r) || unicode.Is(unicode.Hangul, r) ||
+		(r >= 0xFF01 && r <= 0xFF60) || (r >= 0xFFE0 && r <= 0xFFE6) // Fullwidth forms
+}
+
+// textWidth is the number of columns text takes
+func textWidth(text string) int {
+	width := 0
+	for _, r := range text {
+		width++
+		if isWide(r) {
+			width++
+		}
+	}
+
+	return width
+}
+
+// spacelessLanguages are written without spaces between words, so their
+// transcripts are counted by character
+var spacelessLanguages = []string{"zh", "ja", "yue", "th", "lo", "km", "my", "bo"}
+
+// spacelessScripts are the scripts of spacelessLanguages
+var spacelessScripts = []*unicode.RangeTable{
+	unicode.Han, unicode.Hiragana, unicode.Katakana,
+	unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar, unicode.Tibetan,
+}
+
+// wordCount counts the words of a text in a language such as "ja" or
+// "en-US". Languages written without spaces count every character as a
+// word, as word processors do, and Latin words mixed in as one each. Other
+// languages count what whitespace separates. Without a known language, e.g.
+// "auto", characters are counted in the scripts of spaceless languages.
+func wordCount(text, language string) int {
+	code, _, _ := strings.Cut(strings.ToLower(language), "-")
+	byCharacter := code == "" || code == "auto" || slices.Contains(spacelessLanguages, code)
+
+	count := 0
+	for _, field := range strings.Fields(text) {
+		inWord := false
+		for _, r := range field {
+			switch {
+			case byCharacter && (unicode.In(r, spacelessScripts...) || r == 'ー'):
+				// Vowel signs and tone marks belong to the character before
+				if !unicode.Is(unicode.Mn, r) {
+					count++
+				}
+				inWord = false
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				if !inWord {
+					count++
+					inWord = true
+				}
+			case !inWord && !unicode.IsPunct(r) && !unicode.Is(unicode.Mn, r):
+				// Symbols such as "&" count, like other fields
+				count++
+				inWord = true
+			}
+		}
+	}
+
+	return count
+}
+
+// breakablePieces splits a whitespace-separated field into the pieces a line
+// may break between: every CJK character is a piece, other text stays whole.
+// Punctuation stays with the piece before it and opening brackets with the
+// piece after them, so no line starts with "。" or ends with "「".
+func breakablePieces(field string) []string {
+	var pieces []string
+
+	start := 0
+	prev := utf8.RuneError
+
+	for i, r := range field {
+		breaks := i > 0 && !unicode.Is(unicode.Ps, prev) && !unicode.Is(unicode.Pi, prev) &&
+			(isCJK(r) || (isCJK(prev) && !unicode.IsPunct(r)) || (unicode.Is(unicode.Ps, r) && isCJK(prev)))
+		if breaks {
+			pieces = append(pieces, field[start:i])
+			start = i
+		}
+
+		prev = r
+	}
+
+	return append(pieces, field[start:])
+}
+
+// endsWithCJK reports whether text ends in a CJK character or punctuation,
+// after which no space separates the next sentence
+func endsWithCJK(text string) bool {
+	r, _ := utf8.DecodeLastRuneInString(text)
+	return isCJK(r) || isCJKPunct(r)
+}
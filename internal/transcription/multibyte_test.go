@@ -0,0 +1,58 @@
+package transcription
+
+import "testing"
+
+func TestWordCount(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		language string
+		want     int
+	}{
+		{"english", "Hello there, how are you?", "en", 5},
+		{"english region", "Hello there, how are you?", "en-US", 5},
+		{"english symbols", "One + one", "en", 3},
+		{"english contraction", "Don't stop", "en", 2},
+		{"japanese by character", "今日は晴れです。", "ja", 7},
+		{"japanese long vowel", "コーヒー", "ja", 4},
+		{"japanese with latin words", "私はGoが好きです", "ja", 8},
+		{"japanese as english", "今日は 晴れです", "en", 2},
+		{"auto counts japanese by character", "今日は晴れです。", "auto", 7},
+		{"empty language counts japanese by character", "今日は晴れです。", "", 7},
+		{"auto counts english by word", "Hello there, how are you?", "auto", 5},
+		{"thai", "สวัสดี", "th", 4},
+		{"empty text", "", "en", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wordCount(tt.text, tt.language); got != tt.want {
+				t.Errorf("wordCount(%q, %q) = %d, want %d", tt.text, tt.language, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestServiceCountWords(t *testing.T) {
+	tests := []struct {
+		name     string
+		selected string // Options.Language
+		detected string
+		want     int
+	}{
+		{"detected language wins", "en", "ja", 7},
+		{"selected language without detection", "ja", "", 7},
+		{"selected english without detection", "en", "", 1},
+		{"auto without detection", "auto", "", 7},
+		{"nothing known", "", "", 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{opts: Options{Language: tt.selected}}
+			if got := s.countWords("今日は晴れです。", tt.detected); got != tt.want {
+				t.Errorf("countWords with language %q, detected %q = %d, want %d", tt.selected, tt.detected, got, tt.want)
+			}
+		})
+	}
+}
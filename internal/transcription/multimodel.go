@@ -0,0 +1,108 @@
+package transcription
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pascalwhoop/ghospel/internal/models"
+)
+
+// newModelServices creates a service per model of a multi-model run
+func newModelServices(opts Options) []*Service {
+	services := make([]*Service, 0, len(opts.Models))
+
+	for _, model := range opts.Models {
+		modelOpts := opts
+		modelOpts.Model = model
+		modelOpts.Models = nil
+
+		service := NewService(modelOpts)
+		service.modelSuffix = true
+		services = append(services, service)
+	}
+
+	return services
+}
+
+// modelLabel is how a model is named in transcript file names: its registry
+// name, or the file name of a custom model without "ggml-" and ".bin"
+func modelLabel(model string) string {
+	if !models.IsCustomPath(model) {
+		return model
+	}
+
+	name := strings.TrimSuffix(filepath.Base(model), filepath.Ext(model))
+
+	return strings.TrimPrefix(name, "ggml-")
+}
+
+// transcribeModels transcribes the inputs with every model of a multi-model
+// run. Up to Workers models run at once, as long as the memory they are
+// estimated to need fits the memory budget; a model that needs more than the
+// budget runs alone. Every model gets through the whole batch even if
+// another one fails.
+func (s *Service) transcribeModels(inputs []string) error {
+	if err := s.checkWhisper(); err != nil {
+		return err
+	}
+
+	needs := make([]int64, len(s.modelServices))
+	for i, service := range s.modelServices {
+		size, err := s.prepareModel(service.opts.Model)
+		if err != nil {
+			return err
+		}
+
+		needs[i] = models.MemoryNeeded(size)
+	}
+
+	workers := max(s.opts.Workers, 1)
+	budget, limited := models.MemoryBudget()
+
+	slog.Info(fmt.Sprintf("🧪 Transcribing with %d models: %s (up to %d at once as memory allows)",
+		len(s.modelServices), strings.Join(s.opts.Models, ", "), workers),
+		"models", s.opts.Models, "workers", workers)
+
+	var (
+		mu      sync.Mutex
+		cond    = sync.NewCond(&mu)
+		running int
+		used    int64
+		wg      sync.WaitGroup
+		errs    = make([]error, len(s.modelServices))
+	)
+
+	for i, service := range s.modelServices {
+		// Wait for a free worker and enough memory, or an idle machine
+		mu.Lock()
+		for running > 0 && (running >= workers || (limited && used+needs[i] > budget)) {
+			cond.Wait()
+		}
+		running++
+		used += needs[i]
+		mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := service.TranscribeFiles(inputs); err != nil {
+				errs[i] = fmt.Errorf("model %s: %w", service.opts.Model, err)
+			}
+
+			mu.Lock()
+			running--
+			used -= needs[i]
+			cond.Broadcast()
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,373 @@
+package transcription
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// textNormalizers convert spelled-out numbers, currencies, percentages and
+// units to their written form, by transcript language
+var textNormalizers = map[string]func(string) string{
+	"en": normalizeEnglish,
+}
+
+// normalizerFor returns the text normalizer for a language code such as
+// "en" or "en-US"
+func normalizerFor(language string) (func(string) string, bool) {
+	code, _, _ := strings.Cut(strings.ToLower(language), "-")
+	normalize, ok := textNormalizers[code]
+
+	return normalize, ok
+}
+
+// English number words
+var (
+	englishUnits = map[string]int64{
+		"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7,
+		"eight": 8, "nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13,
+		"fourteen": 14, "fifteen": 15, "sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19,
+	}
+	englishTens = map[string]int64{
+		"twenty": 20, "thirty": 30, "forty": 40, "fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+	}
+	englishScales = map[string]int64{
+		"thousand": 1_000, "million": 1_000_000, "billion": 1_000_000_000, "trillion": 1_000_000_000_000,
+	}
+	englishCurrencies = map[string]string{
+		"dollar": "$", "dollars": "$", "euro": "€", "euros": "€",
+	}
+
+	// englishUnitWords maps unit names, possibly spanning several words, to
+	// their symbol. Longer names are tried first.
+	englishUnitWords = []struct {
+		words  []string
+		symbol string
+		space  bool // Separate number and symbol with a space
+	}{
+		{[]string{"kilometers", "per", "hour"}, "km/h", true},
+		{[]string{"kilometres", "per", "hour"}, "km/h", true},
+		{[]string{"miles", "per", "hour"}, "mph", true},
+		{[]string{"degrees", "celsius"}, "°C", false},
+		{[]string{"degrees", "fahrenheit"}, "°F", false},
+		{[]string{"per", "cent"}, "%", false},
+		{[]string{"percent"}, "%", false},
+		{[]string{"kilometers"}, "km", true}, {[]string{"kilometres"}, "km", true},
+		{[]string{"kilometer"}, "km", true}, {[]string{"kilometre"}, "km", true},
+		{[]string{"centimeters"}, "cm", true}, {[]string{"centimetres"}, "cm", true},
+		{[]string{"millimeters"}, "mm", true}, {[]string{"millimetres"}, "mm", true},
+		{[]string{"meters"}, "m", true}, {[]string{"metres"}, "m", true},
+		{[]string{"kilograms"}, "kg", true}, {[]string{"kilogram"}, "kg", true},
+		{[]string{"grams"}, "g", true},
+		{[]string{"milliseconds"}, "ms", true},
+		{[]string{"kilobytes"}, "KB", true}, {[]string{"megabytes"}, "MB", true},
+		{[]string{"gigabytes"}, "GB", true}, {[]string{"terabytes"}, "TB", true},
+		{[]string{"hertz"}, "Hz", true}, {[]string{"kilohertz"}, "kHz", true},
+		{[]string{"megahertz"}, "MHz", true}, {[]string{"gigahertz"}, "GHz", true},
+	}
+)
+
+// wordTokenRegex matches words, including hyphenated ones, and numbers
+// written in digits
+var wordTokenRegex = regexp.MustCompile(`\d+(?:,\d{3})*(?:\.\d+)?|\pL+(?:['-]\pL+)*`)
+
+// digitsRegex matches a number written in digits
+var digitsRegex = regexp.MustCompile(`^\d+(?:,\d{3})*(?:\.\d+)?$`)
+
+// token is a word of the text and its position
+type token struct {
+	text       string
+	start, end int
+}
+
+// normalizeEnglish rewrites spelled-out English numbers as digits ("one
+// hundred and five" becomes "105", "twenty twenty four" becomes "2024") and
+// shortens currencies, percentages and units that follow a number ("five
+// percent" becomes "5%"). Single numbers below ten are kept as words unless
+// a unit follows, as style guides recommend and so "no one" stays intact.
+func normalizeEnglish(text string) string {
+	var tokens []token
+	for _, loc := range wordTokenRegex.FindAllStringIndex(text, -1) {
+		tokens = append(tokens, token{text: text[loc[0]:loc[1]], start: loc[0], end: loc[1]})
+	}
+
+	// adjacent reports whether tokens i and i+1 are only separated by spaces
+	adjacent := func(i int) bool {
+		return i+1 < len(tokens) && strings.TrimSpace(text[tokens[i].end:tokens[i+1].start]) == ""
+	}
+
+	var out strings.Builder
+
+	last := 0
+	for i := 0; i < len(tokens); {
+		digits, n, spelled := parseEnglishNumber(tokens[i:], adjacent, i)
+		if n == 0 {
+			i++
+			continue
+		}
+
+		written, m := englishSuffix(digits, tokens[i+n-1:], adjacent, i+n-1)
+		n += m
+
+		// Keep small numbers verbatim unless a unit made them a quantity
+		if m == 0 && spelled && len(digits) == 1 && n == 1 {
+			i++
+			continue
+		}
+
+		out.WriteString(text[last:tokens[i].start])
+		out.WriteString(written)
+		last = tokens[i+n-1].end
+		i += n
+	}
+
+	out.WriteString(text[last:])
+
+	return out.String()
+}
+
+// parseEnglishNumber parses the number starting at the first token. It
+// returns the number in digits, how many tokens it spans and whether it was
+// spelled out; n is 0 if the tokens don't start with a number. offset is the
+// index of the first token for adjacent.
+func parseEnglishNumber(tokens []token, adjacent func(int) bool, offset int) (string, int, bool) {
+	if digitsRegex.MatchString(tokens[0].text) {
+		return tokens[0].text, 1, false
+	}
+
+	value, n := parseEnglishCardinal(tokens, adjacent, offset)
+	if n == 0 {
+		return "", 0, false
+	}
+
+	digits := formatNumber(value)
+
+	// "two thousand and five" is read as a year more often than not
+	if value >= 2000 && value < 2100 {
+		digits = strconv.FormatInt(value, 10)
+	}
+
+	// Years are read as two pairs: "nineteen ninety nine", "twenty twenty"
+	if value >= 10 && value < 100 && adjacent(offset+n-1) {
+		next, m := parseEnglishCardinal(tokens[n:], adjacent, offset+n)
+		if m > 0 && next >= 10 && next < 100 && !isScaleWord(tokens[n:n+m]) {
+			return strconv.FormatInt(value*100+next, 10), n + m, true
+		}
+	}
+
+	// Decimals are read digit by digit: "three point one four"
+	if n+1 < len(tokens) && strings.EqualFold(tokens[n].text, "point") && adjacent(offset+n-1) && adjacent(offset+n) {
+		var decimals strings.Builder
+
+		m := n + 1
+		for m < len(tokens) {
+			d, ok := englishUnits[strings.ToLower(tokens[m].text)]
+			if !ok || d > 9 {
+				break
+			}
+
+			decimals.WriteString(strconv.FormatInt(d, 10))
+			m++
+
+			if !adjacent(offset + m - 1) {
+				break
+			}
+		}
+
+		if decimals.Len() > 0 {
+			return strconv.FormatInt(value, 10) + "." + decimals.String(), m, true
+		}
+	}
+
+	return digits, n, true
+}
+
+// isScaleWord reports whether any of the tokens is hundred or a larger scale
+func isScaleWord(tokens []token) bool {
+	for _, t := range tokens {
+		word := strings.ToLower(t.text)
+		if _, ok := englishScales[word]; ok || word == "hundred" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseEnglishCardinal parses a spelled-out cardinal number such as "two
+// thousand and twenty four" or "ninety-nine". It stops where a new number
+// would start, e.g. between "twenty" and "twenty four".
+func parseEnglishCardinal(tokens []token, adjacent func(int) bool, offset int) (int64, int) {
+	var total, current int64
+
+	// What the previous word was, to tell where a number ends
+	const (
+		none = iota
+		unit
+		tens
+		hundred
+		scale
+	)
+
+	prev := none
+	n := 0
+
+	for i := 0; i < len(tokens); i++ {
+		if i > 0 && !adjacent(offset+i-1) {
+			break
+		}
+
+		// "ninety-nine" is one word
+		parts := strings.Split(strings.ToLower(tokens[i].text), "-")
+		if len(parts) > 2 {
+			break
+		}
+
+		word := parts[0]
+		if len(parts) == 2 {
+			t, ok := englishTens[parts[0]]
+			u, ok2 := englishUnits[parts[1]]
+			if !ok || !ok2 || u == 0 || u > 9 || (prev != none && prev != hundred && prev != scale) {
+				break
+			}
+
+			current += t + u
+			prev = unit
+			n = i + 1
+
+			continue
+		}
+
+		if v, ok := englishUnits[word]; ok {
+			// "twenty four" continues, "five six" starts a new number
+			if prev == unit || (prev == tens && v > 9) || (prev == tens && v == 0) {
+				break
+			}
+
+			current += v
+			prev = unit
+			n = i + 1
+
+			continue
+		}
+
+		if v, ok := englishTens[word]; ok {
+			if prev == unit || prev == tens {
+				break
+			}
+
+			current += v
+			prev = tens
+			n = i + 1
+
+			continue
+		}
+
+		if word == "hundred" {
+			if (prev != unit && prev != tens) || current == 0 || current >= 100 {
+				break
+			}
+
+			current *= 100
+			prev = hundred
+			n = i + 1
+
+			continue
+		}
+
+		if v, ok := englishScales[word]; ok {
+			if prev == none || prev == scale || current == 0 {
+				break
+			}
+
+			total += current * v
+			current = 0
+			prev = scale
+			n = i + 1
+
+			continue
+		}
+
+		// "one hundred and five", only when a number follows
+		if word == "and" && (prev == hundred || prev == scale) && i+1 < len(tokens) && adjacent(offset+i) {
+			next := strings.ToLower(strings.Split(tokens[i+1].text, "-")[0])
+			_, isUnit := englishUnits[next]
+			_, isTens := englishTens[next]
+			if isUnit || isTens {
+				continue
+			}
+		}
+
+		break
+	}
+
+	if n == 0 {
+		return 0, 0
+	}
+
+	return total + current, n
+}
+
+// englishSuffix applies a currency, percentage or unit following the number
+// in the first token. It returns the written number and how many tokens after
+// the first were consumed.
+func englishSuffix(digits string, tokens []token, adjacent func(int) bool, offset int) (string, int) {
+	word := func(i int) string {
+		if i >= len(tokens) || !adjacent(offset+i-1) {
+			return ""
+		}
+
+		return strings.ToLower(tokens[i].text)
+	}
+
+	if symbol, ok := englishCurrencies[word(1)]; ok {
+		// "five dollars and fifty cents"
+		if word(2) == "and" && word(3) != "" && !strings.Contains(digits, ".") {
+			cents, m, _ := parseEnglishNumber(tokens[3:], adjacent, offset+3)
+			if m > 0 && word(3+m) == "cents" && len(cents) <= 2 && !strings.Contains(cents, ".") {
+				return symbol + digits + "." + strings.Repeat("0", 2-len(cents)) + cents, 3 + m
+			}
+		}
+
+		return symbol + digits, 1
+	}
+
+	for _, unit := range englishUnitWords {
+		matches := true
+		for j, w := range unit.words {
+			if word(1+j) != w {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			if unit.space {
+				return digits + " " + unit.symbol, len(unit.words)
+			}
+
+			return digits + unit.symbol, len(unit.words)
+		}
+	}
+
+	return digits, 0
+}
+
+// formatNumber writes a number in digits, grouping thousands with commas
+// from 1,000 up. Years don't go through it, see parseEnglishNumber.
+func formatNumber(value int64) string {
+	digits := strconv.FormatInt(value, 10)
+	if value < 1_000 {
+		return digits
+	}
+
+	var grouped strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			grouped.WriteByte(',')
+		}
+		grouped.WriteRune(d)
+	}
+
+	return grouped.String()
+}
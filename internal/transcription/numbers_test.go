@@ -0,0 +1,58 @@
+package transcription
+
+import "testing"
+
+func TestNormalizeEnglish(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"hundred and", "It cost one hundred and five.", "It cost 105."},
+		{"hyphenated tens", "She is ninety-nine years old.", "She is 99 years old."},
+		{"small numbers stay words", "No one saw the three of them.", "No one saw the three of them."},
+		{"small number with unit", "It is five percent.", "It is 5%."},
+		{"thousands are grouped", "We counted one thousand two hundred birds.", "We counted 1,200 birds."},
+		{"grouped like larger thousands", "About one hundred and five thousand people came.", "About 105,000 people came."},
+		{"millions", "It sold two million copies.", "It sold 2,000,000 copies."},
+		{"year in pairs", "Back in nineteen ninety nine we met.", "Back in 1999 we met."},
+		{"year in pairs, recent", "See you in twenty twenty four.", "See you in 2024."},
+		{"year as a cardinal", "It happened in two thousand and five.", "It happened in 2005."},
+		{"decimals", "Pi is three point one four.", "Pi is 3.14."},
+		{"currency with cents", "That is five dollars and fifty cents.", "That is $5.50."},
+		{"currency", "It costs twenty euros.", "It costs €20."},
+		{"unit with space", "We drove sixty kilometers per hour.", "We drove 60 km/h."},
+		{"temperature", "It was thirty degrees celsius.", "It was 30°C."},
+		{"digits with a unit", "Only 40 percent agreed.", "Only 40% agreed."},
+		{"separate numbers", "Pick twenty or thirty.", "Pick 20 or 30."},
+		{"no numbers", "Nothing to see here.", "Nothing to see here."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeEnglish(tt.text); got != tt.want {
+				t.Errorf("normalizeEnglish(%q)\n got %q\nwant %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatNumber(t *testing.T) {
+	tests := []struct {
+		value int64
+		want  string
+	}{
+		{0, "0"},
+		{999, "999"},
+		{1_000, "1,000"},
+		{1_200, "1,200"},
+		{105_000, "105,000"},
+		{1_234_567, "1,234,567"},
+	}
+
+	for _, tt := range tests {
+		if got := formatNumber(tt.value); got != tt.want {
+			t.Errorf("formatNumber(%d) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package transcription
+
+import (
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// Observer is told how a batch started with TranscribeFiles progresses, so
+// frontends such as a progress bar or a GUI can present it without parsing
+// log output. Calls come from the goroutine running the batch, one file
+// after another; the batch waits for them, so they should return quickly.
+// Embed NopObserver to implement only some of the methods.
+type Observer interface {
+	// OnBatchStart is called once the files to transcribe are known, in the
+	// order they are transcribed. skipped counts the files left alone, e.g.
+	// because they are transcribed already.
+	OnBatchStart(files []string, skipped int)
+
+	// OnFileStart is called before a file is transcribed, index counts from 1
+	OnFileStart(file string, index, total int)
+
+	// OnSegment is called for every segment of a file's final transcript,
+	// in order, once the file is transcribed and before OnFileDone
+	OnSegment(file string, segment transcribe.Segment)
+
+	// OnFileDone is called when the transcript of a file was written
+	OnFileDone(file string, stats *FileStats)
+
+	// OnError is called when a file could not be transcribed
+	OnError(file string, err error)
+
+	// OnBatchDone is called with the summary of the batch when it ends
+	OnBatchDone(report *Report)
+}
+
+// NopObserver ignores every call, to be embedded by observers that only
+// need some of them
+type NopObserver struct{}
+
+func (NopObserver) OnBatchStart([]string, int)           {}
+func (NopObserver) OnFileStart(string, int, int)         {}
+func (NopObserver) OnSegment(string, transcribe.Segment) {}
+func (NopObserver) OnFileDone(string, *FileStats)        {}
+func (NopObserver) OnError(string, error)                {}
+func (NopObserver) OnBatchDone(*Report)                  {}
+
+// AddObserver registers an observer for the batches of the service. Add
+// observers before starting a batch; a multi-model run passes them on to
+// the batch of every model.
+func (s *Service) AddObserver(observer Observer) {
+	s.observers = append(s.observers, observer)
+
+	for _, modelService := range s.modelServices {
+		modelService.AddObserver(observer)
+	}
+}
+
+// observerList passes every call on to each of its observers
+type observerList []Observer
+
+func (l observerList) OnBatchStart(files []string, skipped int) {
+	for _, o := range l {
+		o.OnBatchStart(files, skipped)
+	}
+}
+
+func (l observerList) OnFileStart(file string, index, total int) {
+	for _, o := range l {
+		o.OnFileStart(file, index, total)
+	}
+}
+
+func (l observerList) OnSegment(file string, segment transcribe.Segment) {
+	for _, o := range l {
+		o.OnSegment(file, segment)
+	}
+}
+
+func (l observerList) OnFileDone(file string, stats *FileStats) {
+	for _, o := range l {
+		o.OnFileDone(file, stats)
+	}
+}
+
+func (l observerList) OnError(file string, err error) {
+	for _, o := range l {
+		o.OnError(file, err)
+	}
+}
+
+func (l observerList) OnBatchDone(report *Report) {
+	for _, o := range l {
+		o.OnBatchDone(report)
+	}
+}
+
+// observedSegments are the segments observers get for a transcribed file:
+// those of the final transcript, after post-processing. Without observers
+// the work is skipped.
+func (s *Service) observedSegments(result *transcribe.Result) []transcribe.Segment {
+	if len(s.observers) == 0 {
+		return nil
+	}
+
+	processed, err := s.postProcess(result)
+	if err != nil {
+		return nil
+	}
+
+	return processed.Segments
+}
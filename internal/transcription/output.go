@@ -0,0 +1,376 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// ValidFormats lists the supported output formats
+var ValidFormats = []string{"txt", "md", "srt", "vtt", "json"}
+
+// Render renders a transcription result in the configured output format,
+// as it would be written to the transcript file of inputPath
+func (s *Service) Render(result *transcribe.Result, inputPath string) (string, error) {
+	return s.formatOutput(result, inputPath)
+}
+
+// ContentType returns the MIME type of an output format
+func ContentType(format string) string {
+	switch strings.ToLower(format) {
+	case "md":
+		return "text/markdown; charset=utf-8"
+	case "srt":
+		return "application/x-subrip; charset=utf-8"
+	case "vtt":
+		return "text/vtt; charset=utf-8"
+	case "json":
+		return "application/json"
+	default:
+		return "text/plain; charset=utf-8"
+	}
+}
+
+// formatOutput renders the transcription in the configured output format
+func (s *Service) formatOutput(result *transcribe.Result, inputPath string) (string, error) {
+	return s.formatOutputAs(result, inputPath, s.opts.Format)
+}
+
+// formatOutputAs renders the transcription in one of the output formats
+func (s *Service) formatOutputAs(result *transcribe.Result, inputPath, format string) (string, error) {
+	result, err := s.postProcess(result)
+	if err != nil {
+		return "", err
+	}
+
+	switch strings.ToLower(format) {
+	case "md":
+		return s.formatMarkdown(result, inputPath)
+	case "srt":
+		if native, ok := s.nativeSubtitles(result, inputPath, "srt"); ok {
+			return native, nil
+		}
+
+		return formatSRT(splitCues(result.Segments, s.opts.Subtitles)), nil
+	case "vtt":
+		if native, ok := s.nativeSubtitles(result, inputPath, "vtt"); ok {
+			return native, nil
+		}
+
+		return formatVTT(splitCues(result.Segments, s.opts.Subtitles)), nil
+	case "json":
+		return s.formatJSON(result, inputPath)
+	default:
+		return s.formatText(result, inputPath)
+	}
+}
+
+// formatText renders the plain-text transcript with a comment header
+func (s *Service) formatText(result *transcribe.Result, inputPath string) (string, error) {
+	var content strings.Builder
+
+	if s.opts.FrontMatter {
+		// A YAML block replaces the comment header for static-site generators
+		header, err := s.buildFrontMatter(result, inputPath)
+		if err != nil {
+			return "", err
+		}
+
+		content.WriteString(header)
+	} else {
+		header, err := s.buildHeader(result, inputPath)
+		if err != nil {
+			return "", err
+		}
+
+		content.WriteString(header)
+	}
+
+	// Add the formatted transcription
+	content.WriteString(wrapText(s.formatParagraphs(result, "[%s]", "%s:"), s.opts.Wrap))
+	content.WriteString("\n")
+
+	return content.String(), nil
+}
+
+// formatMarkdown renders the transcript as Markdown with the title as H1
+func (s *Service) formatMarkdown(result *transcribe.Result, inputPath string) (string, error) {
+	var content strings.Builder
+
+	if s.opts.FrontMatter {
+		header, err := s.buildFrontMatter(result, inputPath)
+		if err != nil {
+			return "", err
+		}
+
+		content.WriteString(header)
+	}
+
+	fmt.Fprintf(&content, "# %s\n\n", titleFromPath(inputPath))
+
+	// Front matter already carries the metadata
+	if !s.opts.FrontMatter && !s.opts.NoHeader {
+		fmt.Fprintf(&content, "*Source: %s · Model: %s · Language: %s · Duration: %s*\n\n",
+			filepath.Base(inputPath), s.opts.Model, result.Language, result.Duration.Round(time.Second))
+	}
+
+	content.WriteString(wrapText(s.formatParagraphs(result, "**[%s]**", "**%s:**"), s.opts.Wrap))
+	content.WriteString("\n")
+
+	return content.String(), nil
+}
+
+// formatParagraphs formats the transcription into readable paragraphs unless
+// the raw whisper text was requested for external post-processing. With
+// timestamps enabled each paragraph is prefixed with its start time, rendered
+// through markerFormat. Diarized or channel-split transcripts are split into
+// speaker turns labelled through speakerFormat.
+func (s *Service) formatParagraphs(result *transcribe.Result, markerFormat, speakerFormat string) string {
+	if s.opts.NoFormat {
+		return result.Text
+	}
+
+	if s.opts.Diarize || s.opts.SplitChannels {
+		return s.formatSpeakerTurns(result.Segments, result.Language, markerFormat, speakerFormat)
+	}
+
+	formatter := NewTextFormatter(s.opts.Formatter).WithLanguage(result.Language)
+	formattedText := formatter.Format(result.Text)
+
+	if !s.opts.Timestamps || formattedText == "" {
+		return formattedText
+	}
+
+	paragraphs := strings.Split(formattedText, "\n\n")
+	starts := paragraphStartTimes(paragraphs, result.Segments)
+
+	for i, paragraph := range paragraphs {
+		marker := fmt.Sprintf(markerFormat, formatClockTime(starts[i]))
+		paragraphs[i] = marker + " " + paragraph
+	}
+
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// formatSpeakerTurns groups consecutive segments of the same speaker into a
+// turn, formats each turn into paragraphs and prefixes it with the speaker
+func (s *Service) formatSpeakerTurns(segments []transcribe.Segment, language, markerFormat, speakerFormat string) string {
+	formatter := NewTextFormatter(s.opts.Formatter).WithLanguage(language)
+
+	var turns []string
+
+	for start := 0; start < len(segments); {
+		end := start
+		texts := []string{}
+
+		for end < len(segments) && segments[end].Speaker == segments[start].Speaker {
+			texts = append(texts, segments[end].Text)
+			end++
+		}
+
+		turn := fmt.Sprintf(speakerFormat, segments[start].Speaker) + " " + formatter.Format(strings.Join(texts, " "))
+		if s.opts.Timestamps {
+			turn = fmt.Sprintf(markerFormat, formatClockTime(segments[start].Start)) + " " + turn
+		}
+
+		turns = append(turns, turn)
+		start = end
+	}
+
+	return strings.Join(turns, "\n\n")
+}
+
+// paragraphStartTimes maps each paragraph to the start time of the segment
+// holding its first word. The formatter only regroups words, so counting
+// words across paragraphs and segments lines them up closely enough.
+func paragraphStartTimes(paragraphs []string, segments []transcribe.Segment) []time.Duration {
+	starts := make([]time.Duration, len(paragraphs))
+
+	segmentIndex := 0
+	segmentWordEnd := 0
+	wordOffset := 0
+
+	if len(segments) > 0 {
+		segmentWordEnd = wordCount(segments[0].Text, "")
+	}
+
+	for i, paragraph := range paragraphs {
+		// Advance to the segment containing the paragraph's first word
+		for segmentIndex < len(segments)-1 && wordOffset >= segmentWordEnd {
+			segmentIndex++
+			segmentWordEnd += wordCount(segments[segmentIndex].Text, "")
+		}
+
+		if segmentIndex < len(segments) {
+			starts[i] = segments[segmentIndex].Start
+		}
+
+		wordOffset += wordCount(paragraph, "")
+	}
+
+	return starts
+}
+
+// nativeSubtitles returns whisper's own subtitle file in the format when
+// NativeSubtitles asked for it. Whisper doesn't write them when its
+// timeline differs from the segments', e.g. with --start or --vad, in which
+// case they are rendered from the segments instead.
+func (s *Service) nativeSubtitles(result *transcribe.Result, inputPath, format string) (string, bool) {
+	if !s.opts.NativeSubtitles {
+		return "", false
+	}
+
+	native, ok := result.NativeSubtitles[format]
+	if !ok {
+		slog.Debug(fmt.Sprintf("🎞️  No %s from whisper for %s, rendering it from the segments", format,
+			filepath.Base(inputPath)), "file", inputPath, "format", format)
+	}
+
+	return native, ok
+}
+
+// formatSRT renders segments as SubRip subtitles
+func formatSRT(segments []transcribe.Segment) string {
+	var content strings.Builder
+
+	for i, seg := range segments {
+		text := seg.Text
+		if seg.Speaker != "" {
+			text = seg.Speaker + ": " + text
+		}
+
+		fmt.Fprintf(&content, "%d\n%s --> %s\n%s\n\n",
+			i+1, formatSubtitleTime(seg.Start, ","), formatSubtitleTime(seg.End, ","), text)
+	}
+
+	return content.String()
+}
+
+// formatVTT renders segments as WebVTT subtitles
+func formatVTT(segments []transcribe.Segment) string {
+	var content strings.Builder
+
+	content.WriteString("WEBVTT\n\n")
+
+	for _, seg := range segments {
+		text := seg.Text
+		if len(seg.Words) > 0 {
+			text = vttWordText(seg.Words)
+		}
+
+		if seg.Speaker != "" {
+			// WebVTT voice span, players can style or show it per speaker
+			text = fmt.Sprintf("<v %s>%s", seg.Speaker, text)
+		}
+
+		fmt.Fprintf(&content, "%s --> %s\n%s\n\n",
+			formatSubtitleTime(seg.Start, "."), formatSubtitleTime(seg.End, "."), text)
+	}
+
+	return content.String()
+}
+
+// vttWordText renders words with WebVTT inline timestamps so players can
+// highlight each word as it is spoken (karaoke-style captions)
+func vttWordText(words []transcribe.Word) string {
+	var text strings.Builder
+
+	for i, word := range words {
+		if i > 0 {
+			fmt.Fprintf(&text, " <%s>", formatSubtitleTime(word.Start, "."))
+		}
+
+		text.WriteString(word.Text)
+	}
+
+	return text.String()
+}
+
+// jsonTranscript is the document written by the json output format
+type jsonTranscript struct {
+	Source   string        `json:"source"`
+	Model    string        `json:"model"`
+	Language string        `json:"language,omitempty"`
+	Duration float64       `json:"duration"`
+	Text     string        `json:"text"`
+	Segments []jsonSegment `json:"segments"`
+}
+
+// jsonSegment is a timed segment in the json output, times are in seconds
+type jsonSegment struct {
+	Start   float64    `json:"start"`
+	End     float64    `json:"end"`
+	Text    string     `json:"text"`
+	Speaker string     `json:"speaker,omitempty"`
+	Words   []jsonWord `json:"words,omitempty"`
+}
+
+// jsonWord is a single timed word in the json output
+type jsonWord struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// formatJSON renders the transcript and its segments as JSON
+func (s *Service) formatJSON(result *transcribe.Result, inputPath string) (string, error) {
+	doc := jsonTranscript{
+		Source:   filepath.Base(inputPath),
+		Model:    s.opts.Model,
+		Language: result.Language,
+		Duration: result.Duration.Seconds(),
+		Text:     result.Text,
+		Segments: jsonSegments(result.Segments),
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode transcript: %w", err)
+	}
+
+	return string(data) + "\n", nil
+}
+
+// jsonSegments converts segments to their json output form
+func jsonSegments(segments []transcribe.Segment) []jsonSegment {
+	converted := make([]jsonSegment, len(segments))
+
+	for i, seg := range segments {
+		converted[i] = jsonSegment{
+			Start:   seg.Start.Seconds(),
+			End:     seg.End.Seconds(),
+			Text:    seg.Text,
+			Speaker: seg.Speaker,
+		}
+
+		for _, word := range seg.Words {
+			converted[i].Words = append(converted[i].Words, jsonWord{
+				Start: word.Start.Seconds(),
+				End:   word.End.Seconds(),
+				Text:  word.Text,
+			})
+		}
+	}
+
+	return converted
+}
+
+// formatSubtitleTime formats a duration as HH:MM:SS<sep>mmm
+func formatSubtitleTime(d time.Duration, sep string) string {
+	millis := d.Milliseconds()
+
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d",
+		millis/3600000, (millis/60000)%60, (millis/1000)%60, sep, millis%1000)
+}
+
+// formatClockTime formats a duration as HH:MM:SS for inline markers
+func formatClockTime(d time.Duration) string {
+	seconds := int64(d / time.Second)
+
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, (seconds/60)%60, seconds%60)
+}
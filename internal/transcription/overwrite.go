@@ -0,0 +1,59 @@
+package transcription
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Policies accepted by --overwrite-policy for inputs that already have a
+// transcript
+const (
+	OverwriteSkip      = "skip"
+	OverwriteOverwrite = "overwrite"
+	OverwriteRename    = "rename"
+)
+
+// OverwritePolicies lists the values accepted by --overwrite-policy
+var OverwritePolicies = []string{OverwriteSkip, OverwriteOverwrite, OverwriteRename}
+
+// overwritePolicy is the policy in effect, Force counts as overwrite
+func (s *Service) overwritePolicy() string {
+	switch {
+	case s.opts.Force:
+		return OverwriteOverwrite
+	case s.opts.OverwritePolicy == "":
+		return OverwriteSkip
+	default:
+		return s.opts.OverwritePolicy
+	}
+}
+
+// availablePath returns path unchanged, or with the rename policy the first
+// of path, "name-2.ext", "name-3.ext", ... that no transcript uses yet
+func (s *Service) availablePath(path string) string {
+	if s.opts.Stdout || s.overwritePolicy() != OverwriteRename {
+		return path
+	}
+
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+
+	candidate := path
+	for n := 2; s.outputExists(candidate); n++ {
+		candidate = fmt.Sprintf("%s-%d%s", base, n, ext)
+	}
+
+	return candidate
+}
+
+// outputExists reports whether a transcript was written to path, or to
+// chapter transcripts named after it with --split-chapters
+func (s *Service) outputExists(path string) bool {
+	if _, err := os.Lstat(path); err == nil {
+		return true
+	}
+
+	return s.opts.SplitChapters && hasChapterOutputs(path)
+}
@@ -0,0 +1,88 @@
+package transcription
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// postProcess returns a copy of the result with the transcript, segments and
+// words passed through the configured text fixes: the repeat guard first, so
+// the other fixes don't work on loops, punctuation repair, which needs the
+// segment timing, number normalization,
+// then the replacement file, so it can still fix normalized text, and the
+// censor last so nothing reintroduces a masked word
+func (s *Service) postProcess(result *transcribe.Result) (*transcribe.Result, error) {
+	if s.opts.RepeatGuard {
+		similarity := s.opts.RepeatSimilarity
+		if similarity == 0 {
+			similarity = DefaultRepeatSimilarity
+		}
+
+		result = collapseRepeats(result, similarity)
+	}
+
+	if s.opts.RepairPunctuation {
+		result = repairPunctuation(result)
+	}
+
+	var fixes []func(string) string
+
+	if s.opts.NormalizeText {
+		if normalize, ok := normalizerFor(result.Language); ok {
+			fixes = append(fixes, normalize)
+		} else {
+			slog.Debug(fmt.Sprintf("🔢 No text normalization for language %q, keeping numbers verbatim", result.Language),
+				"language", result.Language)
+		}
+	}
+
+	if s.opts.ReplaceFile != "" {
+		replacer, err := LoadReplacer(s.opts.ReplaceFile)
+		if err != nil {
+			return nil, err
+		}
+
+		fixes = append(fixes, replacer.Apply)
+	}
+
+	if s.opts.Censor {
+		censor, err := NewCensor(s.opts.CensorWords)
+		if err != nil {
+			return nil, err
+		}
+
+		fixes = append(fixes, censor.Apply)
+	}
+
+	if len(fixes) == 0 {
+		return result, nil
+	}
+
+	apply := func(text string) string {
+		for _, fix := range fixes {
+			text = fix(text)
+		}
+
+		return text
+	}
+
+	fixed := *result
+	fixed.Text = apply(result.Text)
+	fixed.Segments = make([]transcribe.Segment, len(result.Segments))
+
+	for i, seg := range result.Segments {
+		seg.Text = apply(seg.Text)
+
+		seg.Words = slices.Clone(seg.Words)
+		for j := range seg.Words {
+			seg.Words[j].Text = apply(seg.Words[j].Text)
+		}
+
+		fixed.Segments[i] = seg
+	}
+
+	return &fixed, nil
+}
@@ -0,0 +1,34 @@
+package transcription
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// PromptWordLimit approximates how much of a prompt whisper uses: its last
+// 224 tokens, about 160 English words. Earlier words are dropped.
+const PromptWordLimit = 160
+
+// LoadPrompt reads the prompt for whisper from a UTF-8 text file, e.g. a
+// vocabulary list with a term per line. Line breaks and runs of whitespace
+// become single spaces.
+func LoadPrompt(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read prompt file: %w", err)
+	}
+
+	text := decodeText(data)
+	if !utf8.ValidString(text) {
+		return "", fmt.Errorf("prompt file %s is not UTF-8 text", path)
+	}
+
+	prompt := strings.Join(strings.Fields(text), " ")
+	if prompt == "" {
+		return "", fmt.Errorf("prompt file %s is empty", path)
+	}
+
+	return prompt, nil
+}
@@ -0,0 +1,106 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// clockMarkerRegex matches the paragraph timestamps written with
+// --timestamps, e.g. "[00:01:02]" or "**[00:01:02]**"
+var clockMarkerRegex = regexp.MustCompile(`^(\*\*)?\[\d{2}:\d{2}:\d{2}\](\*\*)?\s*`)
+
+// ReadSegments reads the spoken text of a transcript written by ghospel,
+// split into segments: paragraphs for txt and md, cues for srt and vtt and
+// the segments of json files. Headers, front matter, cue timings and
+// timestamp markers are left out, so transcripts of different models or
+// formats can be compared.
+func ReadSegments(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read transcript: %w", err)
+	}
+
+	content := strings.ReplaceAll(decodeText(data), "\r\n", "\n")
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		var doc jsonTranscript
+		if err := json.Unmarshal([]byte(content), &doc); err != nil {
+			return nil, fmt.Errorf("invalid json transcript %s: %w", path, err)
+		}
+
+		segments := make([]string, 0, len(doc.Segments))
+		for _, seg := range doc.Segments {
+			segments = append(segments, strings.TrimSpace(seg.Text))
+		}
+
+		return segments, nil
+	case ".srt", ".vtt":
+		return subtitleSegments(content), nil
+	default:
+		return paragraphSegments(content), nil
+	}
+}
+
+// subtitleSegments returns the text of each subtitle cue
+func subtitleSegments(content string) []string {
+	var segments []string
+
+	for _, block := range strings.Split(content, "\n\n") {
+		var text []string
+		for _, line := range strings.Split(strings.TrimSpace(block), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || line == "WEBVTT" || strings.Contains(line, "-->") || isCueNumber(line) {
+				continue
+			}
+
+			text = append(text, line)
+		}
+
+		if len(text) > 0 {
+			segments = append(segments, strings.Join(text, " "))
+		}
+	}
+
+	return segments
+}
+
+// isCueNumber reports whether a line is an SRT cue counter
+func isCueNumber(line string) bool {
+	return strings.Trim(line, "0123456789") == ""
+}
+
+// paragraphSegments returns the paragraphs of a txt or md transcript,
+// skipping front matter, the comment header or title and the md metadata
+// line
+func paragraphSegments(content string) []string {
+	if rest, ok := strings.CutPrefix(content, "---\n"); ok {
+		if end := strings.Index(rest, "\n---\n"); end >= 0 {
+			content = rest[end+len("\n---\n"):]
+		}
+	}
+
+	var segments []string
+
+	for _, block := range strings.Split(content, "\n\n") {
+		var text []string
+		for _, line := range strings.Split(strings.TrimSpace(block), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" || strings.HasPrefix(line, "# ") || strings.HasPrefix(line, "*Source: ") {
+				continue
+			}
+
+			text = append(text, clockMarkerRegex.ReplaceAllString(line, ""))
+		}
+
+		if len(text) > 0 {
+			segments = append(segments, strings.Join(text, " "))
+		}
+	}
+
+	return segments
+}
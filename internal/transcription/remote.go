@@ -0,0 +1,147 @@
+package transcription
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pascalwhoop/ghospel/internal/logging"
+	"github.com/schollz/progressbar/v3"
+)
+
+// IsURL reports whether the input is an http(s) URL rather than a filesystem path
+func IsURL(input string) bool {
+	lower := strings.ToLower(input)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// remoteFileName derives a local file name from the last path element of a URL
+func remoteFileName(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return "download"
+	}
+
+	name := path.Base(parsed.Path)
+	if name == "." || name == "/" || name == "" {
+		return "download"
+	}
+
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+
+	return filepath.Base(name)
+}
+
+// downloadName names the local copy of a download after the final URL, so
+// redirects to the real media keep a meaningful extension. A short hash of
+// the requested URL keeps two URLs ending in the same file name from
+// overwriting each other's kept downloads.
+func downloadName(rawURL, finalURL string) string {
+	name := remoteFileName(finalURL)
+	ext := filepath.Ext(name)
+	sum := sha256.Sum256([]byte(rawURL))
+
+	return fmt.Sprintf("%s-%x%s", strings.TrimSuffix(name, ext), sum[:4], ext)
+}
+
+// isAudioContentType reports whether a response content type can plausibly
+// hold audio. Servers often mislabel media as octet-stream, so that's allowed.
+func isAudioContentType(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	return strings.HasPrefix(mediaType, "audio/") ||
+		strings.HasPrefix(mediaType, "video/") ||
+		mediaType == "application/octet-stream" ||
+		mediaType == "application/ogg"
+}
+
+// downloadAudio downloads a remote audio file into the cache's downloads
+// directory and returns the local path. Redirects are followed. Cancelling
+// ctx, e.g. on a timeout, stops the download.
+func (s *Service) downloadAudio(ctx context.Context, rawURL string) (string, error) {
+	downloadDir := filepath.Join(s.opts.CacheDir, "downloads")
+	if err := os.MkdirAll(downloadDir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create download directory: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("invalid URL: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to start download: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed with status: %s", resp.Status)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isAudioContentType(contentType) {
+		return "", fmt.Errorf("URL does not point to audio (content type: %s)", contentType)
+	}
+
+	localPath := filepath.Join(downloadDir, downloadName(rawURL, resp.Request.URL.String()))
+
+	out, err := os.Create(localPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create download file: %w", err)
+	}
+	defer out.Close()
+
+	var progressReader io.Reader = resp.Body
+
+	// A second bar would garble the batch progress bar
+	if !s.opts.Quiet && !logging.Structured() && !logging.HasOverlay() && resp.ContentLength > 0 {
+		bar := progressbar.NewOptions64(
+			resp.ContentLength,
+			progressbar.OptionSetDescription(fmt.Sprintf("Downloading %s", filepath.Base(localPath))),
+			progressbar.OptionSetWriter(os.Stderr),
+			progressbar.OptionShowBytes(true),
+			progressbar.OptionShowIts(),
+			progressbar.OptionSetPredictTime(true),
+			progressbar.OptionShowElapsedTimeOnFinish(),
+			progressbar.OptionSetWidth(40),
+			progressbar.OptionThrottle(65*1000000), // 65ms
+			progressbar.OptionShowCount(),
+			progressbar.OptionOnCompletion(func() {
+				fmt.Fprint(os.Stderr, "\n")
+			}),
+			progressbar.OptionSpinnerType(14),
+			progressbar.OptionFullWidth(),
+			progressbar.OptionSetRenderBlankState(true),
+		)
+		reader := progressbar.NewReader(resp.Body, bar)
+		progressReader = &reader
+	}
+
+	if _, err := io.Copy(out, progressReader); err != nil {
+		// Clean up partial download
+		out.Close()
+		os.Remove(localPath)
+
+		return "", fmt.Errorf("download failed: %w", err)
+	}
+
+	return localPath, nil
+}
@@ -0,0 +1,131 @@
+package transcription
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// sentencePause is the silence after a segment that is taken as the end of a
+// sentence when the model left out the punctuation
+const sentencePause = 600 * time.Millisecond
+
+// repairPunctuation returns a copy of the result with a period added to
+// segments that end a sentence without punctuation, sentence starts
+// capitalized and, in English, the pronoun "I" capitalized. A segment ends
+// a sentence when a pause or a new speaker follows it, or when it is the
+// last one. This is a heuristic for models that leave out punctuation and
+// lets the paragraph formatter find sentences; it won't match real
+// punctuation.
+func repairPunctuation(result *transcribe.Result) *transcribe.Result {
+	english := strings.HasPrefix(strings.ToLower(result.Language), "en")
+
+	repaired := *result
+	repaired.Segments = make([]transcribe.Segment, len(result.Segments))
+
+	sentenceStart := true
+	texts := make([]string, len(result.Segments))
+
+	for i, seg := range result.Segments {
+		text := strings.TrimRightFunc(seg.Text, unicode.IsSpace)
+
+		last, _ := utf8.DecodeLastRuneInString(text)
+		if unicode.IsLetter(last) || unicode.IsNumber(last) {
+			endsSentence := i == len(result.Segments)-1
+			if !endsSentence {
+				next := result.Segments[i+1]
+				endsSentence = next.Start-seg.End >= sentencePause || next.Speaker != seg.Speaker
+			}
+
+			if endsSentence {
+				text += "."
+			}
+		}
+
+		// Sentences carry over from one segment to the next
+		text, sentenceStart = capitalizeSentences(text, sentenceStart)
+		if english {
+			text = capitalizePronoun(text)
+		}
+
+		seg.Text = text
+		repaired.Segments[i] = seg
+		texts[i] = text
+	}
+
+	if len(texts) > 0 {
+		repaired.Text = strings.Join(texts, " ")
+	}
+
+	return &repaired
+}
+
+// capitalizeSentences upper-cases the first letter of every sentence in
+// text. sentenceStart tells whether text starts a sentence; the returned
+// flag tells whether the text after it does. Periods inside words such as
+// "3.5" or after common abbreviations don't end a sentence.
+func capitalizeSentences(text string, sentenceStart bool) (string, bool) {
+	runes := []rune(text)
+	pending := false
+	wordStart := 0
+
+	for i, r := range runes {
+		switch {
+		case r == '.' || r == '?' || r == '!':
+			pending = true
+		case unicode.IsSpace(r):
+			if pending && !endsInAbbreviation(string(runes[wordStart:i])) {
+				sentenceStart = true
+			}
+			pending = false
+			wordStart = i + 1
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if sentenceStart {
+				runes[i] = unicode.ToUpper(r)
+				sentenceStart = false
+			}
+			pending = false
+		}
+	}
+
+	if pending && !endsInAbbreviation(string(runes[wordStart:])) {
+		sentenceStart = true
+	}
+
+	return string(runes), sentenceStart
+}
+
+// endsInAbbreviation reports whether a word ending in a period is one of the
+// abbreviations or initialisms the paragraph formatter doesn't split at
+func endsInAbbreviation(word string) bool {
+	word = strings.TrimLeft(strings.TrimSuffix(word, "."), `"'([`)
+
+	return abbreviations[strings.ToLower(word)] || initialismRegex.MatchString(word)
+}
+
+// capitalizePronoun writes the English pronoun "i" as "I", including
+// contractions like "i'm" and "i've"
+func capitalizePronoun(text string) string {
+	runes := []rune(text)
+
+	for i, r := range runes {
+		if r != 'i' {
+			continue
+		}
+
+		if i > 0 && (unicode.IsLetter(runes[i-1]) || unicode.IsNumber(runes[i-1]) || runes[i-1] == '\'') {
+			continue
+		}
+
+		if i+1 < len(runes) && (unicode.IsLetter(runes[i+1]) || unicode.IsNumber(runes[i+1])) {
+			continue
+		}
+
+		runes[i] = 'I'
+	}
+
+	return string(runes)
+}
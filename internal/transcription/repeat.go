@@ -0,0 +1,115 @@
+package transcription
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// DefaultRepeatSimilarity is how alike consecutive segments must be for the
+// repeat guard to take them as a loop: 0.9 tolerates whisper varying
+// punctuation or a word ending between the copies.
+const DefaultRepeatSimilarity = 0.9
+
+// minRepeatRun is how many alike segments in a row make a loop. Two are left
+// alone, people do say "Yes. Yes." or repeat a phrase for emphasis.
+const minRepeatRun = 3
+
+// collapseRepeats returns a copy of the result where every run of at least
+// minRepeatRun consecutive segments whose text is at least similarity alike
+// is cut down to its first segment. Whisper falls into such loops over music
+// and silence, writing the same line dozens of times. The time the copies
+// covered is left without text.
+func collapseRepeats(result *transcribe.Result, similarity float64) *transcribe.Result {
+	collapsed := *result
+	collapsed.Segments = make([]transcribe.Segment, 0, len(result.Segments))
+
+	for start := 0; start < len(result.Segments); {
+		first := result.Segments[start]
+		key := repeatKey(first.Text)
+
+		end := start + 1
+		for key != "" && end < len(result.Segments) &&
+			textSimilarity(key, repeatKey(result.Segments[end].Text)) >= similarity {
+			end++
+		}
+
+		collapsed.Segments = append(collapsed.Segments, first)
+
+		if end-start < minRepeatRun {
+			collapsed.Segments = append(collapsed.Segments, result.Segments[start+1:end]...)
+		} else {
+			last := result.Segments[end-1]
+			slog.Debug(fmt.Sprintf("🔁 Collapsed %d repeats of %q between %s and %s", end-start-1,
+				strings.TrimSpace(first.Text), first.Start, last.End),
+				"repeats", end-start-1, "start", first.Start, "end", last.End)
+		}
+
+		start = end
+	}
+
+	if len(collapsed.Segments) == len(result.Segments) {
+		return result
+	}
+
+	texts := make([]string, 0, len(collapsed.Segments))
+	for _, seg := range collapsed.Segments {
+		if text := strings.TrimSpace(seg.Text); text != "" {
+			texts = append(texts, text)
+		}
+	}
+	collapsed.Text = strings.Join(texts, " ")
+
+	return &collapsed
+}
+
+// repeatKey is the text segments are compared by: lower-cased letters and
+// digits, so punctuation and spacing don't hide a repeat
+func repeatKey(text string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+// textSimilarity tells how alike two texts are, from 0 for nothing in common
+// to 1 for identical, by their edit distance relative to the longer one
+func textSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	ra, rb := []rune(a), []rune(b)
+	longer := max(len(ra), len(rb))
+
+	return 1 - float64(editDistance(ra, rb))/float64(longer)
+}
+
+// editDistance is the Levenshtein distance between two texts: how many
+// characters must be inserted, deleted or replaced to turn one into the other
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
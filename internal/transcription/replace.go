@@ -0,0 +1,155 @@
+package transcription
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+// replacement is a single entry of a replacement file
+type replacement struct {
+	pattern *regexp.Regexp
+	with    string
+	regex   bool // Expand $1-style references in with
+}
+
+// Replacer fixes known misrecognitions, e.g. product names and acronyms
+// whisper consistently gets wrong
+type Replacer struct {
+	entries []replacement
+}
+
+// LoadReplacer reads a replacement file. YAML files map phrases to their
+// replacement, CSV files have a phrase and its replacement per line:
+//
+//	get hub: GitHub
+//	/\bk8s\b/: Kubernetes
+//
+// Phrases match whole words regardless of case. Phrases wrapped in slashes
+// are regular expressions used as-is, their replacement can refer to groups
+// as $1. Entries are applied in file order.
+func LoadReplacer(path string) (*Replacer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replace file: %w", err)
+	}
+
+	var pairs [][2]string
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		reader := csv.NewReader(strings.NewReader(string(data)))
+		reader.Comment = '#'
+		reader.FieldsPerRecord = 2
+		reader.TrimLeadingSpace = true
+
+		records, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("invalid replace file %s: %w", path, err)
+		}
+
+		for _, record := range records {
+			pairs = append(pairs, [2]string{record[0], record[1]})
+		}
+	case ".yaml", ".yml":
+		// Decode into a node to keep the order of the entries
+		var root yaml.Node
+		if err := yaml.Unmarshal(data, &root); err != nil {
+			return nil, fmt.Errorf("invalid replace file %s: %w", path, err)
+		}
+
+		if len(root.Content) > 0 {
+			mapping := root.Content[0]
+			if mapping.Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("invalid replace file %s: line %d: expected a mapping of phrases to replacements", path, mapping.Line)
+			}
+
+			for i := 0; i+1 < len(mapping.Content); i += 2 {
+				pairs = append(pairs, [2]string{mapping.Content[i].Value, mapping.Content[i+1].Value})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported replace file %s (expected .yaml, .yml or .csv)", path)
+	}
+
+	replacer := &Replacer{}
+
+	for _, pair := range pairs {
+		from, with := strings.TrimSpace(pair[0]), pair[1]
+		if from == "" {
+			continue
+		}
+
+		if expr, ok := strings.CutPrefix(from, "/"); ok && len(expr) > 1 && strings.HasSuffix(expr, "/") {
+			pattern, err := regexp.Compile(strings.TrimSuffix(expr, "/"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid replace file %s: %s: %w", path, from, err)
+			}
+
+			replacer.entries = append(replacer.entries, replacement{pattern: pattern, with: with, regex: true})
+			continue
+		}
+
+		// Words of a phrase may be separated by any whitespace
+		words := strings.Fields(from)
+		for i, word := range words {
+			words[i] = regexp.QuoteMeta(word)
+		}
+
+		pattern := regexp.MustCompile(`(?i)` + strings.Join(words, `\s+`))
+		replacer.entries = append(replacer.entries, replacement{pattern: pattern, with: with})
+	}
+
+	return replacer, nil
+}
+
+// Apply runs every replacement over text
+func (r *Replacer) Apply(text string) string {
+	for _, entry := range r.entries {
+		if entry.regex {
+			text = entry.pattern.ReplaceAllString(text, entry.with)
+			continue
+		}
+
+		text = replaceWholeWords(entry.pattern, text, entry.with)
+	}
+
+	return text
+}
+
+// replaceWholeWords replaces the matches of pattern that are not part of a
+// longer word
+func replaceWholeWords(pattern *regexp.Regexp, text, with string) string {
+	var out strings.Builder
+
+	last := 0
+	for _, match := range pattern.FindAllStringIndex(text, -1) {
+		start, end := match[0], match[1]
+
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if isWordRune(before) || isWordRune(after) {
+			continue
+		}
+
+		out.WriteString(text[last:start])
+		out.WriteString(with)
+		last = end
+	}
+
+	out.WriteString(text[last:])
+
+	return out.String()
+}
+
+// isWordRune reports whether r is part of a word
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsNumber(r)
+}
@@ -0,0 +1,127 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// File statuses used in the batch report
+const (
+	statusSucceeded = "succeeded"
+	statusFailed    = "failed"
+	statusSkipped   = "skipped"
+)
+
+// Report is the machine-readable summary of a batch run written by --report
+type Report struct {
+	Model           string       `json:"model"`
+	StartedAt       time.Time    `json:"started_at"`
+	ElapsedSeconds  float64      `json:"elapsed_seconds"`
+	AudioSeconds    float64      `json:"audio_seconds"`
+	Words           int          `json:"words"`
+	RealtimeFactor  float64      `json:"realtime_factor"`
+	ProcessingRatio float64      `json:"processing_ratio"`
+	Succeeded       int          `json:"succeeded"`
+	Failed          int          `json:"failed"`
+	Skipped         int          `json:"skipped"`
+	Files           []FileReport `json:"files"`
+}
+
+// FileReport is the outcome of a single input file in a batch run
+type FileReport struct {
+	Path            string  `json:"path"`
+	Status          string  `json:"status"`
+	Output          string  `json:"output,omitempty"`
+	Language        string  `json:"language,omitempty"`
+	Words           int     `json:"words,omitempty"`
+	AudioSeconds    float64 `json:"audio_seconds,omitempty"`
+	ElapsedSeconds  float64 `json:"elapsed_seconds,omitempty"`
+	RealtimeFactor  float64 `json:"realtime_factor,omitempty"`
+	ProcessingRatio float64 `json:"processing_ratio,omitempty"`
+	Error           string  `json:"error,omitempty"`
+	Reason          string  `json:"reason,omitempty"` // Why a file was skipped
+}
+
+// newReport starts a report for a run using the given model
+func newReport(model string, startedAt time.Time) *Report {
+	return &Report{
+		Model:     model,
+		StartedAt: startedAt,
+		Files:     []FileReport{},
+	}
+}
+
+// addSkipped records a file that was left alone, e.g. because it was
+// transcribed already
+func (r *Report) addSkipped(path, reason string) {
+	r.Skipped++
+	r.Files = append(r.Files, FileReport{Path: path, Status: statusSkipped, Reason: reason})
+}
+
+// addSucceeded records a transcribed file and accumulates its totals
+func (r *Report) addSucceeded(path string, stats *FileStats) {
+	r.Succeeded++
+	r.Words += stats.WordCount
+	r.AudioSeconds += stats.Duration.Seconds()
+	r.Files = append(r.Files, FileReport{
+		Path:            path,
+		Status:          statusSucceeded,
+		Output:          stats.OutputPath,
+		Language:        stats.Language,
+		Words:           stats.WordCount,
+		AudioSeconds:    stats.Duration.Seconds(),
+		ElapsedSeconds:  stats.Elapsed.Seconds(),
+		RealtimeFactor:  realtimeFactor(stats.Duration, stats.Elapsed),
+		ProcessingRatio: processingRatio(stats.Duration, stats.Elapsed),
+	})
+}
+
+// addFailed records a file that could not be transcribed
+func (r *Report) addFailed(path string, err error) {
+	r.Failed++
+	r.Files = append(r.Files, FileReport{Path: path, Status: statusFailed, Error: err.Error()})
+}
+
+// finish fills in the run-wide timing once all files are processed
+func (r *Report) finish(elapsed time.Duration) {
+	r.ElapsedSeconds = elapsed.Seconds()
+	audio := time.Duration(r.AudioSeconds * float64(time.Second))
+	r.RealtimeFactor = realtimeFactor(audio, elapsed)
+	r.ProcessingRatio = processingRatio(audio, elapsed)
+}
+
+// write saves the report as indented JSON to path
+func (r *Report) write(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	return nil
+}
+
+// realtimeFactor is how many seconds of audio were transcribed per second of processing
+func realtimeFactor(audio, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return 0
+	}
+
+	return audio.Seconds() / elapsed.Seconds()
+}
+
+// processingRatio is how many seconds of processing a second of audio took,
+// the inverse of realtimeFactor. Slow files, e.g. noisy ones or with long
+// silences, stand out with a high ratio.
+func processingRatio(audio, elapsed time.Duration) float64 {
+	if audio <= 0 {
+		return 0
+	}
+
+	return elapsed.Seconds() / audio.Seconds()
+}
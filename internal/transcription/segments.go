@@ -0,0 +1,55 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// segmentRecord is a line of the segments file written with --segment-output
+type segmentRecord struct {
+	Source string `json:"source"`
+	Index  int    `json:"index"`
+	jsonSegment
+}
+
+// segmentsPath is the segments file written next to a transcript, e.g.
+// "talk.segments.jsonl" for "talk.txt"
+func segmentsPath(outputPath string) string {
+	return strings.TrimSuffix(outputPath, filepath.Ext(outputPath)) + ".segments.jsonl"
+}
+
+// writeSegments writes every segment of the transcript of inputPath as a
+// JSON object on its own line next to outputPath, for search indexing and
+// other tools working on segments. Text fixes apply as in the transcript.
+func (s *Service) writeSegments(result *transcribe.Result, inputPath, outputPath string) error {
+	result, err := s.postProcess(result)
+	if err != nil {
+		return err
+	}
+
+	var content strings.Builder
+
+	encoder := json.NewEncoder(&content)
+	encoder.SetEscapeHTML(false)
+
+	source := filepath.Base(inputPath)
+
+	for i, seg := range jsonSegments(result.Segments) {
+		seg.Text = strings.TrimSpace(seg.Text)
+
+		if err := encoder.Encode(segmentRecord{Source: source, Index: i, jsonSegment: seg}); err != nil {
+			return fmt.Errorf("failed to encode segment: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(segmentsPath(outputPath), []byte(content.String()), 0o644); err != nil {
+		return fmt.Errorf("failed to write segments file: %w", err)
+	}
+
+	return nil
+}
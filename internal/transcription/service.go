@@ -1,65 +1,343 @@
 package transcription
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"time"
 
-	"github.com/pascalwhoop/ghospel/internal/audio"
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/pascalwhoop/ghospel/internal/logging"
 	"github.com/pascalwhoop/ghospel/internal/models"
-	"github.com/pascalwhoop/ghospel/internal/whisper"
-	"github.com/schollz/progressbar/v3"
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+	"golang.org/x/term"
 )
 
+// ErrNoAudioFiles is returned when the inputs contain no supported audio files
+var ErrNoAudioFiles = errors.New("no audio files found")
+
 // Options holds transcription configuration
 type Options struct {
-	Model      string
-	OutputDir  string
-	Workers    int
-	Recursive  bool
-	Timestamps bool
-	Prompt     string
-	Language   string
-	Format     string
-	CacheDir   string
-	Quiet      bool
-	Verbose    bool
-	Force      bool
+	Model        string
+	OutputDir    string
+	Workers      int
+	Recursive    bool
+	Timestamps   bool
+	Prompt       string
+	Language     string
+	Format       string
+	CacheDir     string
+	ModelsDir    string
+	ModelBaseURL string
+	FFmpegPath   string
+	WhisperPath  string
+	TempDir      string
+	Quiet        bool
+	Verbose      bool
+	Force        bool
+	DryRun       bool
+	KeepDownload bool
+	NoFormat     bool
+	Formatter    FormatterOptions
+	OnComplete   string
+	StrictHooks  bool
+	FrontMatter  bool
+	Normalize    bool
+	Denoise      bool
+	HighPass     int
+	Resample     string
+	Start        time.Duration
+	End          time.Duration
+	Stream       bool
+	KeepWAV      bool
+	Retries      int
+	ReportPath   string
+	Diarize      bool
+
+	// WordTimestamps adds per-word timing to json and vtt output
+	WordTimestamps bool
+
+	// VAD skips long silences before running whisper
+	VAD bool
+
+	// Whisper decoding parameters, zero values use whisper's defaults
+	BeamSize    int
+	Temperature float64
+
+	// Hallucination guards, see transcribe.Options
+	EntropyThreshold  float64
+	LogprobThreshold  float64
+	NoSpeechThreshold float64
+	NoFallback        bool
+	SuppressNonSpeech bool
+
+	// Timeout bounds each file and BatchTimeout the whole run, 0 disables them
+	Timeout      time.Duration
+	BatchTimeout time.Duration
+
+	// Channel picks one input channel (1-based) instead of the mono downmix,
+	// SplitChannels transcribes each channel separately with channel labels
+	Channel       int
+	SplitChannels bool
+
+	// AudioTrack (1-based) or AudioLanguage pick the audio stream of inputs
+	// with several, such as videos with one track per language
+	AudioTrack    int
+	AudioLanguage string
+
+	// Wrap hard-wraps txt and md paragraphs at this column, 0 disables it
+	Wrap int
+
+	// Subtitles limits the length of srt and vtt cues
+	Subtitles SubtitleOptions
+
+	// NativeSubtitles writes the srt and vtt files whisper made itself, with
+	// its exact cue timings, instead of rendering them from the segments.
+	// Where whisper's files don't match the segments, e.g. with --start or
+	// --vad, they are rendered as usual.
+	NativeSubtitles bool
+
+	// NoHeader omits the txt comment header and the md metadata line,
+	// HeaderTemplate replaces the default txt header (DefaultHeaderTemplate)
+	NoHeader       bool
+	HeaderTemplate string
+
+	// MinDuration and MaxDuration skip files shorter or longer than this,
+	// 0 disables the respective limit
+	MinDuration time.Duration
+	MaxDuration time.Duration
+
+	// Since skips local files last modified before this time, zero keeps all
+	Since time.Time
+
+	// Include and Exclude are glob patterns such as "interview-*" matched
+	// against file names, ignoring case. With Include set, only matching
+	// files are transcribed; Exclude wins over Include.
+	Include []string
+	Exclude []string
+
+	// Resume skips the files an interrupted run of the same batch finished,
+	// as recorded in its journal in the cache directory
+	Resume bool
+
+	// Stdout writes transcripts to standard output instead of files, so it
+	// carries nothing but transcript content
+	Stdout bool
+
+	// Embed stores the transcript in the audio file's metadata, in a tagged
+	// copy unless InPlace is set
+	Embed   bool
+	InPlace bool
+
+	// RepeatGuard collapses runs of three or more alike consecutive segments,
+	// whisper's hallucinated loops, into their first. RepeatSimilarity is how
+	// alike they must be, 0-1, 0 uses DefaultRepeatSimilarity.
+	RepeatGuard      bool
+	RepeatSimilarity float64
+
+	// RepairPunctuation ends unpunctuated sentences at pauses and capitalizes
+	// sentence starts, for models that leave punctuation out
+	RepairPunctuation bool
+
+	// NormalizeText writes spelled-out numbers, currencies, percentages and
+	// units in digits and symbols, for languages with a normalizer
+	NormalizeText bool
+
+	// ReplaceFile fixes known misrecognitions with the phrases of a YAML or
+	// CSV file (see LoadReplacer)
+	ReplaceFile string
+
+	// Censor masks profanity in transcripts, using the word list file at
+	// CensorWords instead of the built-in list when set
+	Censor      bool
+	CensorWords string
+
+	// MergePath combines the transcripts of all inputs, in order, into this
+	// single file instead of writing one transcript per input
+	MergePath string
+
+	// AppendPath adds the transcript of every input, in order, to the end of
+	// this file below a header line instead of writing one file per input
+	AppendPath string
+
+	// SplitChapters writes one transcript per chapter for inputs with
+	// chapter metadata, such as audiobooks, and a single one otherwise
+	SplitChapters bool
+
+	// SegmentOutput also writes every segment as a JSON line to a
+	// .segments.jsonl file next to each transcript
+	SegmentOutput bool
+
+	// Progress is ProgressBar for the console progress bar or ProgressJSON
+	// to write lifecycle events as JSON lines to stderr
+	Progress string
+
+	// OutputPath is the exact transcript path for a run with a single
+	// input, instead of one derived from the input name
+	OutputPath string
+
+	// AudioExtensions are the file extensions picked up from directories,
+	// transcribe.AudioExtensions if empty
+	AudioExtensions []string
+
+	// OverwritePolicy decides what happens to inputs that already have a
+	// transcript, one of OverwritePolicies; empty skips them unless Force
+	OverwritePolicy string
+
+	// Encoding of transcript files, one of Encodings; empty is UTF-8
+	// without a byte order mark. Stdout is always UTF-8.
+	Encoding string
+
+	// Formats writes every transcript in each of these formats when more
+	// than one is given, from the same transcription. Format is the first,
+	// the primary format that hooks, --segment-output and the skip check go
+	// by.
+	Formats []string
+
+	// Models transcribes every input with each of these models when more
+	// than one is given, into <name>.<model>.<ext> side by side. Model is
+	// ignored then.
+	Models []string
 }
 
-// Service handles audio transcription
+// Service handles batch audio transcription and console reporting on top of
+// the transcribe library
 type Service struct {
-	opts           Options
-	audioProcessor *audio.Processor
-	whisperClient  *whisper.Client
-	modelManager   *models.Manager
+	opts         Options
+	transcriber  *transcribe.Transcriber
+	modelManager *models.Manager
+	journal      *journal // Journal of the running batch
+	observers    observerList
+
+	// modelServices run the models of a multi-model run, one each, and
+	// modelSuffix is set on them to name transcripts after the model
+	modelServices []*Service
+	modelSuffix   bool
 }
 
 // NewService creates a new transcription service
 func NewService(opts Options) *Service {
-	// Initialize audio processor
-	audioProcessor := audio.NewProcessor("/opt/homebrew/bin/ffmpeg", "/tmp/ghospel")
+	opts.Model = models.Resolve(opts.Model)
+
+	if len(opts.AudioExtensions) == 0 {
+		opts.AudioExtensions = transcribe.AudioExtensions
+	}
 
-	// Initialize whisper client
-	whisperClient := whisper.NewClient("", opts.CacheDir)
+	// Models live in the cache directory unless a custom directory is set
+	modelsDir := opts.ModelsDir
+	if modelsDir == "" {
+		modelsDir = opts.CacheDir
+	}
+
+	ffmpegPath := opts.FFmpegPath
+	if ffmpegPath == "" {
+		ffmpegPath = "/opt/homebrew/bin/ffmpeg"
+	}
+
+	// Initialize the transcription pipeline
+	transcriber := transcribe.New(transcribe.Config{
+		ModelsDir:   modelsDir,
+		FFmpegPath:  ffmpegPath,
+		WhisperPath: opts.WhisperPath,
+		TempDir:     opts.TempDir,
+	})
 
 	// Initialize model manager
-	modelManager := models.NewManager(opts.CacheDir)
+	modelManager := models.NewManager(modelsDir)
+	modelManager.SetBaseURL(opts.ModelBaseURL)
 
-	return &Service{
-		opts:           opts,
-		audioProcessor: audioProcessor,
-		whisperClient:  whisperClient,
-		modelManager:   modelManager,
+	service := &Service{
+		opts:         opts,
+		transcriber:  transcriber,
+		modelManager: modelManager,
 	}
+
+	if opts.Progress == ProgressJSON {
+		service.AddObserver(&eventStream{w: os.Stderr})
+	}
+
+	if len(opts.Models) > 1 {
+		service.modelServices = newModelServices(opts)
+	}
+
+	return service
+}
+
+// Close removes the temporary files of the run. Audio kept with --keep-wav
+// stays where it was reported.
+func (s *Service) Close() error {
+	for _, modelService := range s.modelServices {
+		modelService.Close()
+	}
+
+	// The batch was interrupted while files were being transcribed
+	if s.journal != nil && s.journal.file != nil {
+		s.journal.hint()
+	}
+
+	if s.opts.KeepWAV {
+		return nil
+	}
+
+	return s.transcriber.Close()
+}
+
+// checkWhisper fails with an actionable error when the whisper binary can't
+// be run, instead of failing every file the same way
+func (s *Service) checkWhisper() error {
+	if err := s.transcriber.CheckWhisper(); err != nil {
+		if s.opts.WhisperPath != "" {
+			return fmt.Errorf("%w; check --whisper-path or whisper_path in the config", err)
+		}
+
+		return fmt.Errorf("%w; use a release build, which embeds whisper-cli, run `make build-whisper` "+
+			"in a source checkout or put whisper-cli on PATH", err)
+	}
+
+	return nil
+}
+
+// checkFFmpeg fails with an actionable error when ffmpeg is missing, unless
+// none of the files needs converting
+func (s *Service) checkFFmpeg(files []string) error {
+	opts := s.transcribeOptions()
+
+	needed := false
+	for _, file := range files {
+		if IsURL(file) || transcribe.NeedsFFmpeg(file, opts) {
+			needed = true
+			break
+		}
+	}
+
+	if !needed {
+		return nil
+	}
+
+	if err := s.transcriber.CheckFFmpeg(); err != nil {
+		return fmt.Errorf("%w; set ffmpeg_path or install ffmpeg", err)
+	}
+
+	return nil
 }
 
 // TranscribeFiles transcribes the given input files/directories
 func (s *Service) TranscribeFiles(inputs []string) error {
-	if !s.opts.Quiet {
-		fmt.Printf("🎵 Ghospel v0.1.0 - Starting transcription with model: %s\n", s.opts.Model)
+	if len(s.modelServices) > 0 {
+		return s.transcribeModels(inputs)
+	}
+
+	slog.Info(fmt.Sprintf("🎵 Ghospel v0.1.0 - Starting transcription with model: %s", s.opts.Model),
+		"model", s.opts.Model)
+
+	// Make sure transcripts can be written before doing any work
+	if err := s.prepareOutputDir(); err != nil {
+		return err
 	}
 
 	// Find all audio files
@@ -68,57 +346,112 @@ func (s *Service) TranscribeFiles(inputs []string) error {
 		return fmt.Errorf("failed to find audio files: %w", err)
 	}
 
+	if s.opts.OutputPath != "" && len(audioFiles) > 1 {
+		return fmt.Errorf("--output needs a single input, but the inputs hold %d audio files", len(audioFiles))
+	}
+
 	if len(audioFiles) == 0 {
-		return fmt.Errorf("no audio files found")
+		if !s.opts.Since.IsZero() {
+			return fmt.Errorf("%w modified since %s", ErrNoAudioFiles, s.opts.Since.Format("2006-01-02 15:04"))
+		}
+
+		return ErrNoAudioFiles
+	}
+
+	report := newReport(s.opts.Model, time.Now())
+
+	batchJournal, err := s.loadJournal(inputs)
+	if err != nil {
+		return err
 	}
 
 	// Filter out already transcribed files unless force flag is set
 	var filesToProcess []string
-	var skippedCount int
-	
+	var skippedFiles []string
+
 	for _, file := range audioFiles {
-		outputPath := s.getOutputPath(file)
-		if !s.opts.Force {
-			if _, err := os.Stat(outputPath); err == nil {
-				skippedCount++
-				if s.opts.Verbose {
-					fmt.Printf("⏭️  Skipping %s (already transcribed)\n", filepath.Base(file))
-				}
-				continue
+		if batchJournal.isDone(file) {
+			skippedFiles = append(skippedFiles, file)
+			report.addSkipped(file, "done in the resumed run")
+			if !s.opts.DryRun {
+				slog.Debug(fmt.Sprintf("⏭️  Skipping %s (done in the resumed run)", filepath.Base(file)), "file", file)
+			}
+			continue
+		}
+
+		if s.isTranscribed(file) {
+			skippedFiles = append(skippedFiles, file)
+			report.addSkipped(file, "already transcribed")
+			if !s.opts.DryRun {
+				slog.Debug(fmt.Sprintf("⏭️  Skipping %s (already transcribed)", filepath.Base(file)), "file", file)
 			}
+			continue
 		}
 		filesToProcess = append(filesToProcess, file)
 	}
 
-	if !s.opts.Quiet {
-		if skippedCount > 0 {
-			fmt.Printf("📁 Found %d audio file(s), %d already transcribed, %d to process\n", 
-				len(audioFiles), skippedCount, len(filesToProcess))
-		} else {
-			fmt.Printf("📁 Found %d audio file(s) to transcribe\n", len(filesToProcess))
-		}
+	filesToProcess = s.filterByDuration(filesToProcess, report)
+
+	if s.opts.DryRun {
+		s.printPlan(filesToProcess, report)
+		return nil
+	}
+
+	if len(skippedFiles) > 0 {
+		slog.Info(fmt.Sprintf("📁 Found %d audio file(s), %d already transcribed, %d to process",
+			len(audioFiles), len(skippedFiles), len(filesToProcess)),
+			"found", len(audioFiles), "skipped", len(skippedFiles), "pending", len(filesToProcess))
+	} else {
+		slog.Info(fmt.Sprintf("📁 Found %d audio file(s) to transcribe", len(filesToProcess)),
+			"found", len(audioFiles), "pending", len(filesToProcess))
 	}
 
 	if len(filesToProcess) == 0 {
-		if !s.opts.Quiet {
-			fmt.Println("✅ All files already transcribed! Use --force to re-transcribe.")
+		batchJournal.remove()
+
+		if len(skippedFiles) == len(audioFiles) {
+			slog.Info("✅ All files already transcribed! Use --force to re-transcribe.")
+		} else {
+			slog.Info("✅ Nothing left to transcribe within the duration limits.")
 		}
-		return nil
+
+		s.observers.OnBatchStart([]string{}, report.Skipped)
+		s.observers.OnBatchDone(report)
+
+		return s.writeReport(report)
 	}
 
 	// Update audioFiles to only include files to process
 	audioFiles = filesToProcess
 
-	// Initialize progress bar for batch transcription
-	var bar *progressbar.ProgressBar
-	if !s.opts.Quiet && len(audioFiles) > 1 {
-		bar = progressbar.NewOptions(len(audioFiles),
-			progressbar.OptionSetDescription("Transcribing files"),
-			progressbar.OptionSetWriter(os.Stderr),
-			progressbar.OptionSetWidth(40),
-			progressbar.OptionShowCount(),
-			progressbar.OptionSetRenderBlankState(true),
-		)
+	if err := s.checkWhisper(); err != nil {
+		return err
+	}
+
+	if err := s.checkFFmpeg(audioFiles); err != nil {
+		return err
+	}
+
+	// The model picker needs the terminal before the progress bar takes it
+	if s.interactive() {
+		if err := s.ensureModelDownloaded(); err != nil {
+			return fmt.Errorf("model preparation failed: %w", err)
+		}
+	}
+
+	if err := batchJournal.open(s.opts.Resume); err != nil {
+		return err
+	}
+	s.journal = batchJournal
+
+	s.observers.OnBatchStart(audioFiles, report.Skipped)
+
+	// Bound the whole batch, files still pending at the deadline are failed
+	ctx := context.Background()
+	if s.opts.BatchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.opts.BatchTimeout)
+		defer cancel()
 	}
 
 	// Track overall statistics
@@ -126,64 +459,169 @@ func (s *Service) TranscribeFiles(inputs []string) error {
 	totalWords := 0
 	totalDuration := time.Duration(0)
 	successCount := 0
-	failedCount := 0
+	languages := make(map[string]int)
+
+	var failures []*FileError
 
 	// Process each file
 	for i, file := range audioFiles {
-		fileStats, err := s.transcribeFile(file)
+		if ctx.Err() != nil {
+			for _, remaining := range audioFiles[i:] {
+				failures = append(failures, &FileError{Path: remaining, Err: ErrBatchTimeout})
+				report.addFailed(remaining, ErrBatchTimeout)
+				batchJournal.record(remaining, "", ErrBatchTimeout)
+				s.observers.OnError(remaining, ErrBatchTimeout)
+			}
+
+			slog.Error(fmt.Sprintf("🛑 Batch timeout of %s reached, %d file(s) not transcribed",
+				s.opts.BatchTimeout, len(audioFiles)-i),
+				"timeout", s.opts.BatchTimeout, "remaining", len(audioFiles)-i)
+
+			break
+		}
+
+		s.observers.OnFileStart(file, i+1, len(audioFiles))
+
+		fileStats, err := s.transcribeFile(ctx, file)
 		if err != nil {
-			failedCount++
-			if s.opts.Verbose {
-				fmt.Printf("❌ Failed to transcribe %s: %v\n", file, err)
+			failures = append(failures, &FileError{Path: file, Err: err})
+			report.addFailed(file, err)
+			batchJournal.record(file, "", err)
+			s.observers.OnError(file, err)
+			slog.Debug(fmt.Sprintf("❌ Failed to transcribe %s: %v", file, err), "file", file, "error", err)
+
+			var hookErr *HookError
+			if errors.As(err, &hookErr) {
+				slog.Error(fmt.Sprintf("🛑 Aborting batch: %v", hookErr), "error", hookErr)
+				break
 			}
 		} else {
 			successCount++
+			report.addSucceeded(file, fileStats)
+			batchJournal.record(file, fileStats.OutputPath, nil)
+			for _, segment := range fileStats.segments {
+				s.observers.OnSegment(file, segment)
+			}
+			s.observers.OnFileDone(file, fileStats)
 			totalWords += fileStats.WordCount
 			totalDuration += fileStats.Duration
-			if !s.opts.Quiet {
-				if len(audioFiles) == 1 {
-					fmt.Printf("✅ Transcribed: %s (%d words, %s duration)\n", 
-						filepath.Base(file), fileStats.WordCount, fileStats.Duration.Round(time.Second))
-				} else {
-					fmt.Printf("✅ [%d/%d] %s (%d words, %s)\n", 
-						i+1, len(audioFiles), filepath.Base(file), fileStats.WordCount, fileStats.Duration.Round(time.Second))
-				}
+			if fileStats.Language != "" {
+				languages[fileStats.Language]++
+			}
+			message := fmt.Sprintf("✅ [%d/%d] %s (%d words, %s, %s)",
+				i+1, len(audioFiles), filepath.Base(file), fileStats.WordCount, fileStats.Duration.Round(time.Second), fileStats.Language)
+			if len(audioFiles) == 1 {
+				message = fmt.Sprintf("✅ Transcribed: %s (%d words, %s duration, language: %s)",
+					filepath.Base(file), fileStats.WordCount, fileStats.Duration.Round(time.Second), fileStats.Language)
 			}
-		}
 
-		// Update progress bar
-		if bar != nil {
-			bar.Add(1)
+			slog.Info(message, "file", file, "output", fileStats.OutputPath, "words", fileStats.WordCount,
+				"duration", fileStats.Duration, "elapsed", fileStats.Elapsed, "language", fileStats.Language,
+				"processing_ratio", processingRatio(fileStats.Duration, fileStats.Elapsed))
+			logFileSpeed(file, fileStats)
 		}
+
 	}
 
+	elapsed := time.Since(startTime)
+	report.finish(elapsed)
+	s.observers.OnBatchDone(report)
+
 	// Print summary statistics
-	if !s.opts.Quiet {
-		elapsed := time.Since(startTime)
-		fmt.Println("\n🎉 Transcription complete!")
-		fmt.Printf("📊 Summary: %d successful, %d failed\n", successCount, failedCount)
-		if totalWords > 0 {
-			fmt.Printf("📝 Total words transcribed: %d\n", totalWords)
-			fmt.Printf("⏱️  Total audio duration: %s\n", totalDuration.Round(time.Second))
-			fmt.Printf("🚀 Processing time: %s\n", elapsed.Round(time.Second))
-			if totalDuration > 0 {
-				ratio := elapsed.Seconds() / totalDuration.Seconds()
-				fmt.Printf("⚡ Speed: %.1fx realtime\n", 1.0/ratio)
-			}
+	logging.Blank()
+	slog.Info("🎉 Transcription complete!")
+	slog.Info(fmt.Sprintf("📊 Summary: %d successful, %d failed", successCount, len(failures)),
+		"succeeded", successCount, "failed", len(failures))
+	if totalWords > 0 {
+		slog.Info(fmt.Sprintf("📝 Total words transcribed: %d", totalWords), "words", totalWords)
+		slog.Info(fmt.Sprintf("⏱️  Total audio duration: %s", totalDuration.Round(time.Second)), "duration", totalDuration)
+		if len(languages) > 0 {
+			slog.Info(fmt.Sprintf("🌐 Languages: %s", formatLanguageCounts(languages)), "languages", languages)
+		}
+		slog.Info(fmt.Sprintf("🚀 Processing time: %s", elapsed.Round(time.Second)), "elapsed", elapsed)
+		if totalDuration > 0 {
+			ratio := elapsed.Seconds() / totalDuration.Seconds()
+			slog.Info(fmt.Sprintf("⚡ Speed: %.1fx realtime", 1.0/ratio), "realtime_factor", 1.0/ratio)
 		}
 	}
 
-	return nil
+	batchJournal.close(len(failures) == 0)
+
+	if err := s.writeReport(report); err != nil {
+		return err
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	batchErr := &BatchError{Failures: failures, Total: len(audioFiles)}
+
+	logging.Blank()
+	slog.Info("❌ Failed files:")
+	for _, failure := range failures {
+		slog.Error(fmt.Sprintf("   %s: %v", filepath.Base(failure.Path), failure.Err),
+			"file", failure.Path, "error", failure.Err)
+	}
+
+	// Leave a persistent record next to the transcripts for unattended runs
+	if s.opts.OutputDir != "" {
+		logPath, err := writeErrorLog(s.opts.OutputDir, batchErr)
+		if err != nil {
+			slog.Warn(fmt.Sprintf("⚠️  %v", err), "error", err)
+		} else {
+			slog.Info(fmt.Sprintf("📄 Error details written to %s", logPath), "path", logPath)
+		}
+	}
+
+	return batchErr
+}
+
+// printPlan prints what a run would do without running whisper. The files
+// the report has as skipped are listed with their reason.
+func (s *Service) printPlan(filesToProcess []string, report *Report) {
+	fmt.Println("📋 Dry run - no files will be transcribed")
+
+	for _, file := range filesToProcess {
+		fmt.Printf("   transcribe  %s -> %s\n", file, s.outputName(s.outputPaths(file)))
+	}
+
+	for _, file := range report.Files {
+		if file.Status != statusSkipped {
+			continue
+		}
+
+		reason := file.Reason
+		if reason == "already transcribed" {
+			reason = fmt.Sprintf("%s: %s", reason, s.outputPaths(file.Path))
+		}
+
+		fmt.Printf("   skip        %s (%s)\n", file.Path, reason)
+	}
+
+	fmt.Printf("\n📊 Plan: %d to transcribe, %d to skip\n", len(filesToProcess), report.Skipped)
 }
 
 // findAudioFiles discovers audio files from the input paths
 func (s *Service) findAudioFiles(inputs []string) ([]string, error) {
 	var audioFiles []string
 
-	supportedExts := []string{".mp3", ".m4a", ".wav", ".flac", ".mp4", ".aac", ".ogg"}
+	paths, err := s.expandInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, input := range paths {
+		// Remote inputs are downloaded when they are transcribed
+		if IsURL(input) {
+			audioFiles = append(audioFiles, input)
+			continue
+		}
 
-	for _, input := range inputs {
 		stat, err := os.Stat(input)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrInputNotFound, input)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("cannot access %s: %w", input, err)
 		}
@@ -196,7 +634,8 @@ func (s *Service) findAudioFiles(inputs []string) ([]string, error) {
 						return err
 					}
 
-					if !info.IsDir() && s.isAudioFile(path, supportedExts) {
+					if !info.IsDir() && s.isAudioFile(path, s.opts.AudioExtensions) && !isTaggedCopy(path) &&
+						s.nameIncluded(path) && s.modifiedSince(path, info) {
 						audioFiles = append(audioFiles, path)
 					}
 
@@ -211,7 +650,16 @@ func (s *Service) findAudioFiles(inputs []string) ([]string, error) {
 				for _, entry := range entries {
 					if !entry.IsDir() {
 						path := filepath.Join(input, entry.Name())
-						if s.isAudioFile(path, supportedExts) {
+						if !s.isAudioFile(path, s.opts.AudioExtensions) || isTaggedCopy(path) || !s.nameIncluded(path) {
+							continue
+						}
+
+						info, err := entry.Info()
+						if err != nil {
+							return nil, fmt.Errorf("cannot access %s: %w", path, err)
+						}
+
+						if s.modifiedSince(path, info) {
 							audioFiles = append(audioFiles, path)
 						}
 					}
@@ -223,7 +671,7 @@ func (s *Service) findAudioFiles(inputs []string) ([]string, error) {
 			}
 		} else {
 			// Handle file
-			if s.isAudioFile(input, supportedExts) {
+			if s.isAudioFile(input, s.opts.AudioExtensions) && s.nameIncluded(input) && s.modifiedSince(input, stat) {
 				audioFiles = append(audioFiles, input)
 			}
 		}
@@ -232,6 +680,103 @@ func (s *Service) findAudioFiles(inputs []string) ([]string, error) {
 	return audioFiles, nil
 }
 
+// expandInputs expands glob patterns in the inputs to the paths they match.
+// This covers quoted patterns and shells that don't expand globs themselves.
+// Inputs without glob metacharacters, or that exist literally, are kept as-is.
+func (s *Service) expandInputs(inputs []string) ([]string, error) {
+	var paths []string
+
+	for _, input := range inputs {
+		if IsURL(input) || !hasGlobMeta(input) {
+			paths = append(paths, input)
+			continue
+		}
+
+		// A file may legitimately contain metacharacters in its name
+		if _, err := os.Stat(input); err == nil {
+			paths = append(paths, input)
+			continue
+		}
+
+		matches, err := doublestar.FilepathGlob(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern %s: %w", input, err)
+		}
+
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("%w: no files matched pattern %s", ErrInputNotFound, input)
+		}
+
+		paths = append(paths, matches...)
+	}
+
+	return paths, nil
+}
+
+// hasGlobMeta reports whether the path contains glob metacharacters
+func hasGlobMeta(path string) bool {
+	return strings.ContainsAny(path, "*?[{")
+}
+
+// filterByDuration drops files outside the --min-duration/--max-duration
+// limits and records them as skipped. Durations are read from the file
+// headers; files whose duration can't be determined, including remote
+// ones, are kept.
+func (s *Service) filterByDuration(files []string, report *Report) []string {
+	if s.opts.MinDuration <= 0 && s.opts.MaxDuration <= 0 {
+		return files
+	}
+
+	var kept []string
+	for _, file := range files {
+		if IsURL(file) {
+			kept = append(kept, file)
+			continue
+		}
+
+		duration, err := s.transcriber.AudioDuration(file)
+		if err != nil {
+			slog.Debug(fmt.Sprintf("⚠️  Could not read the duration of %s, keeping it", filepath.Base(file)),
+				"file", file, "error", err)
+			kept = append(kept, file)
+			continue
+		}
+
+		var reason string
+		switch {
+		case s.opts.MinDuration > 0 && duration < s.opts.MinDuration:
+			reason = fmt.Sprintf("shorter than --min-duration %s", s.opts.MinDuration)
+		case s.opts.MaxDuration > 0 && duration > s.opts.MaxDuration:
+			reason = fmt.Sprintf("longer than --max-duration %s", s.opts.MaxDuration)
+		default:
+			kept = append(kept, file)
+			continue
+		}
+
+		// A dry run lists the skips in its plan
+		if !s.opts.DryRun {
+			slog.Info(fmt.Sprintf("⏭️  Skipping %s (%s, %s)", filepath.Base(file), duration.Round(time.Second), reason),
+				"file", file, "duration", duration, "reason", reason)
+		}
+		report.addSkipped(file, reason)
+	}
+
+	return kept
+}
+
+// modifiedSince reports whether a file was modified at or after the --since
+// cutoff, logging the files it filters out
+func (s *Service) modifiedSince(path string, info os.FileInfo) bool {
+	if s.opts.Since.IsZero() || !info.ModTime().Before(s.opts.Since) {
+		return true
+	}
+
+	slog.Debug(fmt.Sprintf("⏭️  Skipping %s (modified %s)", filepath.Base(path), info.ModTime().Format("2006-01-02 15:04")),
+		"file", path, "modified", info.ModTime())
+
+	return false
+}
+
 // isAudioFile checks if the file has a supported audio extension
 func (s *Service) isAudioFile(path string, supportedExts []string) bool {
 	ext := strings.ToLower(filepath.Ext(path))
@@ -246,19 +791,25 @@ func (s *Service) isAudioFile(path string, supportedExts []string) bool {
 
 // FileStats holds transcription statistics for a single file
 type FileStats struct {
-	WordCount int
-	Duration  time.Duration
+	WordCount  int
+	Duration   time.Duration
+	Elapsed    time.Duration
+	OutputPath string
+	Language   string
+
+	segments []transcribe.Segment // Final segments for observers, see observedSegments
 }
 
 // transcribeFile transcribes a single audio file and returns statistics
-func (s *Service) transcribeFile(inputPath string) (*FileStats, error) {
-	// Get audio duration before processing
-	audioInfo, err := s.audioProcessor.GetAudioInfo(inputPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get audio info: %w", err)
-	}
+func (s *Service) transcribeFile(batchCtx context.Context, inputPath string) (*FileStats, error) {
+	startTime := time.Now()
 
-	duration := s.parseAudioDuration(audioInfo["duration"])
+	ctx := batchCtx
+	if s.opts.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(batchCtx, s.opts.Timeout)
+		defer cancel()
+	}
 
 	// Determine output file path
 	outputPath := s.getOutputPath(inputPath)
@@ -268,40 +819,268 @@ func (s *Service) transcribeFile(inputPath string) (*FileStats, error) {
 		return nil, fmt.Errorf("model preparation failed: %w", err)
 	}
 
-	// Step 2: Convert audio to WAV using FFmpeg if needed
-	wavPath, needsCleanup, err := s.prepareAudioFile(inputPath)
-	if err != nil {
-		return nil, fmt.Errorf("audio preparation failed: %w", err)
+	// Step 2: Fetch remote inputs
+	sourcePath := inputPath
+	if IsURL(inputPath) {
+		localPath, err := s.downloadAudio(ctx, inputPath)
+		if err != nil {
+			return nil, err
+		}
+
+		if s.opts.KeepDownload {
+			slog.Info(fmt.Sprintf("💾 Downloaded audio kept at %s", localPath), "path", localPath)
+		} else {
+			defer os.Remove(localPath)
+		}
+
+		sourcePath = localPath
 	}
 
-	// Clean up temporary WAV file if needed
-	if needsCleanup {
-		defer s.audioProcessor.Cleanup(wavPath)
+	if s.opts.SplitChapters {
+		chapters, err := s.transcriber.Chapters(sourcePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(chapters) > 0 {
+			slog.Debug(fmt.Sprintf("📖 %s has %d chapters, transcribing them separately", filepath.Base(inputPath),
+				len(chapters)), "file", inputPath, "chapters", len(chapters))
+
+			return s.transcribeChapters(batchCtx, ctx, inputPath, sourcePath, outputPath, chapters)
+		}
+
+		slog.Debug(fmt.Sprintf("📖 %s has no chapters, writing a single transcript", filepath.Base(inputPath)),
+			"file", inputPath)
 	}
 
-	// Step 3: Run Whisper inference
-	transcription, err := s.whisperClient.Transcribe(wavPath, s.opts.Model)
+	// Step 3: Convert audio and run Whisper inference
+	slog.Debug(fmt.Sprintf("🔄 Transcribing %s...", filepath.Base(inputPath)), "file", inputPath)
+
+	result, err := s.transcribeWithRetry(ctx, sourcePath, s.transcribeOptions())
 	if err != nil {
-		return nil, fmt.Errorf("transcription failed: %w", err)
+		// whisper/ffmpeg were killed at the deadline, report why
+		switch {
+		case errors.Is(batchCtx.Err(), context.DeadlineExceeded):
+			return nil, ErrBatchTimeout
+		case errors.Is(ctx.Err(), context.DeadlineExceeded):
+			return nil, fmt.Errorf("%w after %s", ErrTimeout, s.opts.Timeout)
+		}
+
+		return nil, err
+	}
+
+	logAudioTrack(inputPath, result.AudioTrack)
+	logDiagnostics(inputPath, result.Diagnostics)
+
+	for _, wavPath := range result.WAVPaths {
+		slog.Info(fmt.Sprintf("🔊 Converted audio kept at %s", wavPath), "file", inputPath, "path", wavPath)
 	}
 
 	// Count words in transcription
-	wordCount := s.countWords(transcription)
+	wordCount := s.countWords(result.Text, result.Language)
 
 	// Step 4: Format and save output
-	content := s.formatOutput(transcription, inputPath)
-	if err := os.WriteFile(outputPath, []byte(content), 0o644); err != nil {
-		return nil, fmt.Errorf("failed to write output file: %w", err)
+	content, err := s.formatOutput(result, inputPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.writeOutput(outputPath, content); err != nil {
+		return nil, err
+	}
+
+	// The other formats are rendered from the same transcription
+	outputPaths := []string{outputPath}
+	for _, format := range s.formats()[1:] {
+		content, err := s.formatOutputAs(result, inputPath, format)
+		if err != nil {
+			return nil, err
+		}
+
+		path := s.outputPathFor(inputPath, format)
+		if err := s.writeOutput(path, content); err != nil {
+			return nil, err
+		}
+
+		outputPaths = append(outputPaths, path)
+	}
+
+	if s.opts.SegmentOutput {
+		if err := s.writeSegments(result, inputPath, outputPath); err != nil {
+			return nil, err
+		}
+	}
+
+	// Step 5: Store the transcript in the audio file
+	if s.opts.Embed {
+		taggedPath, err := s.embedTranscript(ctx, inputPath, sourcePath, outputPath, result)
+		if err != nil {
+			return nil, err
+		}
+
+		slog.Info(fmt.Sprintf("🏷️  Transcript embedded in %s", taggedPath), "file", inputPath, "path", taggedPath)
+	}
+
+	if !s.opts.Stdout {
+		for i, format := range s.formats() {
+			if err := s.writeOutputRecord(inputPath, outputPaths[i], format); err != nil {
+				slog.Warn(fmt.Sprintf("⚠️  %v", err), "error", err)
+			}
+		}
+	}
+
+	// Step 6: Run the post-processing hook
+	if err := s.runHook(inputPath, outputPath); err != nil {
+		return nil, err
 	}
 
 	return &FileStats{
-		WordCount: wordCount,
-		Duration:  duration,
+		WordCount:  wordCount,
+		Duration:   result.Duration,
+		Elapsed:    time.Since(startTime),
+		OutputPath: s.outputName(strings.Join(outputPaths, ", ")),
+		Language:   result.Language,
+		segments:   s.observedSegments(result),
 	}, nil
 }
 
-// ensureModelDownloaded checks if the model exists and downloads it if needed
+// logFileSpeed reports in verbose output how long a file took relative to
+// its length, to spot slow recordings
+func logFileSpeed(path string, stats *FileStats) {
+	if stats.Duration <= 0 {
+		return
+	}
+
+	slog.Debug(fmt.Sprintf("⚡ %s: processed in %s, %.2fx audio duration (%.1fx realtime)",
+		filepath.Base(path), stats.Elapsed.Round(100*time.Millisecond),
+		processingRatio(stats.Duration, stats.Elapsed), realtimeFactor(stats.Duration, stats.Elapsed)),
+		"file", path, "elapsed", stats.Elapsed, "processing_ratio", processingRatio(stats.Duration, stats.Elapsed))
+}
+
+// formatLanguageCounts renders per-language file counts, most common first,
+// e.g. "en (3), de (1)"
+func formatLanguageCounts(languages map[string]int) string {
+	codes := make([]string, 0, len(languages))
+	for code := range languages {
+		codes = append(codes, code)
+	}
+
+	sort.Slice(codes, func(i, j int) bool {
+		if languages[codes[i]] != languages[codes[j]] {
+			return languages[codes[i]] > languages[codes[j]]
+		}
+
+		return codes[i] < codes[j]
+	})
+
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = fmt.Sprintf("%s (%d)", code, languages[code])
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// writeReport saves the JSON batch report when --report is set
+func (s *Service) writeReport(report *Report) error {
+	if s.opts.ReportPath == "" {
+		return nil
+	}
+
+	if err := report.write(s.opts.ReportPath); err != nil {
+		return err
+	}
+
+	slog.Info(fmt.Sprintf("📄 Report written to %s", s.opts.ReportPath), "path", s.opts.ReportPath)
+
+	return nil
+}
+
+// transcribeOptions returns the library options for transcribing one file
+func (s *Service) transcribeOptions() transcribe.Options {
+	return transcribe.Options{
+		Model:             s.opts.Model,
+		Language:          s.opts.Language,
+		Prompt:            s.opts.Prompt,
+		Normalize:         s.opts.Normalize,
+		Denoise:           s.opts.Denoise,
+		HighPass:          s.opts.HighPass,
+		ResampleQuality:   s.opts.Resample,
+		Start:             s.opts.Start,
+		End:               s.opts.End,
+		Stream:            s.opts.Stream,
+		KeepWAV:           s.opts.KeepWAV,
+		Diarize:           s.opts.Diarize,
+		WordTimestamps:    s.opts.WordTimestamps,
+		VAD:               s.opts.VAD,
+		BeamSize:          s.opts.BeamSize,
+		Temperature:       s.opts.Temperature,
+		EntropyThreshold:  s.opts.EntropyThreshold,
+		LogprobThreshold:  s.opts.LogprobThreshold,
+		NoSpeechThreshold: s.opts.NoSpeechThreshold,
+		NoFallback:        s.opts.NoFallback,
+		SuppressNonSpeech: s.opts.SuppressNonSpeech,
+		Channel:           s.opts.Channel,
+		SplitChannels:     s.opts.SplitChannels,
+		AudioTrack:        s.opts.AudioTrack,
+		AudioLanguage:     s.opts.AudioLanguage,
+		NativeSubtitles:   s.opts.NativeSubtitles && s.writesSubtitles(),
+	}
+}
+
+// logAudioTrack tells which audio stream of an input with several was
+// transcribed
+func logAudioTrack(inputPath string, track transcribe.AudioTrack) {
+	if track.Count < 2 {
+		return
+	}
+
+	language := track.Language
+	if language == "" {
+		language = "untagged"
+	}
+
+	slog.Debug(fmt.Sprintf("🎧 %s: transcribed audio track %d of %d (%s)", filepath.Base(inputPath),
+		track.Number, track.Count, language),
+		"file", inputPath, "track", track.Number, "tracks", track.Count, "track_language", track.Language)
+}
+
+// logDiagnostics shows whisper's model, warning and timing lines in verbose
+// output, to diagnose slow runs and model issues
+func logDiagnostics(inputPath string, diagnostics []string) {
+	for _, line := range diagnostics {
+		slog.Debug(fmt.Sprintf("🔧 %s: %s", filepath.Base(inputPath), line), "file", inputPath, "whisper", line)
+	}
+}
+
+// transcribeWithRetry runs the transcription, retrying transient whisper
+// failures up to the configured number of extra attempts
+func (s *Service) transcribeWithRetry(ctx context.Context, path string, opts transcribe.Options) (*transcribe.Result, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := s.transcriber.Transcribe(ctx, path, opts)
+		if err == nil && result.StreamFallback {
+			slog.Warn("⚠️  This whisper-cli can't read audio from stdin, --stream falls back to WAV files",
+				"file", path)
+		}
+
+		if err == nil || attempt > s.opts.Retries || !errors.Is(err, transcribe.ErrTransient) {
+			return result, err
+		}
+
+		slog.Debug(fmt.Sprintf("🔁 Whisper failed on %s without output, retrying (%d/%d)...",
+			filepath.Base(path), attempt, s.opts.Retries), "file", path, "attempt", attempt, "error", err)
+	}
+}
+
+// ensureModelDownloaded checks if the model exists and downloads it if
+// needed. In an interactive terminal a missing or unknown model opens the
+// model picker, whose choice is used for the rest of the run.
 func (s *Service) ensureModelDownloaded() error {
+	// Custom model files bypass the registry, they only need to be valid
+	if models.IsCustomPath(s.opts.Model) {
+		return models.ValidateModelFile(s.opts.Model)
+	}
+
 	availableModels := s.modelManager.AvailableModels()
 
 	var targetModel *models.ModelInfo
@@ -313,114 +1092,217 @@ func (s *Service) ensureModelDownloaded() error {
 		}
 	}
 
+	if targetModel != nil {
+		if _, err := os.Stat(targetModel.Path); err == nil {
+			s.modelManager.MarkUsed(targetModel.Name)
+			return nil
+		}
+	}
+
+	if s.interactive() {
+		picked, err := s.modelManager.Pick(os.Stdin, os.Stderr, s.opts.Model)
+		if err != nil {
+			return err
+		}
+
+		slog.Info(fmt.Sprintf("🎯 Using model %s", picked), "model", picked)
+		s.opts.Model = picked
+
+		return s.modelManager.Download(picked)
+	}
+
 	if targetModel == nil {
 		return fmt.Errorf("unknown model: %s", s.opts.Model)
 	}
 
-	// Check if model file exists
-	if _, err := os.Stat(targetModel.Path); os.IsNotExist(err) {
-		if !s.opts.Quiet {
-			fmt.Printf("📥 Model %s not found, downloading...\n", s.opts.Model)
+	slog.Info(fmt.Sprintf("📥 Model %s not found, downloading...", s.opts.Model), "model", s.opts.Model)
+
+	return s.modelManager.Download(s.opts.Model)
+}
+
+// prepareModel downloads a registry model that is missing and returns the
+// size of the model file, which tells how large the model is. Unlike
+// ensureModelDownloaded, the model picker is never shown: it's for runs
+// that name several models explicitly.
+func (s *Service) prepareModel(name string) (int64, error) {
+	path := name
+
+	if models.IsCustomPath(name) {
+		if err := models.ValidateModelFile(name); err != nil {
+			return 0, err
 		}
+	} else {
+		var target *models.ModelInfo
+
+		availableModels := s.modelManager.AvailableModels()
+		for i, model := range availableModels {
+			if model.Name == name {
+				target = &availableModels[i]
+				break
+			}
+		}
+
+		if target == nil {
+			return 0, fmt.Errorf("unknown model: %s (see ghospel models list)", name)
+		}
+
+		if _, err := os.Stat(target.Path); errors.Is(err, os.ErrNotExist) {
+			slog.Info(fmt.Sprintf("📥 Model %s not found, downloading...", name), "model", name)
 
-		return s.modelManager.Download(s.opts.Model)
+			if err := s.modelManager.Download(name); err != nil {
+				return 0, fmt.Errorf("model preparation failed: %w", err)
+			}
+		} else {
+			s.modelManager.MarkUsed(name)
+		}
+
+		path = target.Path
 	}
 
-	return nil
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, fmt.Errorf("model preparation failed: %w", err)
+	}
+
+	return info.Size(), nil
+}
+
+// interactive reports whether the user can be asked questions: stdin and
+// stderr are terminals and neither --quiet nor structured logs are used
+func (s *Service) interactive() bool {
+	return !s.opts.Quiet && !logging.Structured() &&
+		term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stderr.Fd()))
 }
 
-// prepareAudioFile converts audio to WAV format if needed
-func (s *Service) prepareAudioFile(inputPath string) (string, bool, error) {
-	// Check if file is already in WAV format
-	ext := strings.ToLower(filepath.Ext(inputPath))
-	if ext == ".wav" {
-		// TODO: Check if it's 16kHz mono, if not, still convert
-		return inputPath, false, nil
+// prepareOutputDir creates the output directory, or the directory of the
+// output path, if one was given and checks
+// that transcripts can be written to it. Dry runs only check that the path
+// isn't an existing file.
+func (s *Service) prepareOutputDir() error {
+	dir := s.opts.OutputDir
+	if s.opts.OutputPath != "" {
+		dir = filepath.Dir(s.opts.OutputPath)
+	}
+
+	if dir == "" {
+		return nil
+	}
+
+	if stat, err := os.Stat(dir); err == nil && !stat.IsDir() {
+		return fmt.Errorf("output directory %s exists but is not a directory", dir)
 	}
 
-	// Convert to WAV
-	if !s.opts.Quiet && s.opts.Verbose {
-		fmt.Printf("🔄 Converting %s to WAV format...\n", filepath.Base(inputPath))
+	if s.opts.DryRun {
+		return nil
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("cannot create output directory %s: %w", dir, err)
 	}
 
-	wavPath, err := s.audioProcessor.ConvertToWav(inputPath)
+	probe, err := os.CreateTemp(dir, ".ghospel-write-check-*")
 	if err != nil {
-		return "", false, err
+		return fmt.Errorf("output directory %s is not writable: %w", dir, err)
 	}
 
-	return wavPath, true, nil
+	probe.Close()
+	os.Remove(probe.Name())
+
+	return nil
 }
 
-// formatOutput formats the transcription output
-func (s *Service) formatOutput(transcription, inputPath string) string {
-	var content strings.Builder
+// isTranscribed reports whether the file already has an up-to-date output
+// and should be skipped. Stale outputs (see staleReason) are transcribed
+// again. It always returns false unless the overwrite policy is skip.
+func (s *Service) isTranscribed(inputPath string) bool {
+	if s.overwritePolicy() != OverwriteSkip || s.opts.Stdout {
+		return false
+	}
 
-	// Add header comment
-	content.WriteString(fmt.Sprintf("# Transcription of: %s\n", filepath.Base(inputPath)))
-	content.WriteString(fmt.Sprintf("# Model: %s\n", s.opts.Model))
-	content.WriteString("# Generated with Ghospel v0.1.0\n\n")
+	// Every format has to be there, a missing one is written again
+	for _, format := range s.formats() {
+		outputPath := s.outputPathFor(inputPath, format)
 
-	// Format the transcription into readable paragraphs
-	formatter := NewTextFormatter()
-	formattedText := formatter.Format(transcription)
+		info, err := os.Stat(outputPath)
+		if err != nil {
+			if !s.opts.SplitChapters || !hasChapterOutputs(outputPath) {
+				return false
+			}
 
-	// Add the formatted transcription
-	content.WriteString(formattedText)
-	content.WriteString("\n")
+			continue
+		}
 
-	return content.String()
-}
+		if reason := s.staleReason(inputPath, outputPath, format, info); reason != "" {
+			slog.Info(fmt.Sprintf("🔄 %s is out of date, %s", filepath.Base(outputPath), reason),
+				"file", inputPath, "output", outputPath, "reason", reason)
 
-// getOutputPath determines the output file path
-func (s *Service) getOutputPath(inputPath string) string {
-	dir := filepath.Dir(inputPath)
-	if s.opts.OutputDir != "" {
-		dir = s.opts.OutputDir
-		// Ensure output directory exists
-		os.MkdirAll(dir, 0o755)
+			return false
+		}
 	}
 
-	base := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
-	ext := "." + s.opts.Format
-
-	return filepath.Join(dir, base+ext)
+	return true
 }
 
-// parseAudioDuration parses FFmpeg duration format (HH:MM:SS.ms) into time.Duration
-func (s *Service) parseAudioDuration(durationStr string) time.Duration {
-	if durationStr == "" {
-		return 0
+// writeOutput saves a transcript to its output file, or prints it in stdout
+// mode. Status output goes to stderr, so stdout stays pipeable.
+func (s *Service) writeOutput(outputPath, content string) error {
+	if s.opts.Stdout {
+		if !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+
+		if _, err := os.Stdout.Write(encodeText(content, EncodingUTF8)); err != nil {
+			return fmt.Errorf("failed to write transcript to stdout: %w", err)
+		}
+
+		return nil
 	}
 
-	// Parse format like "00:01:23.45"
-	parts := strings.Split(durationStr, ":")
-	if len(parts) != 3 {
-		return 0
+	if err := os.WriteFile(outputPath, encodeText(content, s.encoding()), 0o644); err != nil {
+		return fmt.Errorf("failed to write output file: %w", err)
 	}
 
-	// Extract hours, minutes, and seconds
-	var hours, minutes, seconds float64
-	if h, err := time.ParseDuration(parts[0] + "h"); err == nil {
-		hours = h.Seconds()
+	return nil
+}
+
+// outputName is where a transcript ends up for reporting, "-" in stdout mode
+func (s *Service) outputName(outputPath string) string {
+	if s.opts.Stdout {
+		return "-"
 	}
-	if m, err := time.ParseDuration(parts[1] + "m"); err == nil {
-		minutes = m.Seconds()
+
+	return outputPath
+}
+
+// getOutputPath determines the output file path of the primary format,
+// OutputPath if given. Transcripts of URL inputs go to the current
+// directory unless an output directory is set. With the rename policy,
+// taken paths get a numeric suffix, e.g. "talk-2.txt".
+func (s *Service) getOutputPath(inputPath string) string {
+	return s.outputPathFor(inputPath, s.opts.Format)
+}
+
+// TranscribedDuration is how much audio of a local file a transcription
+// covers: its duration, limited to the --start/--end range
+func (s *Service) TranscribedDuration(path string) (time.Duration, error) {
+	duration, err := s.transcriber.AudioDuration(path)
+	if err != nil {
+		return 0, err
 	}
-	if s, err := time.ParseDuration(parts[2] + "s"); err == nil {
-		seconds = s.Seconds()
+
+	if s.opts.End > 0 && s.opts.End < duration {
+		duration = s.opts.End
 	}
 
-	totalSeconds := hours + minutes + seconds
-	return time.Duration(totalSeconds * float64(time.Second))
+	return duration - s.opts.Start, nil
 }
 
-// countWords counts the number of words in a text string
-func (s *Service) countWords(text string) int {
-	if text == "" {
-		return 0
+// countWords counts the words of a transcript in the detected language, or
+// the selected one if detection didn't report any
+func (s *Service) countWords(text, language string) int {
+	if language == "" {
+		language = s.opts.Language
 	}
 
-	// Split by whitespace and count non-empty parts
-	words := strings.Fields(strings.TrimSpace(text))
-	return len(words)
+	return wordCount(text, language)
 }
@@ -0,0 +1,157 @@
+package transcription
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/pascalwhoop/ghospel/internal/logging"
+)
+
+// fakeWhisper is a whisper-cli stand-in printing a fixed transcript
+const fakeWhisper = `#!/bin/sh
+[ "$1" = "--help" ] && exit 0
+echo "[00:00:00.000 --> 00:00:02.000]  Hello there."
+echo "[00:00:02.000 --> 00:00:04.000]  General Kenobi."
+`
+
+// writeSilentWav writes a second of 16kHz mono 16-bit silence, which whisper
+// reads as is, so the test needs no ffmpeg
+func writeSilentWav(t *testing.T, path string) {
+	t.Helper()
+
+	const sampleRate, samples = 16000, 16000
+
+	data := make([]byte, 44+samples*2)
+	copy(data[0:], "RIFF")
+	binary.LittleEndian.PutUint32(data[4:], uint32(len(data)-8))
+	copy(data[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(data[16:], 16)           // fmt chunk size
+	binary.LittleEndian.PutUint16(data[20:], 1)            // PCM
+	binary.LittleEndian.PutUint16(data[22:], 1)            // Mono
+	binary.LittleEndian.PutUint32(data[24:], sampleRate)   // Sample rate
+	binary.LittleEndian.PutUint32(data[28:], sampleRate*2) // Byte rate
+	binary.LittleEndian.PutUint16(data[32:], 2)            // Block align
+	binary.LittleEndian.PutUint16(data[34:], 16)           // Bits per sample
+	copy(data[36:], "data")
+	binary.LittleEndian.PutUint32(data[40:], samples*2)
+
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// captureOutput redirects stdout and the status output to files until the
+// test ends and returns them
+func captureOutput(t *testing.T) (stdout, stderr *os.File) {
+	t.Helper()
+
+	dir := t.TempDir()
+
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stderr, err = os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	origStdout, origStderr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+
+	if err := logging.Setup(stderr, logging.FormatText); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		os.Stdout, os.Stderr = origStdout, origStderr
+		_ = logging.Setup(origStderr, logging.FormatText)
+		stdout.Close()
+		stderr.Close()
+	})
+
+	return stdout, stderr
+}
+
+func TestStdoutOnlyHasTranscript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake whisper is a shell script")
+	}
+
+	dir := t.TempDir()
+
+	whisperPath := filepath.Join(dir, "whisper-cli")
+	if err := os.WriteFile(whisperPath, []byte(fakeWhisper), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	cacheDir := filepath.Join(dir, "cache")
+	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := os.WriteFile(filepath.Join(cacheDir, "ggml-base.bin"), make([]byte, 1000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	input := filepath.Join(dir, "talk.wav")
+	writeSilentWav(t, input)
+
+	stdout, stderr := captureOutput(t)
+
+	service := NewService(Options{
+		Model:       "base",
+		Format:      "txt",
+		Workers:     1,
+		Stdout:      true,
+		CacheDir:    cacheDir,
+		TempDir:     filepath.Join(dir, "tmp"),
+		WhisperPath: whisperPath,
+	})
+	defer service.Close()
+
+	if err := service.TranscribeFiles([]string{input}); err != nil {
+		t.Fatalf("TranscribeFiles: %v", err)
+	}
+
+	out, err := os.ReadFile(stdout.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := os.ReadFile(stderr.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(out), "Hello there.") || !strings.Contains(string(out), "General Kenobi.") {
+		t.Errorf("stdout is missing the transcript:\n%s", out)
+	}
+
+	// Status lines carry emoji such as ✅ or 📊, the transcript has none
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.ContainsFunc(line, func(r rune) bool { return unicode.Is(unicode.So, r) }) {
+			t.Errorf("status line on stdout: %q", line)
+		}
+	}
+
+	for _, status := range []string{"Found", "Total words", "Processing time"} {
+		if strings.Contains(string(out), status) {
+			t.Errorf("stdout contains status output %q:\n%s", status, out)
+		}
+	}
+
+	if len(status) == 0 {
+		t.Error("no status output on stderr, is it captured?")
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "talk.txt")); !os.IsNotExist(err) {
+		t.Error("stdout mode wrote a transcript file")
+	}
+}
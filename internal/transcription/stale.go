@@ -0,0 +1,94 @@
+package transcription
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// outputRecord describes how a transcript was made. It is kept in a hidden
+// file next to the transcript so later runs can tell whether it is stale.
+type outputRecord struct {
+	Source         string    `json:"source"`
+	SourceModified time.Time `json:"source_modified,omitzero"` // Zero for URL inputs
+	Model          string    `json:"model"`
+	Format         string    `json:"format"`
+	TranscribedAt  time.Time `json:"transcribed_at"`
+}
+
+// recordPath is the record file of a transcript, e.g. ".talk.txt.ghospel.json"
+// for "talk.txt"
+func recordPath(outputPath string) string {
+	return filepath.Join(filepath.Dir(outputPath), "."+filepath.Base(outputPath)+".ghospel.json")
+}
+
+// writeOutputRecord records the model, format and source version a
+// transcript was made from
+func (s *Service) writeOutputRecord(inputPath, outputPath, format string) error {
+	record := outputRecord{
+		Source:        inputPath,
+		Model:         s.opts.Model,
+		Format:        format,
+		TranscribedAt: time.Now(),
+	}
+
+	if !IsURL(inputPath) {
+		if info, err := os.Stat(inputPath); err == nil {
+			record.SourceModified = info.ModTime()
+		}
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode transcript record: %w", err)
+	}
+
+	if err := os.WriteFile(recordPath(outputPath), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("failed to write transcript record: %w", err)
+	}
+
+	return nil
+}
+
+// staleReason tells why the existing transcript at outputPath in the given
+// format is out of date, or returns "" if it can be kept: the source changed after it was
+// transcribed, or a different model or format was used. Transcripts without
+// a record, e.g. from older versions, are only compared by modification time.
+func (s *Service) staleReason(inputPath, outputPath, format string, output os.FileInfo) string {
+	var record outputRecord
+
+	data, err := os.ReadFile(recordPath(outputPath))
+	hasRecord := err == nil && json.Unmarshal(data, &record) == nil
+
+	if hasRecord && record.Model != s.opts.Model {
+		return fmt.Sprintf("it was transcribed with model %s", record.Model)
+	}
+
+	if hasRecord && record.Format != "" && record.Format != format {
+		return fmt.Sprintf("it was written as %s", record.Format)
+	}
+
+	if IsURL(inputPath) {
+		return ""
+	}
+
+	source, err := os.Stat(inputPath)
+	if err != nil {
+		return ""
+	}
+
+	// Tagging the source in place changes it after the transcript was
+	// written, the record has its modification time from after that
+	modified := output.ModTime()
+	if hasRecord && !record.SourceModified.IsZero() {
+		modified = record.SourceModified
+	}
+
+	if source.ModTime().After(modified) {
+		return "the audio changed since it was transcribed"
+	}
+
+	return ""
+}
@@ -0,0 +1,123 @@
+package transcription
+
+import (
+	"strings"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/pkg/transcribe"
+)
+
+// SubtitleOptions limits the size of SRT and VTT cues, zero values disable
+// the respective limit
+type SubtitleOptions struct {
+	MaxLineChars   int           // Maximum characters per cue, e.g. 42
+	MaxCueDuration time.Duration // Maximum time a cue stays on screen, e.g. 7s
+}
+
+// splitCues splits segments that exceed the subtitle limits into several
+// cues. Split points fall between words; cue timestamps come from the word
+// timings when available and are otherwise interpolated in proportion to
+// the characters spoken.
+func splitCues(segments []transcribe.Segment, opts SubtitleOptions) []transcribe.Segment {
+	if opts.MaxLineChars <= 0 && opts.MaxCueDuration <= 0 {
+		return segments
+	}
+
+	var cues []transcribe.Segment
+
+	for _, seg := range segments {
+		if opts.fits(seg.Text, seg.End-seg.Start) {
+			cues = append(cues, seg)
+			continue
+		}
+
+		words := seg.Words
+		if len(words) == 0 {
+			words = interpolateWords(seg)
+		}
+
+		var cue []transcribe.Word
+		flush := func() {
+			if len(cue) == 0 {
+				return
+			}
+
+			split := transcribe.Segment{
+				Start:   cue[0].Start,
+				End:     cue[len(cue)-1].End,
+				Text:    joinWords(cue),
+				Speaker: seg.Speaker,
+			}
+			if len(seg.Words) > 0 {
+				split.Words = cue
+			}
+
+			cues = append(cues, split)
+			cue = nil
+		}
+
+		for _, word := range words {
+			// A single word over the limits still gets a cue of its own
+			if len(cue) > 0 && !opts.fits(joinWords(cue)+" "+word.Text, word.End-cue[0].Start) {
+				flush()
+			}
+
+			cue = append(cue, word)
+		}
+
+		flush()
+	}
+
+	return cues
+}
+
+// fits reports whether a cue with the given text and duration is within
+// the limits
+func (o SubtitleOptions) fits(text string, duration time.Duration) bool {
+	if o.MaxLineChars > 0 && len([]rune(text)) > o.MaxLineChars {
+		return false
+	}
+
+	return o.MaxCueDuration <= 0 || duration <= o.MaxCueDuration
+}
+
+// interpolateWords spreads a segment's duration over its words in
+// proportion to their length, counting the following space
+func interpolateWords(seg transcribe.Segment) []transcribe.Word {
+	fields := strings.Fields(seg.Text)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	total := 0
+	for _, field := range fields {
+		total += len([]rune(field)) + 1
+	}
+
+	span := seg.End - seg.Start
+	words := make([]transcribe.Word, len(fields))
+
+	offset := 0
+	for i, field := range fields {
+		start := seg.Start + span*time.Duration(offset)/time.Duration(total)
+		offset += len([]rune(field)) + 1
+
+		words[i] = transcribe.Word{
+			Start: start,
+			End:   seg.Start + span*time.Duration(offset)/time.Duration(total),
+			Text:  field,
+		}
+	}
+
+	return words
+}
+
+// joinWords joins the words of a cue back into its text
+func joinWords(words []transcribe.Word) string {
+	texts := make([]string, len(words))
+	for i, word := range words {
+		texts[i] = word.Text
+	}
+
+	return strings.Join(texts, " ")
+}
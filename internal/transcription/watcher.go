@@ -0,0 +1,187 @@
+package transcription
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pascalwhoop/ghospel/internal/logging"
+)
+
+// settleDelay is how long a file's size must stay unchanged before it is
+// considered fully written and handed to whisper
+const settleDelay = 3 * time.Second
+
+// pendingFile tracks a file that changed recently and may still be growing
+type pendingFile struct {
+	size    int64
+	changed time.Time
+}
+
+// Watch transcribes any untranscribed files in the given directories, then
+// keeps monitoring them and transcribes new audio files as they land. It runs
+// until the context is cancelled.
+func (s *Service) Watch(ctx context.Context, dirs []string) error {
+	for _, dir := range dirs {
+		stat, err := os.Stat(dir)
+		if err != nil {
+			return fmt.Errorf("cannot access %s: %w", dir, err)
+		}
+
+		if !stat.IsDir() {
+			return fmt.Errorf("watch mode requires directories, got file: %s", dir)
+		}
+	}
+
+	// Catch up on files that arrived while we weren't watching. Individual
+	// failures are already reported and shouldn't stop the watch.
+	var batchErr *BatchError
+	if err := s.TranscribeFiles(dirs); err != nil && !errors.Is(err, ErrNoAudioFiles) && !errors.As(err, &batchErr) {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	for _, dir := range dirs {
+		if err := s.addWatchDir(watcher, dir); err != nil {
+			return err
+		}
+	}
+
+	slog.Info(fmt.Sprintf("👀 Watching %s for new audio files (Ctrl+C to stop)", strings.Join(dirs, ", ")), "dirs", dirs)
+
+	pending := make(map[string]*pendingFile)
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logging.Blank()
+			slog.Info("👋 Stopped watching")
+
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+				continue
+			}
+
+			// Start watching directories created below a recursive watch root
+			if stat, err := os.Stat(event.Name); err == nil && stat.IsDir() {
+				if s.opts.Recursive {
+					if err := s.addWatchDir(watcher, event.Name); err != nil {
+						slog.Debug(fmt.Sprintf("⚠️  %v", err), "error", err)
+					}
+				}
+
+				continue
+			}
+
+			if s.isAudioFile(event.Name, s.opts.AudioExtensions) && !isTaggedCopy(event.Name) && s.nameIncluded(event.Name) {
+				pending[event.Name] = &pendingFile{size: -1, changed: time.Now()}
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			slog.Debug(fmt.Sprintf("⚠️  Watch error: %v", err), "error", err)
+
+		case <-ticker.C:
+			for path, file := range pending {
+				stat, err := os.Stat(path)
+				if err != nil {
+					// File was removed or renamed before it settled
+					delete(pending, path)
+					continue
+				}
+
+				if stat.Size() != file.size {
+					file.size = stat.Size()
+					file.changed = time.Now()
+
+					continue
+				}
+
+				if time.Since(file.changed) < settleDelay {
+					continue
+				}
+
+				delete(pending, path)
+				s.transcribeWatched(ctx, path)
+			}
+		}
+	}
+}
+
+// addWatchDir registers a directory with the watcher, including all of its
+// subdirectories when running recursively
+func (s *Service) addWatchDir(watcher *fsnotify.Watcher, dir string) error {
+	if !s.opts.Recursive {
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("cannot watch %s: %w", dir, err)
+		}
+
+		return nil
+	}
+
+	return filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if err := watcher.Add(path); err != nil {
+				return fmt.Errorf("cannot watch %s: %w", path, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// transcribeWatched transcribes a single file picked up by the watcher
+func (s *Service) transcribeWatched(ctx context.Context, path string) {
+	if s.isTranscribed(path) {
+		slog.Debug(fmt.Sprintf("⏭️  Skipping %s (already transcribed)", filepath.Base(path)), "file", path)
+
+		return
+	}
+
+	// The watcher keeps no report, the skip is only logged
+	if len(s.filterByDuration([]string{path}, &Report{})) == 0 {
+		return
+	}
+
+	slog.Info(fmt.Sprintf("🎵 New file: %s", filepath.Base(path)), "file", path)
+
+	fileStats, err := s.transcribeFile(ctx, path)
+	if err != nil {
+		slog.Error(fmt.Sprintf("❌ Failed to transcribe %s: %v", filepath.Base(path), err), "file", path, "error", err)
+		return
+	}
+
+	slog.Info(fmt.Sprintf("✅ Transcribed: %s (%d words, %s duration, language: %s)",
+		filepath.Base(path), fileStats.WordCount, fileStats.Duration.Round(time.Second), fileStats.Language),
+		"file", path, "output", fileStats.OutputPath, "words", fileStats.WordCount,
+		"duration", fileStats.Duration, "elapsed", fileStats.Elapsed, "language", fileStats.Language,
+		"processing_ratio", processingRatio(fileStats.Duration, fileStats.Elapsed))
+	logFileSpeed(path, fileStats)
+}
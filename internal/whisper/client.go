@@ -1,11 +1,18 @@
 package whisper
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/pascalwhoop/ghospel/internal/binaries"
 )
@@ -14,17 +21,20 @@ import (
 type Client struct {
 	whisperBinaryPath string
 	modelsDir         string
+	lookedUp          []string // Where findWhisperBinary looked, for error messages
 }
 
 // NewClient creates a new whisper client
 func NewClient(whisperBinaryPath, modelsDir string) *Client {
+	lookedUp := []string{whisperBinaryPath}
 	if whisperBinaryPath == "" {
-		whisperBinaryPath = findWhisperBinary()
+		whisperBinaryPath, lookedUp = findWhisperBinary()
 	}
 
 	return &Client{
 		whisperBinaryPath: whisperBinaryPath,
 		modelsDir:         modelsDir,
+		lookedUp:          lookedUp,
 	}
 }
 
@@ -32,88 +42,484 @@ func NewClient(whisperBinaryPath, modelsDir string) *Client {
 // 1. Embedded binary (release builds)
 // 2. Development build location
 // 3. System PATH
-func findWhisperBinary() string {
+//
+// It also returns the locations it tried, in order.
+func findWhisperBinary() (string, []string) {
 	// First, try embedded binary (release builds)
+	lookedUp := []string{"embedded binary"}
 	if binaries.IsEmbeddedBinaryAvailable() {
 		if path, err := binaries.ExtractWhisperBinary(); err == nil {
-			return path
+			return path, lookedUp
 		}
 	}
 
 	// Second, try development build location
 	devPath := "./whisper_cpp_source/build/bin/whisper-cli"
+	lookedUp = append(lookedUp, devPath)
 	if _, err := os.Stat(devPath); err == nil {
-		return devPath
+		return devPath, lookedUp
 	}
 
 	// Third, try system PATH
+	lookedUp = append(lookedUp, "whisper-cli on PATH")
 	if path, err := exec.LookPath("whisper-cli"); err == nil {
-		return path
+		return path, lookedUp
 	}
 
 	// Fallback to development path (will fail gracefully if not found)
-	return devPath
+	return devPath, lookedUp
 }
 
-// Transcribe transcribes an audio file using the specified model
-func (c *Client) Transcribe(audioPath, modelName string) (string, error) {
-	// Construct model path
-	modelPath := filepath.Join(c.modelsDir, fmt.Sprintf("ggml-%s.bin", modelName))
+// ErrNotAvailable is returned by Check when the whisper binary can't be run
+var ErrNotAvailable = errors.New("whisper binary not available")
 
-	// Build whisper command with Metal GPU acceleration (default enabled)
-	cmd := exec.Command(c.whisperBinaryPath,
+// ErrTransient marks whisper failures that are worth retrying: the process
+// exited non-zero without producing a transcript or a recognizable error, as
+// happens with intermittent Metal/driver hiccups
+var ErrTransient = errors.New("transient whisper failure")
+
+// ErrStdinUnsupported is returned by TranscribeStream when the whisper-cli
+// build can't read audio from stdin
+var ErrStdinUnsupported = errors.New("whisper-cli cannot read audio from stdin")
+
+// permanentFailureMarkers are whisper-cli messages that retrying won't fix
+var permanentFailureMarkers = []string{
+	"failed to load model",
+	"failed to open",
+	"failed to read",
+	"unknown argument",
+	"error: input file not found",
+	"error: unknown language",
+}
+
+// speakerTurnMarker is appended to segments by tinydiarize models when the
+// speaker changes after them
+const speakerTurnMarker = "[SPEAKER_TURN]"
+
+// Segment is a timed piece of transcribed text as reported by whisper
+type Segment struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+
+	// SpeakerTurn is set when a tinydiarize model detected a speaker change
+	// right after this segment
+	SpeakerTurn bool
+
+	// Words holds per-word timing when word timestamps were requested
+	Words []Word
+}
+
+// Word is a single word with its timing
+type Word struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// MaxBeamSize is the largest beam whisper.cpp supports (WHISPER_MAX_DECODERS)
+const MaxBeamSize = 8
+
+// maxWordsPerSegment caps segments rebuilt from word-level output
+const maxWordsPerSegment = 15
+
+// Options configures a single whisper run
+type Options struct {
+	Language    string // Language code, or "auto" to let whisper detect it
+	Prompt      string // Initial prompt to steer vocabulary and style
+	TinyDiarize bool   // Emit speaker-turn markers, needs a tdrz model
+
+	// WordTimestamps makes whisper emit one segment per word, which are
+	// regrouped into sentence-sized segments carrying the word timings
+	WordTimestamps bool
+
+	BeamSize    int     // Beam search width, 0 uses whisper's default
+	Temperature float64 // Sampling temperature, 0 decodes greedily/deterministically
+
+	// Thresholds against hallucinations, 0 uses whisper's default (see the
+	// Default* constants)
+	EntropyThreshold  float64 // Decode again at a higher temperature above this entropy
+	LogprobThreshold  float64 // Decode again below this average log probability
+	NoSpeechThreshold float64 // Treat segments as silence above this no-speech probability
+
+	NoFallback        bool // Never decode again at higher temperatures
+	SuppressNonSpeech bool // Suppress non-speech tokens such as ♪ and [Music]
+
+	// OutputFormats has whisper write transcript files of its own besides
+	// printing the segments, any of "txt", "srt", "vtt" and "json", into
+	// OutputDir. Their content is returned in Transcript.Files; the caller
+	// removes OutputDir.
+	OutputFormats []string
+	OutputDir     string
+}
+
+// outputFlags are the whisper-cli flags writing each output file format
+var outputFlags = map[string]string{
+	"txt":  "--output-txt",
+	"srt":  "--output-srt",
+	"vtt":  "--output-vtt",
+	"json": "--output-json",
+}
+
+// outputName is the name whisper's output files get in Options.OutputDir,
+// followed by the format's extension
+const outputName = "transcript"
+
+// Whisper's defaults for the hallucination thresholds
+const (
+	DefaultEntropyThreshold  = 2.4
+	DefaultLogprobThreshold  = -1.0
+	DefaultNoSpeechThreshold = 0.6
+)
+
+// Transcript is the result of a whisper run
+type Transcript struct {
+	Segments []Segment
+
+	// Language is the language whisper transcribed in: the requested one, or
+	// the one it detected when running with "auto"
+	Language string
+
+	// Diagnostics are whisper's notable log lines: model loading, detected
+	// language, warnings and timings
+	Diagnostics []string
+
+	// Files are the files whisper wrote for Options.OutputFormats, by format
+	Files map[string]string
+}
+
+// detectedLanguageRegex matches whisper's "auto-detected language: en (p = 0.97)" line
+var detectedLanguageRegex = regexp.MustCompile(`auto-detected language: ([a-z]{2,3})(?: \(p = ([\d.]+)\))?`)
+
+// Detection is the language whisper detected in the audio
+type Detection struct {
+	Language    string
+	Probability float64 // Whisper's confidence, 0 to 1, 0 if not reported
+}
+
+// segmentRegex matches whisper's "[00:00:00.000 --> 00:00:05.000]   text" lines
+var segmentRegex = regexp.MustCompile(`^\[(\d+:\d{2}:\d{2}\.\d{3}) --> (\d+:\d{2}:\d{2}\.\d{3})\]\s*(.*)$`)
+
+// Transcribe transcribes an audio file using the specified model and returns
+// the timed segments whisper produced
+func (c *Client) Transcribe(ctx context.Context, audioPath, modelName string, opts Options) (*Transcript, error) {
+	return c.run(ctx, audioPath, nil, modelName, opts)
+}
+
+// TranscribeStream transcribes WAV audio read from r, which whisper-cli
+// receives on its stdin. Builds without stdin support fail with an error
+// wrapping ErrStdinUnsupported.
+func (c *Client) TranscribeStream(ctx context.Context, r io.Reader, modelName string, opts Options) (*Transcript, error) {
+	return c.run(ctx, "-", r, modelName, opts)
+}
+
+// run executes whisper-cli on audioPath, feeding it stdin if given
+func (c *Client) run(ctx context.Context, audioPath string, stdin io.Reader, modelName string, opts Options) (*Transcript, error) {
+	// Construct model path, absolute paths point at a custom model file
+	modelPath := modelName
+	if !filepath.IsAbs(modelName) {
+		modelPath = filepath.Join(c.modelsDir, fmt.Sprintf("ggml-%s.bin", modelName))
+	}
+
+	language := opts.Language
+	if language == "" {
+		language = "auto"
+	}
+
+	args := []string{
 		"-m", modelPath, // Model path
 		"-f", audioPath, // Audio file path
-		"--output-txt",                         // Output as text
-		"--output-file", "/tmp/ghospel_output", // Output file prefix
-		"--language", "en", // Language (can be made configurable)
+		"--language", language, // Language code or auto-detect
 		"--threads", "4", // Number of threads
 		"--flash-attn", // Enable flash attention for better performance
 		// Note: --no-gpu is NOT used, so GPU/Metal acceleration is enabled by default
-	)
+	}
+	if opts.Prompt != "" {
+		args = append(args, "--prompt", opts.Prompt)
+	}
+	if opts.TinyDiarize {
+		args = append(args, "--tinydiarize")
+	}
+	if opts.WordTimestamps {
+		args = append(args, "--max-len", "1", "--split-on-word")
+	}
+	if opts.BeamSize > 0 {
+		args = append(args, "--beam-size", strconv.Itoa(opts.BeamSize))
+	}
+	if opts.Temperature > 0 {
+		args = append(args, "--temperature", strconv.FormatFloat(opts.Temperature, 'f', -1, 64))
+	}
+	if opts.EntropyThreshold != 0 {
+		args = append(args, "--entropy-thold", strconv.FormatFloat(opts.EntropyThreshold, 'f', -1, 64))
+	}
+	if opts.LogprobThreshold != 0 {
+		args = append(args, "--logprob-thold", strconv.FormatFloat(opts.LogprobThreshold, 'f', -1, 64))
+	}
+	if opts.NoSpeechThreshold != 0 {
+		args = append(args, "--no-speech-thold", strconv.FormatFloat(opts.NoSpeechThreshold, 'f', -1, 64))
+	}
+	if opts.NoFallback {
+		args = append(args, "--no-fallback")
+	}
+	if opts.SuppressNonSpeech {
+		args = append(args, "--suppress-nst")
+	}
+	if len(opts.OutputFormats) > 0 {
+		args = append(args, "--output-file", filepath.Join(opts.OutputDir, outputName))
+		for _, format := range opts.OutputFormats {
+			flag, ok := outputFlags[format]
+			if !ok {
+				return nil, fmt.Errorf("whisper cannot write %s files", format)
+			}
+
+			args = append(args, flag)
+		}
+	}
+
+	// Build whisper command with Metal GPU acceleration (default enabled)
+	cmd := exec.CommandContext(ctx, c.whisperBinaryPath, args...)
+	cmd.Stdin = stdin
+
+	// The transcript is printed to stdout, whisper's log to stderr
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	output := stdout.String() + stderr.String()
+	if err != nil {
+		if stdin != nil && isStdinUnsupported(stderr.String()) {
+			return nil, fmt.Errorf("%w: %w\nOutput: %s", ErrStdinUnsupported, err, output)
+		}
+
+		if isTransientFailure(ctx, err, output) {
+			return nil, fmt.Errorf("%w: %w\nOutput: %s", ErrTransient, err, output)
+		}
+
+		return nil, fmt.Errorf("whisper transcription failed: %w\nOutput: %s", err, output)
+	}
+
+	// whisper-cli prints the timed segments to stdout. Output files are only
+	// written when asked for, into the caller's directory, so concurrent runs
+	// never share one.
+	segments := parseSegments(stdout.String())
+	if opts.WordTimestamps {
+		segments = groupWords(segments)
+	}
 
-	// Execute the command
-	output, err := cmd.CombinedOutput()
+	if len(segments) == 0 {
+		// Fallback: return the plain output if we couldn't parse it
+		if text := strings.TrimSpace(stdout.String()); text != "" {
+			segments = []Segment{{Text: text}}
+		}
+	}
+
+	transcript := &Transcript{Segments: segments, Language: language, Diagnostics: parseDiagnostics(stderr.String())}
+	if match := detectedLanguageRegex.FindStringSubmatch(output); match != nil {
+		transcript.Language = match[1]
+	}
+
+	for _, format := range opts.OutputFormats {
+		data, err := os.ReadFile(filepath.Join(opts.OutputDir, outputName+"."+format))
+		if err != nil {
+			return nil, fmt.Errorf("whisper wrote no %s file: %w", format, err)
+		}
+
+		if transcript.Files == nil {
+			transcript.Files = make(map[string]string, len(opts.OutputFormats))
+		}
+		transcript.Files[format] = string(data)
+	}
+
+	return transcript, nil
+}
+
+// DetectLanguage only runs whisper's language detection on the audio file,
+// without transcribing it. Whisper looks at the 30 seconds from offset.
+func (c *Client) DetectLanguage(ctx context.Context, audioPath, modelName string, offset time.Duration) (*Detection, error) {
+	modelPath := modelName
+	if !filepath.IsAbs(modelName) {
+		modelPath = filepath.Join(c.modelsDir, fmt.Sprintf("ggml-%s.bin", modelName))
+	}
+
+	args := []string{
+		"-m", modelPath,
+		"-f", audioPath,
+		"--language", "auto",
+		"--detect-language", // Exit after detecting the language
+		"--threads", "4",
+	}
+	if offset > 0 {
+		args = append(args, "--offset-t", strconv.FormatInt(offset.Milliseconds(), 10))
+	}
+
+	output, err := exec.CommandContext(ctx, c.whisperBinaryPath, args...).CombinedOutput()
 	if err != nil {
-		return "", fmt.Errorf("whisper transcription failed: %w\nOutput: %s", err, string(output))
+		return nil, fmt.Errorf("whisper language detection failed: %w\nOutput: %s", err, string(output))
+	}
+
+	match := detectedLanguageRegex.FindStringSubmatch(string(output))
+	if match == nil {
+		return nil, fmt.Errorf("whisper reported no language\nOutput: %s", string(output))
 	}
 
-	// The transcription is written to /tmp/ghospel_output.txt
-	// But whisper-cli also outputs the transcription to stdout, let's parse that
-	lines := strings.Split(string(output), "\n")
+	detection := &Detection{Language: match[1]}
+	if match[2] != "" {
+		detection.Probability, _ = strconv.ParseFloat(match[2], 64)
+	}
 
-	var transcription strings.Builder
+	return detection, nil
+}
+
+// diagnosticPrefixes start the lines of whisper's log worth showing: the
+// model, hardware, language detection and timings
+var diagnosticPrefixes = []string{
+	"whisper_init_from_file",
+	"whisper_model_load: type",
+	"whisper_model_load: model size",
+	"whisper_backend_init",
+	"system_info:",
+	"whisper_print_timings:",
+}
 
-	// Skip header lines and extract the actual transcription
-	inTranscription := false
+// parseDiagnostics picks the notable lines from whisper's log: those
+// starting with diagnosticPrefixes, the detected language and warnings
+func parseDiagnostics(log string) []string {
+	var lines []string
 
-	for _, line := range lines {
+	for _, line := range strings.Split(log, "\n") {
 		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
 
-		// Look for timestamp patterns or transcription content
-		if strings.Contains(line, "[00:") || inTranscription {
-			inTranscription = true
-			// Remove timestamp markers and extract text
-			if strings.Contains(line, "]") {
-				parts := strings.SplitN(line, "]", 2)
-				if len(parts) > 1 {
-					text := strings.TrimSpace(parts[1])
-					if text != "" {
-						transcription.WriteString(text)
-						transcription.WriteString(" ")
-					}
-				}
+		keep := strings.Contains(line, "auto-detected language") || strings.Contains(strings.ToLower(line), "warning")
+		for _, prefix := range diagnosticPrefixes {
+			if strings.HasPrefix(line, prefix) {
+				keep = true
+				break
 			}
 		}
+
+		if keep {
+			lines = append(lines, strings.Join(strings.Fields(line), " "))
+		}
+	}
+
+	return lines
+}
+
+// isStdinUnsupported reports whether whisper failed because it took "-" for
+// a file name, as builds without stdin support do
+func isStdinUnsupported(log string) bool {
+	return strings.Contains(log, "input file not found '-'") ||
+		strings.Contains(log, "failed to open '-'") ||
+		strings.Contains(log, "failed to read WAV file '-'")
+}
+
+// isTransientFailure reports whether a failed run looks worth retrying
+func isTransientFailure(ctx context.Context, err error, output string) bool {
+	var exitErr *exec.ExitError
+	if ctx.Err() != nil || !errors.As(err, &exitErr) {
+		return false
 	}
 
-	result := strings.TrimSpace(transcription.String())
-	if result == "" {
-		// Fallback: return the full output if we couldn't parse it
-		result = string(output)
+	if len(parseSegments(output)) > 0 {
+		return false
 	}
 
-	return result, nil
+	for _, marker := range permanentFailureMarkers {
+		if strings.Contains(output, marker) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// groupWords rebuilds sentence-sized segments from whisper's one-word
+// segments, keeping each word's timing. A segment ends at sentence
+// punctuation, a speaker turn or after maxWordsPerSegment words.
+func groupWords(wordSegments []Segment) []Segment {
+	var segments []Segment
+
+	var current *Segment
+
+	for _, ws := range wordSegments {
+		if current == nil {
+			segments = append(segments, Segment{Start: ws.Start})
+			current = &segments[len(segments)-1]
+		}
+
+		current.Words = append(current.Words, Word{Start: ws.Start, End: ws.End, Text: ws.Text})
+		current.End = ws.End
+
+		if ws.SpeakerTurn || len(current.Words) >= maxWordsPerSegment || strings.ContainsAny(ws.Text[len(ws.Text)-1:], ".?!") {
+			current.SpeakerTurn = ws.SpeakerTurn
+			current = nil
+		}
+	}
+
+	for i := range segments {
+		texts := make([]string, len(segments[i].Words))
+		for j, word := range segments[i].Words {
+			texts[j] = word.Text
+		}
+
+		segments[i].Text = strings.Join(texts, " ")
+	}
+
+	return segments
+}
+
+// parseSegments extracts timed segments from whisper's console output
+func parseSegments(output string) []Segment {
+	var segments []Segment
+
+	for _, line := range strings.Split(output, "\n") {
+		match := segmentRegex.FindStringSubmatch(strings.TrimSpace(line))
+		if match == nil {
+			continue
+		}
+
+		text := strings.TrimSpace(match[3])
+
+		speakerTurn := strings.Contains(text, speakerTurnMarker)
+		if speakerTurn {
+			text = strings.TrimSpace(strings.ReplaceAll(text, speakerTurnMarker, ""))
+		}
+
+		if text == "" {
+			// A turn marker on its own still ends the previous speaker's turn
+			if speakerTurn && len(segments) > 0 {
+				segments[len(segments)-1].SpeakerTurn = true
+			}
+
+			continue
+		}
+
+		segments = append(segments, Segment{
+			Start:       parseTimestamp(match[1]),
+			End:         parseTimestamp(match[2]),
+			Text:        text,
+			SpeakerTurn: speakerTurn,
+		})
+	}
+
+	return segments
+}
+
+// parseTimestamp parses whisper's HH:MM:SS.mmm timestamp format
+func parseTimestamp(ts string) time.Duration {
+	var hours, minutes, seconds, millis int
+	if _, err := fmt.Sscanf(ts, "%d:%d:%d.%d", &hours, &minutes, &seconds, &millis); err != nil {
+		return 0
+	}
+
+	return time.Duration(hours)*time.Hour +
+		time.Duration(minutes)*time.Minute +
+		time.Duration(seconds)*time.Second +
+		time.Duration(millis)*time.Millisecond
 }
 
 // IsAvailable checks if the whisper binary is available
@@ -123,3 +529,13 @@ func (c *Client) IsAvailable() bool {
 
 	return err == nil
 }
+
+// Check returns an error wrapping ErrNotAvailable that lists the locations
+// that were tried when the whisper binary can't be run
+func (c *Client) Check() error {
+	if c.IsAvailable() {
+		return nil
+	}
+
+	return fmt.Errorf("%w (tried %s)", ErrNotAvailable, strings.Join(c.lookedUp, ", "))
+}
@@ -0,0 +1,1000 @@
+// Package transcribe is the importable Go API behind the ghospel CLI.
+//
+// It converts an audio file to the format whisper.cpp expects, runs the
+// transcription and returns the timed segments. It never prints to stdout or
+// exits the process, so it can be embedded in other programs:
+//
+//	t := transcribe.New(transcribe.Config{ModelsDir: "/path/to/models"})
+//	result, err := t.Transcribe(ctx, "interview.mp3", transcribe.Options{Model: "base"})
+package transcribe
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pascalwhoop/ghospel/internal/audio"
+	"github.com/pascalwhoop/ghospel/internal/models"
+	"github.com/pascalwhoop/ghospel/internal/whisper"
+)
+
+// ErrModelNotFound is returned when the requested model file is not present
+// in the models directory. Models are not downloaded implicitly.
+var ErrModelNotFound = errors.New("model not found")
+
+// ErrDiarizationUnsupported is returned when Diarize is requested with a
+// model that can't detect speaker turns
+var ErrDiarizationUnsupported = errors.New("speaker diarization needs a tinydiarize (tdrz) model, e.g. small.en-tdrz")
+
+// MaxBeamSize is the largest beam size whisper supports
+const MaxBeamSize = whisper.MaxBeamSize
+
+// Whisper's defaults for the hallucination thresholds of Options
+const (
+	DefaultEntropyThreshold  = whisper.DefaultEntropyThreshold
+	DefaultLogprobThreshold  = whisper.DefaultLogprobThreshold
+	DefaultNoSpeechThreshold = whisper.DefaultNoSpeechThreshold
+)
+
+// Resampling qualities for Options.ResampleQuality
+const (
+	ResampleFast = audio.ResampleFast
+	ResampleHigh = audio.ResampleHigh
+	ResampleBest = audio.ResampleBest
+)
+
+// ResampleQualities lists the values accepted by Options.ResampleQuality
+var ResampleQualities = audio.ResampleQualities
+
+// ErrChannelOutOfRange is returned when Options.Channel exceeds the number
+// of channels in the file
+var ErrChannelOutOfRange = errors.New("channel out of range")
+
+// ErrFFmpegNotFound is returned by CheckFFmpeg when the configured ffmpeg
+// binary can't be run
+var ErrFFmpegNotFound = errors.New("ffmpeg not found")
+
+// ErrWhisperNotFound is returned by CheckWhisper when the whisper binary
+// can't be run
+var ErrWhisperNotFound = whisper.ErrNotAvailable
+
+// ErrNoAudio is returned for inputs without audio to transcribe, such as
+// empty files or mislabeled non-audio files, as opposed to files whisper
+// failed on
+var ErrNoAudio = audio.ErrNoAudioStream
+
+// ErrAudioTrackNotFound is returned when the input has no audio stream
+// matching Options.AudioTrack or Options.AudioLanguage
+var ErrAudioTrackNotFound = errors.New("audio track not found")
+
+// AudioExtensions lists the file extensions ghospel treats as audio by
+// default. ffmpeg reads many more formats; files are not checked by
+// extension when passed to Transcribe.
+var AudioExtensions = audio.DefaultExtensions
+
+// ErrStreamUnsupported is wrapped by errors from Options.Stream runs the
+// whisper build can't take audio on stdin for. Transcribe falls back to WAV
+// files on its own, see Result.StreamFallback.
+var ErrStreamUnsupported = whisper.ErrStdinUnsupported
+
+// ErrTransient is wrapped by errors from whisper runs that failed in a way
+// that may succeed when retried (non-zero exit without any transcript)
+var ErrTransient = whisper.ErrTransient
+
+// Config configures a Transcriber. Zero values fall back to sensible defaults.
+type Config struct {
+	ModelsDir   string // Directory containing ggml-<model>.bin files (default: ~/.whisper)
+	FFmpegPath  string // Path to the ffmpeg binary (default: ffmpeg on PATH)
+	WhisperPath string // Path to whisper-cli (default: auto-discovered)
+	TempDir     string // Parent of the per-transcriber directory for intermediate WAV files (default: ghospel in os.TempDir())
+}
+
+// Options configures a single transcription
+type Options struct {
+	Model    string // Model name, e.g. "base", or an absolute path to a ggml model file
+	Language string // Language code, or "auto" (default) to detect it
+	Prompt   string // Optional prompt to steer vocabulary and style
+
+	// Normalize applies loudness normalization before transcription, which
+	// helps quiet or unevenly leveled recordings
+	Normalize bool
+
+	// Denoise applies FFT noise reduction and HighPass (in Hz, 0 = off)
+	// removes low-frequency hum. Aggressive filtering can hurt accuracy.
+	Denoise  bool
+	HighPass int
+
+	// ResampleQuality selects the resampler used when converting to 16kHz,
+	// one of ResampleQualities. Empty is ResampleFast, ffmpeg's default;
+	// the higher qualities help archival material with a high sample rate.
+	ResampleQuality string
+
+	// Start and End limit transcription to a window of the file. End of 0
+	// means the end of the file. Segment timestamps stay relative to the
+	// original recording.
+	Start time.Duration
+	End   time.Duration
+
+	// Stream pipes ffmpeg's output directly into whisper instead of writing
+	// an intermediate WAV file. If the whisper binary can't read audio from
+	// stdin, the file-based path is used instead.
+	Stream bool
+
+	// KeepWAV keeps the converted WAV file whisper read instead of deleting
+	// it and lists it in Result.WAVPaths, e.g. to debug a bad transcript or
+	// feed the same audio to other tools. It takes precedence over Stream.
+	KeepWAV bool
+
+	// Diarize labels segments with the speaker. It needs a tinydiarize model,
+	// which only detects speaker turns: labels alternate between "Speaker 1"
+	// and "Speaker 2" at each turn rather than identifying voices.
+	Diarize bool
+
+	// WordTimestamps fills Segment.Words with per-word timing. Word times
+	// come from whisper's token timestamps and can drift by a few hundred
+	// milliseconds, especially around pauses.
+	WordTimestamps bool
+
+	// VAD detects long silences (2s or more) before transcription and only
+	// passes the speech in between to whisper, which saves time and avoids
+	// hallucinated repeats on silent stretches. Timestamps still refer to
+	// the original recording.
+	VAD bool
+
+	// BeamSize is the number of candidate transcripts whisper keeps while
+	// decoding (1 to MaxBeamSize, 0 = whisper's default of 5). Larger beams
+	// are slightly more accurate but slower.
+	BeamSize int
+
+	// Temperature is the sampling temperature (0 to 1). 0 decodes
+	// deterministically; higher values add randomness, which can get whisper
+	// out of repetition loops at the cost of consistency.
+	Temperature float64
+
+	// EntropyThreshold, LogprobThreshold and NoSpeechThreshold guard against
+	// hallucinated text on silence and music. Whisper decodes a segment
+	// again at a higher temperature when its entropy is above the entropy
+	// threshold (repetitive text) or its average log probability below the
+	// logprob threshold. It drops a segment as silence when the no-speech
+	// probability is above its threshold and the log probability is low
+	// too. 0 uses whisper's defaults, DefaultEntropyThreshold and so on.
+	EntropyThreshold  float64
+	LogprobThreshold  float64
+	NoSpeechThreshold float64
+
+	// NoFallback keeps the first decoding of each segment instead of
+	// decoding again at higher temperatures, which is faster and avoids
+	// creative rewrites of hard passages
+	NoFallback bool
+
+	// SuppressNonSpeech keeps whisper from emitting non-speech tokens such
+	// as "♪" or "[Music]", common on music-only stretches
+	SuppressNonSpeech bool
+
+	// NativeSubtitles has whisper write its own SRT and WebVTT files, with
+	// its exact cue timings, and returns them in Result.NativeSubtitles.
+	// They are only made when whisper's timeline and text are those of the
+	// segments: not with Start, VAD, SplitChannels, Diarize or
+	// WordTimestamps, which rebuild them from whisper's output.
+	NativeSubtitles bool
+
+	// Channel transcribes a single channel (1-based) of a multi-channel
+	// recording instead of the mono downmix of all channels, 0 downmixes.
+	Channel int
+
+	// SplitChannels transcribes every channel separately and labels each
+	// segment's Speaker with its channel ("Channel 1", "Channel 2", ...).
+	// It is a cheap alternative to Diarize for interviews recorded with one
+	// speaker per channel. Mono files are transcribed as usual.
+	SplitChannels bool
+
+	// AudioTrack picks the audio stream (1-based) of inputs with several,
+	// such as a film with one track per language. AudioLanguage picks the
+	// first stream tagged with that language instead, e.g. "eng"; a
+	// two-letter code also matches tags starting with it. By default the
+	// first audio stream is transcribed.
+	AudioTrack    int
+	AudioLanguage string
+}
+
+// AudioTrack describes the audio stream of the input that was transcribed
+type AudioTrack struct {
+	Number   int    // 1-based position among the input's audio streams
+	Count    int    // Number of audio streams in the input, 0 if unknown
+	Language string // Language tag such as "eng", empty if untagged
+}
+
+// Segment is a timed piece of the transcript
+type Segment struct {
+	Start   time.Duration
+	End     time.Duration
+	Text    string
+	Speaker string // Speaker label, only set when diarizing
+	Words   []Word // Per-word timing, only set with WordTimestamps
+}
+
+// Word is a single word of a segment with its timing
+type Word struct {
+	Start time.Duration
+	End   time.Duration
+	Text  string
+}
+
+// Result holds the transcript of one file and statistics about the run
+type Result struct {
+	Text     string        // Full transcript as returned by whisper
+	Segments []Segment     // Timed segments making up the transcript
+	Duration time.Duration // Duration of the source audio
+	Language string        // Language of the transcript, detected when Options.Language is "auto"
+	Elapsed  time.Duration // Wall-clock time spent transcribing
+
+	// AudioTrack is the audio stream that was transcribed
+	AudioTrack AudioTrack
+
+	// WAVPaths are the WAV files whisper read, one per channel run, only
+	// set with Options.KeepWAV. A WAV input that needed no conversion is
+	// listed as is.
+	WAVPaths []string
+
+	// Diagnostics are whisper's notable log lines, such as model loading,
+	// warnings and timings, to diagnose slow runs. They never end up in the
+	// transcript.
+	Diagnostics []string
+
+	// NativeSubtitles are whisper's own subtitle files by format, "srt" and
+	// "vtt", only set with Options.NativeSubtitles
+	NativeSubtitles map[string]string
+
+	// StreamFallback is set when Options.Stream was asked for but this run
+	// found that whisper can't read stdin and used WAV files instead. Later
+	// runs of the Transcriber use WAV files right away.
+	StreamFallback bool
+}
+
+// Chapter is a titled section of the audio, read by Chapters and written by
+// EmbedTranscript
+type Chapter struct {
+	Start time.Duration
+	End   time.Duration
+	Title string
+}
+
+// Transcriber transcribes audio files with whisper.cpp
+type Transcriber struct {
+	modelsDir      string
+	audioProcessor *audio.Processor
+	whisperClient  *whisper.Client
+
+	// streamUnsupported is set once whisper turned out not to read stdin,
+	// so later files go straight to the file-based path
+	streamUnsupported atomic.Bool
+}
+
+// New creates a new Transcriber
+func New(cfg Config) *Transcriber {
+	if cfg.ModelsDir == "" {
+		homeDir, _ := os.UserHomeDir()
+		cfg.ModelsDir = filepath.Join(homeDir, ".whisper")
+	}
+
+	return &Transcriber{
+		modelsDir:      cfg.ModelsDir,
+		audioProcessor: audio.NewProcessor(cfg.FFmpegPath, cfg.TempDir),
+		whisperClient:  whisper.NewClient(cfg.WhisperPath, cfg.ModelsDir),
+	}
+}
+
+// Transcribe transcribes the audio file at path
+func (t *Transcriber) Transcribe(ctx context.Context, path string, opts Options) (*Result, error) {
+	startTime := time.Now()
+
+	if opts.Model == "" {
+		return nil, errors.New("no model specified")
+	}
+
+	opts.Model = models.Resolve(opts.Model)
+
+	if opts.BeamSize < 0 || opts.BeamSize > MaxBeamSize {
+		return nil, fmt.Errorf("invalid beam size %d (must be between 1 and %d)", opts.BeamSize, MaxBeamSize)
+	}
+
+	if opts.Temperature < 0 || opts.Temperature > 1 {
+		return nil, fmt.Errorf("invalid temperature %g (must be between 0 and 1)", opts.Temperature)
+	}
+
+	if opts.EntropyThreshold < 0 {
+		return nil, fmt.Errorf("invalid entropy threshold %g (must be positive)", opts.EntropyThreshold)
+	}
+
+	if opts.LogprobThreshold > 0 {
+		return nil, fmt.Errorf("invalid logprob threshold %g (must be negative)", opts.LogprobThreshold)
+	}
+
+	if opts.NoSpeechThreshold < 0 || opts.NoSpeechThreshold > 1 {
+		return nil, fmt.Errorf("invalid no-speech threshold %g (must be between 0 and 1)", opts.NoSpeechThreshold)
+	}
+
+	if opts.Diarize && !models.SupportsDiarization(opts.Model) {
+		return nil, fmt.Errorf("%w (got %s)", ErrDiarizationUnsupported, opts.Model)
+	}
+
+	if opts.Channel < 0 {
+		return nil, fmt.Errorf("invalid channel %d", opts.Channel)
+	}
+
+	if opts.ResampleQuality != "" && !slices.Contains(ResampleQualities, opts.ResampleQuality) {
+		return nil, fmt.Errorf("invalid resample quality %q (valid: %s)", opts.ResampleQuality,
+			strings.Join(ResampleQualities, ", "))
+	}
+
+	if opts.SplitChannels && (opts.Channel > 0 || opts.Diarize) {
+		return nil, errors.New("SplitChannels can't be combined with Channel or Diarize")
+	}
+
+	if err := t.checkModel(opts.Model); err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %s: %w", path, err)
+	}
+
+	if stat.Size() == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrNoAudio, filepath.Base(path))
+	}
+
+	// Get audio duration before processing
+	audioInfo, err := t.audioProcessor.GetAudioInfo(ctx, path)
+	if errors.Is(err, ErrNoAudio) {
+		return nil, fmt.Errorf("%w in %s, is it an audio file?", ErrNoAudio, filepath.Base(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audio info: %w", err)
+	}
+
+	duration := parseAudioDuration(audioInfo["duration"])
+	channelCount, _ := strconv.Atoi(audioInfo["channels"])
+
+	track, err := t.selectAudioTrack(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if track.Channels > 0 {
+		channelCount = track.Channels
+	}
+
+	if opts.Channel > 0 && channelCount > 0 && opts.Channel > channelCount {
+		return nil, fmt.Errorf("%w: %s has %d channel(s), got channel %d", ErrChannelOutOfRange, filepath.Base(path), channelCount, opts.Channel)
+	}
+
+	if err := validateRange(opts.Start, opts.End, duration); err != nil {
+		return nil, err
+	}
+
+	var rangeDuration time.Duration
+	if opts.End > 0 {
+		rangeDuration = opts.End - opts.Start
+	}
+
+	convertOpts := audio.ConvertOptions{
+		Normalize: opts.Normalize,
+		Denoise:   opts.Denoise,
+		HighPass:  opts.HighPass,
+		Start:     opts.Start,
+		Duration:  rangeDuration,
+		Channel:   opts.Channel,
+		Track:     track.Index,
+		Resample:  opts.ResampleQuality,
+	}
+
+	// Length of the audio actually transcribed
+	windowDuration := duration
+	switch {
+	case rangeDuration > 0:
+		windowDuration = rangeDuration
+	case opts.Start > 0 && duration > 0:
+		windowDuration -= opts.Start
+	}
+
+	if opts.VAD && windowDuration > 0 {
+		regions, err := t.audioProcessor.DetectSpeech(ctx, path, convertOpts, windowDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(regions) == 0 {
+			// Nothing but silence, no need to run whisper at all
+			return &Result{Segments: []Segment{}, Duration: windowDuration, Elapsed: time.Since(startTime)}, nil
+		}
+
+		if len(regions) > 1 || regions[0].Start > 0 || regions[0].End < windowDuration {
+			convertOpts.Keep = regions
+		}
+	}
+
+	// Whisper only saw the extracted window, possibly with silences cut
+	// out, so map its timestamps back to the original timeline
+	toSource := func(d time.Duration) time.Duration {
+		return audio.MapToSource(convertOpts.Keep, d) + opts.Start
+	}
+
+	whisperOpts := whisper.Options{
+		Language:          opts.Language,
+		Prompt:            opts.Prompt,
+		TinyDiarize:       opts.Diarize,
+		WordTimestamps:    opts.WordTimestamps,
+		BeamSize:          opts.BeamSize,
+		Temperature:       opts.Temperature,
+		EntropyThreshold:  opts.EntropyThreshold,
+		LogprobThreshold:  opts.LogprobThreshold,
+		NoSpeechThreshold: opts.NoSpeechThreshold,
+		NoFallback:        opts.NoFallback,
+		SuppressNonSpeech: opts.SuppressNonSpeech,
+	}
+
+	// Each channel is a separate whisper run; by default there's just the
+	// one for the mono downmix or the selected channel
+	channels := []int{opts.Channel}
+	if opts.SplitChannels && channelCount > 1 {
+		channels = channels[:0]
+		for ch := 1; ch <= channelCount; ch++ {
+			channels = append(channels, ch)
+		}
+	}
+
+	// Whisper's own subtitle files only match the segments when it saw the
+	// recording from the start, in one piece, and its segments are kept
+	if opts.NativeSubtitles && opts.Start == 0 && len(convertOpts.Keep) == 0 && len(channels) == 1 &&
+		!opts.Diarize && !opts.WordTimestamps {
+		outputDir, err := t.audioProcessor.MkdirTemp("whisper-*")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(outputDir)
+
+		whisperOpts.OutputFormats = nativeSubtitleFormats
+		whisperOpts.OutputDir = outputDir
+	}
+
+	var segments []Segment
+	var language string
+	var wavPaths []string
+	var diagnostics []string
+	var nativeSubtitles map[string]string
+	var streamFallback bool
+
+	for _, channel := range channels {
+		convertOpts.Channel = channel
+
+		transcript, wavPath, fellBack, err := t.run(ctx, path, opts, convertOpts, whisperOpts)
+		if err != nil {
+			return nil, err
+		}
+
+		streamFallback = streamFallback || fellBack
+
+		if wavPath != "" {
+			wavPaths = append(wavPaths, wavPath)
+		}
+
+		if language == "" {
+			language = transcript.Language
+		}
+
+		diagnostics = append(diagnostics, transcript.Diagnostics...)
+		nativeSubtitles = transcript.Files
+
+		speaker := 1
+		for _, seg := range transcript.Segments {
+			segment := Segment{Start: toSource(seg.Start), End: toSource(seg.End), Text: seg.Text}
+
+			for _, word := range seg.Words {
+				segment.Words = append(segment.Words, Word{
+					Start: toSource(word.Start),
+					End:   toSource(word.End),
+					Text:  word.Text,
+				})
+			}
+
+			switch {
+			case len(channels) > 1:
+				segment.Speaker = fmt.Sprintf("Channel %d", channel)
+			case opts.Diarize:
+				segment.Speaker = fmt.Sprintf("Speaker %d", speaker)
+				if seg.SpeakerTurn {
+					speaker = 3 - speaker
+				}
+			}
+
+			segments = append(segments, segment)
+		}
+	}
+
+	if len(channels) > 1 {
+		// Interleave the channels into one conversation
+		sort.SliceStable(segments, func(i, j int) bool {
+			return segments[i].Start < segments[j].Start
+		})
+	}
+
+	if segments == nil {
+		segments = []Segment{}
+	}
+
+	texts := make([]string, len(segments))
+	for i, seg := range segments {
+		texts[i] = seg.Text
+	}
+
+	return &Result{
+		Text:        strings.Join(texts, " "),
+		Segments:    segments,
+		Duration:    windowDuration,
+		Language:    language,
+		Elapsed:     time.Since(startTime),
+		WAVPaths:    wavPaths,
+		Diagnostics: diagnostics,
+		AudioTrack: AudioTrack{
+			Number:   track.Index + 1,
+			Count:    track.count,
+			Language: track.Language,
+		},
+		NativeSubtitles: nativeSubtitles,
+		StreamFallback:  streamFallback,
+	}, nil
+}
+
+// nativeSubtitleFormats are the files whisper writes for
+// Options.NativeSubtitles
+var nativeSubtitleFormats = []string{"srt", "vtt"}
+
+// selectedTrack is the audio stream picked for transcription and the number
+// of streams it was picked from
+type selectedTrack struct {
+	audio.AudioStream
+	count int
+}
+
+// selectAudioTrack picks the audio stream to transcribe according to
+// opts.AudioTrack and opts.AudioLanguage, the first one by default
+func (t *Transcriber) selectAudioTrack(path string, opts Options) (selectedTrack, error) {
+	if opts.AudioTrack < 0 {
+		return selectedTrack{}, fmt.Errorf("invalid audio track %d", opts.AudioTrack)
+	}
+
+	streams, err := t.audioProcessor.AudioStreams(path)
+	if err != nil || len(streams) == 0 {
+		// Without ffmpeg a 16kHz WAV input is still transcribed as it is
+		if opts.AudioTrack > 1 || opts.AudioLanguage != "" {
+			return selectedTrack{}, fmt.Errorf("%w: cannot list the audio tracks of %s", ErrAudioTrackNotFound, filepath.Base(path))
+		}
+
+		return selectedTrack{}, nil
+	}
+
+	if opts.AudioLanguage != "" {
+		language := strings.ToLower(opts.AudioLanguage)
+		for _, stream := range streams {
+			tag := strings.ToLower(stream.Language)
+			if tag == language || (len(language) == 2 && strings.HasPrefix(tag, language)) {
+				return selectedTrack{AudioStream: stream, count: len(streams)}, nil
+			}
+		}
+
+		return selectedTrack{}, fmt.Errorf("%w: %s has no %s audio track (available: %s)",
+			ErrAudioTrackNotFound, filepath.Base(path), opts.AudioLanguage, describeTracks(streams))
+	}
+
+	index := max(opts.AudioTrack, 1) - 1
+	if index >= len(streams) {
+		return selectedTrack{}, fmt.Errorf("%w: %s has %d audio track(s), got track %d (available: %s)",
+			ErrAudioTrackNotFound, filepath.Base(path), len(streams), opts.AudioTrack, describeTracks(streams))
+	}
+
+	return selectedTrack{AudioStream: streams[index], count: len(streams)}, nil
+}
+
+// checkModel returns an error wrapping ErrModelNotFound unless the model
+// file exists
+func (t *Transcriber) checkModel(model string) error {
+	if models.IsCustomPath(model) {
+		if err := models.ValidateModelFile(model); err != nil {
+			return fmt.Errorf("%w: %w", ErrModelNotFound, err)
+		}
+
+		return nil
+	}
+
+	modelPath := filepath.Join(t.modelsDir, fmt.Sprintf("ggml-%s.bin", model))
+	if _, err := os.Stat(modelPath); os.IsNotExist(err) {
+		return fmt.Errorf("%w: %s (expected at %s)", ErrModelNotFound, model, modelPath)
+	}
+
+	return nil
+}
+
+// describeTracks lists audio streams as "1 (eng), 2 (ger)"
+func describeTracks(streams []audio.AudioStream) string {
+	tracks := make([]string, len(streams))
+	for i, stream := range streams {
+		tracks[i] = strconv.Itoa(stream.Index + 1)
+		if stream.Language != "" {
+			tracks[i] += " (" + stream.Language + ")"
+		}
+	}
+
+	return strings.Join(tracks, ", ")
+}
+
+// DefaultDetectionSample is how much audio DetectLanguage looks at unless
+// Options.End is set; whisper never uses more than 30 seconds to detect
+const DefaultDetectionSample = 30 * time.Second
+
+// LanguageDetection is the language detected in an audio file
+type LanguageDetection struct {
+	Language    string        // Language code, e.g. "en"
+	Probability float64       // Whisper's confidence, 0 to 1, 0 if not reported
+	Sample      time.Duration // Length of the audio the language was detected in
+}
+
+// DetectLanguage detects the spoken language from a sample of the audio
+// file without transcribing it, which takes a fraction of a full run. The
+// sample starts at Options.Start and ends at Options.End, or after
+// DefaultDetectionSample. Model, Channel and the audio track options apply
+// as for Transcribe; other options are ignored.
+func (t *Transcriber) DetectLanguage(ctx context.Context, path string, opts Options) (*LanguageDetection, error) {
+	if opts.Model == "" {
+		return nil, errors.New("no model specified")
+	}
+
+	opts.Model = models.Resolve(opts.Model)
+
+	if err := t.checkModel(opts.Model); err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot access %s: %w", path, err)
+	}
+
+	if stat.Size() == 0 {
+		return nil, fmt.Errorf("%w: %s is empty", ErrNoAudio, filepath.Base(path))
+	}
+
+	// The header is enough, decoding the whole file would defeat the purpose
+	info, err := t.audioProcessor.ProbeAudioInfo(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if info["audio_info"] == "" && info["duration"] != "" {
+		return nil, fmt.Errorf("%w in %s, is it an audio file?", ErrNoAudio, filepath.Base(path))
+	}
+
+	track, err := t.selectAudioTrack(path, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	end := opts.End
+	if end == 0 {
+		end = opts.Start + DefaultDetectionSample
+	}
+
+	duration := parseAudioDuration(info["duration"])
+	if duration > 0 {
+		end = min(end, duration)
+	}
+
+	if err := validateRange(opts.Start, end, duration); err != nil {
+		return nil, err
+	}
+
+	// Whisper reads 16kHz mono WAV files as they are and seeks itself,
+	// anything else only has the sample converted
+	wavPath, offset := path, opts.Start
+	if !audio.IsWhisperWav(path) || opts.Channel > 0 || track.Index > 0 {
+		convertOpts := audio.ConvertOptions{
+			Start:    opts.Start,
+			Duration: end - opts.Start,
+			Channel:  opts.Channel,
+			Track:    track.Index,
+		}
+
+		if wavPath, err = t.audioProcessor.ConvertToWav(ctx, path, convertOpts); err != nil {
+			return nil, fmt.Errorf("audio conversion failed: %w", err)
+		}
+		defer os.Remove(wavPath)
+
+		offset = 0
+	}
+
+	detection, err := t.whisperClient.DetectLanguage(ctx, wavPath, opts.Model, offset)
+	if err != nil {
+		return nil, err
+	}
+
+	return &LanguageDetection{
+		Language:    detection.Language,
+		Probability: detection.Probability,
+		Sample:      end - opts.Start,
+	}, nil
+}
+
+// EmbedTranscript writes a copy of the audio file at path to outputPath with
+// the transcript stored in its lyrics tag and the chapters, if any, added.
+// The audio itself is copied without re-encoding. An outputPath equal to
+// path replaces the original once the tagged copy is complete.
+func (t *Transcriber) EmbedTranscript(ctx context.Context, path, outputPath, text string, chapters []Chapter) error {
+	target := outputPath
+	if outputPath == path {
+		// ffmpeg can't write over its input, tag a hidden sibling first
+		target = filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tagged"+filepath.Ext(path))
+	}
+
+	audioChapters := make([]audio.Chapter, len(chapters))
+	for i, chapter := range chapters {
+		audioChapters[i] = audio.Chapter{Start: chapter.Start, End: chapter.End, Title: chapter.Title}
+	}
+
+	tags := map[string]string{"lyrics": text}
+	if err := t.audioProcessor.EmbedMetadata(ctx, path, target, tags, audioChapters); err != nil {
+		return err
+	}
+
+	if target != outputPath {
+		if err := os.Rename(target, outputPath); err != nil {
+			os.Remove(target)
+
+			return fmt.Errorf("failed to replace %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// run transcribes one conversion of the input, streaming it into whisper
+// when requested and falling back to an intermediate WAV file when whisper
+// can't read stdin, which the returned bool reports. With KeepWAV it returns
+// the path of the WAV file whisper read.
+func (t *Transcriber) run(ctx context.Context, path string, opts Options, convertOpts audio.ConvertOptions, whisperOpts whisper.Options) (*whisper.Transcript, string, bool, error) {
+	var fellBack bool
+
+	// Inputs whisper reads as they are need no stream, nor ffmpeg
+	passThrough := audio.IsWhisperWav(path) && !convertOpts.NeedsConversion()
+
+	if opts.Stream && !opts.KeepWAV && !passThrough && !t.streamUnsupported.Load() {
+		transcript, err := t.transcribeStream(ctx, path, opts.Model, convertOpts, whisperOpts)
+		if err == nil {
+			return transcript, "", false, nil
+		}
+
+		if ctx.Err() != nil {
+			return nil, "", false, fmt.Errorf("transcription failed: %w", ctx.Err())
+		}
+
+		// Only a whisper without stdin support is worth another try from a
+		// WAV file, any other failure would just happen again
+		if !errors.Is(err, ErrStreamUnsupported) {
+			return nil, "", false, err
+		}
+
+		t.streamUnsupported.Store(true)
+		fellBack = true
+	}
+
+	transcript, wavPath, err := t.transcribeFile(ctx, path, opts.Model, opts.KeepWAV, convertOpts, whisperOpts)
+
+	return transcript, wavPath, fellBack, err
+}
+
+// CheckFFmpeg returns an error wrapping ErrFFmpegNotFound when ffmpeg can't
+// be run. Only 16kHz mono WAV inputs (see NeedsFFmpeg) work without it.
+func (t *Transcriber) CheckFFmpeg() error {
+	if !t.audioProcessor.IsFFmpegAvailable() {
+		return fmt.Errorf("%w at %s", ErrFFmpegNotFound, t.audioProcessor.FFmpegPath())
+	}
+
+	return nil
+}
+
+// CheckWhisper returns an error wrapping ErrWhisperNotFound, listing the
+// locations that were tried, when the whisper binary can't be run
+func (t *Transcriber) CheckWhisper() error {
+	return t.whisperClient.Check()
+}
+
+// NeedsFFmpeg reports whether transcribing the file at path with opts runs
+// ffmpeg, which is the case unless it is a 16kHz mono WAV file that needs
+// no filtering or trimming
+func NeedsFFmpeg(path string, opts Options) bool {
+	if !audio.IsWhisperWav(path) {
+		return true
+	}
+
+	return opts.Normalize || opts.Denoise || opts.HighPass > 0 || opts.Start > 0 || opts.End > 0 ||
+		opts.VAD || opts.Channel > 0 || opts.SplitChannels || opts.AudioTrack > 1 || opts.AudioLanguage != ""
+}
+
+// Close removes the intermediate files of this transcriber, including those
+// kept with Options.KeepWAV and any left behind by interrupted runs. Each
+// transcriber works in its own directory below Config.TempDir.
+func (t *Transcriber) Close() error {
+	return t.audioProcessor.Close()
+}
+
+// TempDir returns the directory holding this transcriber's intermediate
+// files, empty if none were written yet
+func (t *Transcriber) TempDir() string {
+	return t.audioProcessor.TempDir()
+}
+
+// Chapters returns the chapters stored in the audio file at path, e.g. by
+// audiobooks and podcasts, in order. Files without chapters return none.
+// Use a chapter's Start and End as Options.Start and Options.End to
+// transcribe it on its own.
+func (t *Transcriber) Chapters(path string) ([]Chapter, error) {
+	audioChapters, err := t.audioProcessor.Chapters(path)
+	if err != nil {
+		return nil, err
+	}
+
+	chapters := make([]Chapter, 0, len(audioChapters))
+	for _, chapter := range audioChapters {
+		if chapter.End <= chapter.Start {
+			continue
+		}
+
+		chapters = append(chapters, Chapter{Start: chapter.Start, End: chapter.End, Title: chapter.Title})
+	}
+
+	return chapters, nil
+}
+
+// AudioDuration returns the length of the audio file at path, read from its
+// header. It is cheap enough to call for every file of a batch upfront.
+func (t *Transcriber) AudioDuration(path string) (time.Duration, error) {
+	info, err := t.audioProcessor.ProbeAudioInfo(path)
+	if err != nil {
+		return 0, err
+	}
+
+	duration := parseAudioDuration(info["duration"])
+	if duration == 0 {
+		return 0, fmt.Errorf("could not determine duration of %s", path)
+	}
+
+	return duration, nil
+}
+
+// transcribeFile converts the input to a WAV file if needed and runs whisper
+// on it. The WAV file is removed afterwards unless keep is set, in which
+// case its path is returned.
+func (t *Transcriber) transcribeFile(ctx context.Context, path, model string, keep bool, convertOpts audio.ConvertOptions, whisperOpts whisper.Options) (*whisper.Transcript, string, error) {
+	// Convert audio to WAV using FFmpeg if needed
+	wavPath, needsCleanup, err := t.prepareAudioFile(ctx, path, convertOpts)
+	if err != nil {
+		return nil, "", fmt.Errorf("audio preparation failed: %w", err)
+	}
+
+	// Clean up temporary WAV file if needed
+	if needsCleanup && !keep {
+		defer t.audioProcessor.Cleanup(wavPath)
+	}
+
+	// Run Whisper inference
+	transcript, err := t.whisperClient.Transcribe(ctx, wavPath, model, whisperOpts)
+	if err != nil {
+		return nil, "", fmt.Errorf("transcription failed: %w", err)
+	}
+
+	if !keep {
+		wavPath = ""
+	}
+
+	return transcript, wavPath, nil
+}
+
+// transcribeStream pipes ffmpeg's WAV output into whisper's stdin
+func (t *Transcriber) transcribeStream(ctx context.Context, path, model string, convertOpts audio.ConvertOptions, whisperOpts whisper.Options) (*whisper.Transcript, error) {
+	ffmpeg, stdout, ffmpegErr, err := t.audioProcessor.StreamWav(ctx, path, convertOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	transcript, whisperErr := t.whisperClient.TranscribeStream(ctx, stdout, model, whisperOpts)
+
+	// Drain whatever whisper didn't read so ffmpeg can exit
+	_, _ = io.Copy(io.Discard, stdout)
+
+	if err := ffmpeg.Wait(); err != nil {
+		return nil, fmt.Errorf("ffmpeg conversion failed: %w\nOutput: %s", err, ffmpegErr.String())
+	}
+
+	if whisperErr != nil {
+		return nil, fmt.Errorf("transcription failed: %w", whisperErr)
+	}
+
+	return transcript, nil
+}
+
+// prepareAudioFile converts audio to WAV format if needed
+func (t *Transcriber) prepareAudioFile(ctx context.Context, inputPath string, convertOpts audio.ConvertOptions) (string, bool, error) {
+	// Whisper reads 16kHz mono WAV files as they are
+	if audio.IsWhisperWav(inputPath) && !convertOpts.NeedsConversion() {
+		return inputPath, false, nil
+	}
+
+	wavPath, err := t.audioProcessor.ConvertToWav(ctx, inputPath, convertOpts)
+	if err != nil {
+		return "", false, err
+	}
+
+	return wavPath, true, nil
+}
+
+// validateRange checks a requested time range against the file's duration.
+// A zero duration means ffmpeg couldn't report it, so only ordering is checked.
+func validateRange(start, end, duration time.Duration) error {
+	if start < 0 || end < 0 {
+		return errors.New("time range cannot be negative")
+	}
+
+	if end > 0 && start >= end {
+		return fmt.Errorf("range start %s must be before end %s", start, end)
+	}
+
+	if duration <= 0 {
+		return nil
+	}
+
+	if start >= duration {
+		return fmt.Errorf("range start %s is beyond the end of the file (%s)", start, duration.Round(time.Second))
+	}
+
+	if end > duration {
+		return fmt.Errorf("range end %s is beyond the end of the file (%s)", end, duration.Round(time.Second))
+	}
+
+	return nil
+}
+
+// parseAudioDuration parses FFmpeg duration format (HH:MM:SS.ms) into time.Duration
+func parseAudioDuration(durationStr string) time.Duration {
+	if durationStr == "" {
+		return 0
+	}
+
+	// Parse format like "00:01:23.45"
+	parts := strings.Split(durationStr, ":")
+	if len(parts) != 3 {
+		return 0
+	}
+
+	// Extract hours, minutes, and seconds
+	var hours, minutes, seconds float64
+	if h, err := time.ParseDuration(parts[0] + "h"); err == nil {
+		hours = h.Seconds()
+	}
+	if m, err := time.ParseDuration(parts[1] + "m"); err == nil {
+		minutes = m.Seconds()
+	}
+	if s, err := time.ParseDuration(parts[2] + "s"); err == nil {
+		seconds = s.Seconds()
+	}
+
+	totalSeconds := hours + minutes + seconds
+	return time.Duration(totalSeconds * float64(time.Second))
+}